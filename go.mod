@@ -11,10 +11,12 @@ require (
 	github.com/karrick/tparse/v2 v2.8.2
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/pion/dtls/v2 v2.0.10-0.20210502094952-3dc563b9aede
+	github.com/pion/udp v0.1.1
 	github.com/plgd-dev/cloud v1.1.3-0.20210614184948-03b478890cfe
 	github.com/plgd-dev/go-coap/v2 v2.4.1-0.20210716104741-9c45e5c8f566
 	github.com/plgd-dev/kit v0.0.0-20210517131053-7dfd49bb6277
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/atomic v1.7.0
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b
 	google.golang.org/grpc v1.37.1
 )
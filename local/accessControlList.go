@@ -0,0 +1,107 @@
+package local
+
+import (
+	"context"
+	"fmt"
+
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/local/core"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/schema/acl"
+)
+
+const accessControlListHref = "/oic/sec/acl2"
+
+// GetAccessControlList returns the access control list of the owned device.
+func (c *Client) GetAccessControlList(ctx context.Context, deviceID string) (acl.Response, error) {
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return acl.Response{}, err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetSecureResourceLink(links, accessControlListHref)
+	if err != nil {
+		return acl.Response{}, err
+	}
+	var resp acl.Response
+	err = d.GetResourceWithCodec(ctx, link, codecOcf.VNDOCFCBORCodec{}, &resp)
+	if err != nil {
+		return acl.Response{}, fmt.Errorf("cannot get access control list of device %v: %w", deviceID, err)
+	}
+	return resp, nil
+}
+
+func addAccessControls(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink, accessControls []acl.AccessControl) error {
+	if len(accessControls) == 0 {
+		return nil
+	}
+	return p.UpdateResource(ctx, link, acl.UpdateRequest{
+		AccessControlList: accessControls,
+	}, nil)
+}
+
+func removeAccessControls(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink, accessControls []acl.AccessControl) error {
+	for _, ac := range accessControls {
+		err := p.DeleteResource(ctx, link, nil, kitNetCoap.WithAccessControlId(ac.ID))
+		if err != nil {
+			return fmt.Errorf("cannot remove access control %v: %w", ac.ID, err)
+		}
+	}
+	return nil
+}
+
+// AddAccessControls appends access controls to the access control list of the device.
+func (c *Client) AddAccessControls(ctx context.Context, deviceID string, accessControls ...acl.AccessControl) error {
+	return c.provisionDevice(ctx, deviceID, accessControlListHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		return addAccessControls(ctx, p, link, accessControls)
+	})
+}
+
+// RemoveAccessControl removes the access control identified by ID from the access control list of the device.
+func (c *Client) RemoveAccessControl(ctx context.Context, deviceID string, accessControlID int) error {
+	return c.provisionDevice(ctx, deviceID, accessControlListHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		return removeAccessControls(ctx, p, link, []acl.AccessControl{{ID: accessControlID}})
+	})
+}
+
+// RemoveAccessControlsBySubject removes all access controls of the subject from the access control list of the device.
+func (c *Client) RemoveAccessControlsBySubject(ctx context.Context, deviceID string, subject acl.Subject) error {
+	return c.provisionDevice(ctx, deviceID, accessControlListHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		var current acl.Response
+		err := p.GetResource(ctx, link, &current)
+		if err != nil {
+			return err
+		}
+		remove := make([]acl.AccessControl, 0, len(current.AccessControlList))
+		for _, ac := range current.AccessControlList {
+			if ac.Subject.Equal(subject) {
+				remove = append(remove, ac)
+			}
+		}
+		return removeAccessControls(ctx, p, link, remove)
+	})
+}
+
+// ApplyAccessControlList makes the access control list of the device equal to accessControls.
+// Only access controls which differ are removed or added, IDs of accessControls are ignored. New access controls
+// are added before old ones are removed, so the device doesn't lose the access when the update fails.
+func (c *Client) ApplyAccessControlList(ctx context.Context, deviceID string, accessControls []acl.AccessControl) error {
+	return c.provisionDevice(ctx, deviceID, accessControlListHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		var current acl.Response
+		err := p.GetResource(ctx, link, &current)
+		if err != nil {
+			return err
+		}
+		remove, add := acl.Diff(current.AccessControlList, accessControls)
+		for i := range add {
+			add[i].ID = 0
+		}
+		err = addAccessControls(ctx, p, link, add)
+		if err != nil {
+			return err
+		}
+		return removeAccessControls(ctx, p, link, remove)
+	})
+}
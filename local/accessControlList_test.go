@@ -0,0 +1,120 @@
+package local_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/sdk/schema/acl"
+	"github.com/plgd-dev/sdk/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AccessControlList(t *testing.T) {
+	dev := NewTestDevice(t)
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	deviceID, err := c.OwnDevice(ctx, dev.ID())
+	require.NoError(t, err)
+	defer func() {
+		err := c.DisownDevice(ctx, deviceID)
+		require.NoError(t, err)
+	}()
+
+	subject := acl.Subject{
+		Subject_Device: &acl.Subject_Device{
+			DeviceID: "00000000-0000-0000-0000-000000000002",
+		},
+	}
+	ac := acl.AccessControl{
+		Permission: acl.Permission_READ,
+		Subject:    subject,
+		Resources: []acl.Resource{
+			{
+				Href:       "/light/1",
+				Interfaces: []string{"*"},
+			},
+		},
+	}
+	countSubject := func(list acl.Response) int {
+		var n int
+		for _, v := range list.AccessControlList {
+			if v.Subject.Equal(subject) {
+				n++
+			}
+		}
+		return n
+	}
+
+	orig, err := c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.NotEmpty(t, orig.AccessControlList)
+
+	err = c.AddAccessControls(ctx, deviceID, ac, ac)
+	require.NoError(t, err)
+	got, err := c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.Equal(t, 2, countSubject(got))
+
+	err = c.RemoveAccessControlsBySubject(ctx, deviceID, subject)
+	require.NoError(t, err)
+	got, err = c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.Equal(t, 0, countSubject(got))
+
+	err = c.ApplyAccessControlList(ctx, deviceID, append(orig.AccessControlList, ac))
+	require.NoError(t, err)
+	got, err = c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.Equal(t, 1, countSubject(got))
+	remove, add := acl.Diff(got.AccessControlList, append(orig.AccessControlList, ac))
+	require.Empty(t, remove)
+	require.Empty(t, add)
+
+	for _, v := range got.AccessControlList {
+		if v.Subject.Equal(subject) {
+			err = c.RemoveAccessControl(ctx, deviceID, v.ID)
+			require.NoError(t, err)
+		}
+	}
+	got, err = c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.Equal(t, 0, countSubject(got))
+
+	// the changed access control is added before the old one is removed
+	err = c.AddAccessControls(ctx, deviceID, ac)
+	require.NoError(t, err)
+	changed := ac
+	changed.Permission = acl.Permission_READ | acl.Permission_WRITE
+	dev.SetFailure(func(method codes.Code, href string) error {
+		if method == codes.DELETE && href == "/oic/sec/acl2" {
+			return server.NewError(codes.ServiceUnavailable, fmt.Errorf("injected failure"))
+		}
+		return nil
+	})
+	err = c.ApplyAccessControlList(ctx, deviceID, append(orig.AccessControlList, changed))
+	require.Error(t, err)
+	dev.SetFailure(nil)
+	got, err = c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.Equal(t, 2, countSubject(got))
+	remove, add = acl.Diff(got.AccessControlList, append(orig.AccessControlList, ac, changed))
+	require.Empty(t, remove)
+	require.Empty(t, add)
+
+	err = c.ApplyAccessControlList(ctx, deviceID, orig.AccessControlList)
+	require.NoError(t, err)
+	got, err = c.GetAccessControlList(ctx, deviceID)
+	require.NoError(t, err)
+	require.Equal(t, 0, countSubject(got))
+}
@@ -23,6 +23,12 @@ type ApplicationCallback = interface {
 	GetManufacturerCertificate() (tls.Certificate, error)
 }
 
+// RandomPINApplicationCallback is implemented by the ApplicationCallback to support the random PIN ownership transfer method.
+type RandomPINApplicationCallback = interface {
+	// GetRandomPIN returns the PIN which is displayed by the device.
+	GetRandomPIN(ctx context.Context, deviceID string) (string, error)
+}
+
 type subscription = interface {
 	Cancel()
 	Wait()
@@ -37,6 +43,7 @@ type Config struct {
 	DisablePeerTCPSignalMessageCSMs   bool
 	HeartBeatSeconds                  uint64
 	DefaultTransferDurationSeconds    uint64 // 0 means 15 seconds
+	DeviceStorePath                   string // empty means devices are not persisted
 
 	// specify one of:
 	DeviceOwnershipSDK     *DeviceOwnershipSDKConfig     `yaml:",omitempty"`
@@ -120,7 +127,19 @@ func NewClientFromConfig(cfg *Config, app ApplicationCallback, createSigner func
 	if err != nil {
 		return nil, err
 	}
-	return NewClient(app, deviceOwner, cacheExpiration, observerPollingInterval, errors, opts...)
+	client, err := NewClient(app, deviceOwner, cacheExpiration, observerPollingInterval, errors, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client.closeDeviceOwner = true
+	if cfg.DeviceStorePath != "" {
+		store, err := NewFileDeviceStore(cfg.DeviceStorePath)
+		if err != nil {
+			return nil, err
+		}
+		client.SetDeviceStore(store)
+	}
+	return client, nil
 }
 
 // NewClient constructs a new local client.
@@ -157,11 +176,15 @@ func NewClient(
 		app:                     app,
 		deviceCache:             NewRefDeviceCache(cacheExpiration, errors),
 		observeResourceCache:    kitSync.NewMap(),
+		ownJournals:             kitSync.NewMap(),
 		deviceOwner:             deviceOwner,
 		subscriptions:           make(map[string]subscription),
 		observerPollingInterval: observerPollingInterval,
 		errors:                  errors,
 	}
+	if rotator, ok := deviceOwner.(identityCertificateRotator); ok {
+		rotator.setOnIdentityCertificateRotated(client.onIdentityCertificateRotated)
+	}
 	return &client, nil
 }
 
@@ -175,6 +198,8 @@ type DeviceOwner interface {
 	GetOnboardAuthorizationCodeURL(ctx context.Context, deviceID string) (string, error)
 	GetIdentityCertificate() (tls.Certificate, error)
 	GetIdentityCACerts() ([]*x509.Certificate, error)
+	// GetIdentityCertificateSigner returns the signer of identity certificates of owned devices.
+	GetIdentityCertificateSigner(ctx context.Context) (core.CertificateSigner, error)
 	Close(ctx context.Context) error
 }
 
@@ -188,13 +213,20 @@ type Client struct {
 	observeResourceCache    *kitSync.Map
 	observerPollingInterval time.Duration
 
-	deviceOwner DeviceOwner
+	deviceOwner      DeviceOwner
+	closeDeviceOwner bool
+	ownJournals      *kitSync.Map
 
 	subscriptionsLock sync.Mutex
 	subscriptions     map[string]subscription
 
 	disableUDPEndpoints bool
 	errors              func(error)
+	deviceStore         DeviceStore
+
+	identityCertificateRotationHandlersLock  sync.Mutex
+	identityCertificateRotationHandlers      map[int]func(tls.Certificate)
+	lastIdentityCertificateRotationHandlerID int
 }
 
 func (c *Client) popSubscriptions() map[string]subscription {
@@ -226,17 +258,34 @@ func (c *Client) CoreClient() *core.Client {
 	return c.client
 }
 
-// Close clears all connections and spawned goroutines by client.
+// Close clears all connections and spawned goroutines by client. The device owner is closed only when it was
+// created by NewClientFromConfig, the device owner passed to NewClient is closed by the caller.
 func (c *Client) Close(ctx context.Context) error {
 	for _, s := range c.popSubscriptions() {
 		s.Cancel()
 	}
-	return c.deviceCache.Close(ctx)
+	if rotator, ok := c.deviceOwner.(identityCertificateRotator); ok {
+		rotator.setOnIdentityCertificateRotated(nil)
+	}
+	var errors []error
+	if c.closeDeviceOwner {
+		if err := c.deviceOwner.Close(ctx); err != nil {
+			errors = append(errors, fmt.Errorf("cannot close device owner: %w", err))
+		}
+	}
+	c.stopReconnectingObservations()
+	if err := c.deviceCache.Close(ctx); err != nil {
+		errors = append(errors, err)
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("%v", errors)
+	}
+	return nil
 }
 
 func NewDeviceOwnerFromConfig(cfg *Config, dialTLS core.DialTLS, dialDTLS core.DialDTLS, app ApplicationCallback, createSigner func(caCert []*x509.Certificate, caKey crypto.PrivateKey, validNotBefore time.Time, validNotAfter time.Time) core.CertificateSigner, errors func(error)) (DeviceOwner, error) {
 	if cfg.DeviceOwnershipSDK != nil {
-		c, err := NewDeviceOwnershipSDKFromConfig(app, dialTLS, dialDTLS, cfg.DeviceOwnershipSDK, createSigner)
+		c, err := NewDeviceOwnershipSDKFromConfig(app, dialTLS, dialDTLS, cfg.DeviceOwnershipSDK, createSigner, WithError(errors))
 		if err != nil {
 			return nil, fmt.Errorf("cannot create sdk signers: %w", err)
 		}
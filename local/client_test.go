@@ -6,16 +6,75 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"testing"
 	"time"
 
+	"github.com/plgd-dev/kit/security"
 	"github.com/plgd-dev/sdk/app"
 	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
 	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
 )
 
-const TestTimeout = time.Second * 8
+const (
+	TestTimeout          = time.Second * 8
+	TestDeviceName       = "devsim-local"
+	TestSecureDeviceName = "devsim-local-secure"
+	// TestDeviceAddress is the address of the device reachable by GetDeviceByIP, it differs from addresses used by tests of other packages.
+	TestDeviceAddress = "127.0.0.2:5683"
+	// TestDeviceAddressIP6 is the IPv6 address of the device reachable by GetDeviceByIP, it is shared with tests of other packages.
+	TestDeviceAddressIP6 = "[::1]:5683"
+)
+
+// NewTestDevice creates the fake device named TestDeviceName with lights /light/1 and /light/2, which can be owned by the manufacturer
+// certificate of NewTestSecureClient. The device is closed at the end of the test.
+func NewTestDevice(t *testing.T, opts ...test.DeviceOptionFunc) *test.Device {
+	mfgCert, err := tls.X509KeyPair(MfgCert, MfgKey)
+	require.NoError(t, err)
+	mfgCA, err := security.ParseX509FromPEM(MfgTrustedCA)
+	require.NoError(t, err)
+	opts = append([]test.DeviceOptionFunc{
+		test.WithDeviceName(TestDeviceName),
+		test.WithManufacturerCertificate(mfgCert, mfgCA),
+		test.WithResources(test.NewLightResource("/light/1"), test.NewLightResource("/light/2")),
+	}, opts...)
+	dev, err := test.NewDevice(opts...)
+	// the shared address can be used by the test of another package for a moment
+	for deadline := time.Now().Add(TestTimeout); err != nil && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond * 100)
+		dev, err = test.NewDevice(opts...)
+	}
+	require.NoError(t, err)
+	t.Cleanup(dev.Close)
+	return dev
+}
 
-var TestDeviceName = test.TestDeviceName
+// StoreTestDevices stores endpoints of devices to the new device store of the client, the devices don't join
+// the multicast group.
+func StoreTestDevices(t *testing.T, c *local.Client, devs ...*test.Device) *local.MemoryDeviceStore {
+	store := local.NewMemoryDeviceStore()
+	for _, dev := range devs {
+		err := store.Store(context.Background(), local.DeviceRecord{
+			ID:        dev.ID(),
+			Endpoints: dev.Endpoints(),
+		})
+		require.NoError(t, err)
+	}
+	c.SetDeviceStore(store)
+	return store
+}
+
+// NewTestDiscoveryConfiguration discovers devices by unicast requests.
+func NewTestDiscoveryConfiguration(devs ...*test.Device) core.DiscoveryConfiguration {
+	var cfg core.DiscoveryConfiguration
+	for _, dev := range devs {
+		c := dev.DiscoveryConfiguration()
+		cfg.MulticastAddressUDP4 = append(cfg.MulticastAddressUDP4, c.MulticastAddressUDP4...)
+		cfg.MulticastAddressUDP6 = append(cfg.MulticastAddressUDP6, c.MulticastAddressUDP6...)
+	}
+	return cfg
+}
 
 type testSetupSecureClient struct {
 	ca      []*x509.Certificate
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pion/dtls/v2"
 	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
 
 	"github.com/plgd-dev/kit/log"
 )
@@ -130,6 +131,11 @@ func (c *Client) getDeviceConfiguration() deviceConfiguration {
 	}
 }
 
+// NewDevice creates the device from the known endpoints, e.g. from a previous discovery.
+func (c *Client) NewDevice(deviceID string, deviceTypes []string, endpoints schema.Endpoints) *Device {
+	return NewDevice(c.getDeviceConfiguration(), deviceID, deviceTypes, endpoints)
+}
+
 func DefaultDiscoveryConfiguration() DiscoveryConfiguration {
 	return DiscoveryConfiguration{
 		MulticastHopLimit:    2,
@@ -21,7 +21,43 @@ import (
 	"github.com/plgd-dev/sdk/test"
 )
 
-var TestDeviceName = "devsim-" + test.MustGetHostname()
+const (
+	TestTimeout          = time.Second * 8
+	TestDeviceName       = "devsim-core"
+	TestSecureDeviceName = "devsim-core-secure"
+	// TestDeviceAddress is the address of the device reachable by GetDeviceByIP, it differs from addresses used by tests of other packages.
+	TestDeviceAddress = "127.0.0.3:5683"
+	// TestDeviceAddressIP6 is the IPv6 address of the device reachable by GetDeviceByIP, it is shared with tests of other packages.
+	TestDeviceAddressIP6 = "[::1]:5683"
+)
+
+// NewTestDevice creates the fake device named TestDeviceName with lights /light/1 and /light/2, which can be owned by the manufacturer
+// certificate of NewTestSecureClient. The device is closed at the end of the test.
+func NewTestDevice(t *testing.T, opts ...test.DeviceOptionFunc) *test.Device {
+	mfgCert, err := tls.X509KeyPair(MfgCert, MfgKey)
+	require.NoError(t, err)
+	mfgCA, err := security.ParseX509FromPEM(MfgTrustedCA)
+	require.NoError(t, err)
+	opts = append([]test.DeviceOptionFunc{
+		test.WithDeviceName(TestDeviceName),
+		test.WithManufacturerCertificate(mfgCert, mfgCA),
+		test.WithResources(test.NewLightResource("/light/1"), test.NewLightResource("/light/2")),
+	}, opts...)
+	dev, err := test.NewDevice(opts...)
+	// the shared address can be used by the test of another package for a moment
+	for deadline := time.Now().Add(TestTimeout); err != nil && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond * 100)
+		dev, err = test.NewDevice(opts...)
+	}
+	require.NoError(t, err)
+	t.Cleanup(dev.Close)
+	return dev
+}
+
+// NewTestSecureDevice creates the device named TestSecureDeviceName.
+func NewTestSecureDevice(t *testing.T, opts ...test.DeviceOptionFunc) *test.Device {
+	return NewTestDevice(t, append([]test.DeviceOptionFunc{test.WithDeviceName(TestSecureDeviceName)}, opts...)...)
+}
 
 type Client struct {
 	*ocf.Client
@@ -31,6 +67,8 @@ type Client struct {
 	DeviceID string
 	*ocf.Device
 	DeviceLinks schema.ResourceLinks
+	// DiscoveryConfiguration discovers the device set up by SetUpTestDevice.
+	DiscoveryConfiguration ocf.DiscoveryConfiguration
 }
 
 func NewTestSecureClient() (*Client, error) {
@@ -117,12 +155,14 @@ func NewTestSecureClientWithCert(cert tls.Certificate, disableDTLS, disableTCPTL
 	return &Client{Client: c, mfgOtm: mfgOtm, justWorksOtm: justWorksOtm}, nil
 }
 
+// SetUpTestDevice owns the new secure device by the manufacturer certificate, the device is disowned by Close.
 func (c *Client) SetUpTestDevice(t *testing.T) {
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestSecureDevice(t)
+	secureDeviceID := dev.ID()
 
 	timeout, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	device, err := c.GetDeviceByMulticast(timeout, secureDeviceID, ocf.DefaultDiscoveryConfiguration())
+	device, err := c.GetDeviceByMulticast(timeout, secureDeviceID, dev.DiscoveryConfiguration())
 	require.NoError(t, err)
 	eps := device.GetEndpoints()
 	links, err := device.GetResourceLinks(timeout, eps)
@@ -132,6 +172,7 @@ func (c *Client) SetUpTestDevice(t *testing.T) {
 	c.Device = device
 	c.DeviceID = secureDeviceID
 	c.DeviceLinks = links
+	c.DiscoveryConfiguration = dev.DiscoveryConfiguration()
 }
 
 func (c *Client) Close() {
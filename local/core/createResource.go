@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plgd-dev/kit/codec/ocf"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// CreateResourceInterface is the interface used to create a child resource in a collection.
+const CreateResourceInterface = "oic.if.create"
+
+// CreateResource creates a resource in the collection identified by link. By the OCF specification
+// the response contains the link of the created resource, so decode it to schema.ResourceLink
+// to get the href of the new resource.
+func (d *Device) CreateResource(
+	ctx context.Context,
+	link schema.ResourceLink,
+	request interface{},
+	response interface{},
+	options ...kitNetCoap.OptionFunc,
+) error {
+	codec := ocf.VNDOCFCBORCodec{}
+	return d.CreateResourceWithCodec(ctx, link, codec, request, response, options...)
+}
+
+func (d *Device) CreateResourceWithCodec(
+	ctx context.Context,
+	link schema.ResourceLink,
+	codec kitNetCoap.Codec,
+	request interface{},
+	response interface{},
+	options ...kitNetCoap.OptionFunc,
+) error {
+	_, client, err := d.connectToEndpoints(ctx, link.GetEndpoints())
+	if err != nil {
+		return MakeInternal(fmt.Errorf("cannot create resource %v: %w", link.Href, err))
+	}
+	defer d.releaseConnection(client)
+	options = append(options, kitNetCoap.WithInterface(CreateResourceInterface), kitNetCoap.WithAccept(codec.ContentFormat()))
+
+	return client.CreateResourceWithCodec(ctx, link.Href, codec, request, response, options...)
+}
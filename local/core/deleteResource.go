@@ -30,6 +30,7 @@ func (d *Device) DeleteResourceWithCodec(
 	if err != nil {
 		return MakeInternal(fmt.Errorf("cannot delete resource %v: %w", link.Href, err))
 	}
+	defer d.releaseConnection(client)
 	options = append(options, kitNetCoap.WithAccept(codec.ContentFormat()))
 
 	return client.DeleteResourceWithCodec(ctx, link.Href, codec, response, options...)
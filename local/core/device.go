@@ -29,6 +29,7 @@ type Device struct {
 	cfg         deviceConfiguration
 
 	conn         map[string]*coap.ClientCloseHandler
+	requests     map[*coap.ClientCloseHandler]int
 	observations *sync.Map
 	lock         sync.Mutex
 }
@@ -58,6 +59,7 @@ func NewDevice(
 		endpoints:    endpoints,
 		observations: &sync.Map{},
 		conn:         make(map[string]*coap.ClientCloseHandler),
+		requests:     make(map[*coap.ClientCloseHandler]int),
 	}
 }
 
@@ -92,6 +94,71 @@ func (d *Device) Close(ctx context.Context) error {
 	return nil
 }
 
+// CloseConnections removes connections from the pool, so next requests establish new ones. It is used when the certificate
+// of the client was changed. Connections are closed gracefully: a connection with requests in progress is closed after
+// its last request finishes and a connection used by observations is closed when the observations stop.
+func (d *Device) CloseConnections() error {
+	var errors []error
+	for _, conn := range d.popConnections() {
+		if d.isConnectionUsed(conn) {
+			continue
+		}
+		err := conn.Close()
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if len(errors) > 0 {
+		return MakeInternal(fmt.Errorf("cannot close connections of device %v: %v", d.DeviceID(), errors))
+	}
+	return nil
+}
+
+// isConnectionUsed reports whether the connection serves a request in progress or an observation.
+func (d *Device) isConnectionUsed(conn *coap.ClientCloseHandler) bool {
+	d.lock.Lock()
+	requests := d.requests[conn]
+	d.lock.Unlock()
+	if requests > 0 {
+		return true
+	}
+	used := false
+	d.observations.Range(func(key, value interface{}) bool {
+		used = value.(*observation).client == conn
+		return !used
+	})
+	return used
+}
+
+// releaseConnection marks the request acquired by connectToEndpoints as finished.
+func (d *Device) releaseConnection(conn *coap.ClientCloseHandler) {
+	d.lock.Lock()
+	d.requests[conn]--
+	last := d.requests[conn] <= 0
+	if last {
+		delete(d.requests, conn)
+	}
+	d.lock.Unlock()
+	if last {
+		d.closeUnusedConnection(conn)
+	}
+}
+
+// closeUnusedConnection closes the connection removed from the pool by CloseConnections when it is not used anymore.
+func (d *Device) closeUnusedConnection(conn *coap.ClientCloseHandler) {
+	d.lock.Lock()
+	for _, c := range d.conn {
+		if c == conn {
+			d.lock.Unlock()
+			return
+		}
+	}
+	d.lock.Unlock()
+	if !d.isConnectionUsed(conn) {
+		conn.Close()
+	}
+}
+
 func (d *Device) dialTLS(ctx context.Context, addr string, tlsConfig *TLSConfig, verifyPeerCertificate func(verifyPeerCertificate *x509.Certificate) error, dialOptions ...coap.DialOptionFunc) (*coap.ClientCloseHandler, error) {
 	cert, err := tlsConfig.GetCertificate()
 	if err != nil {
@@ -145,6 +212,7 @@ func (d *Device) getConn(addr string) (c *coap.ClientCloseHandler, ok bool) {
 	c, ok = d.conn[addr]
 	if ok {
 		if c.Context().Err() == nil {
+			d.requests[c]++
 			return c, ok
 		}
 		delete(d.conn, addr)
@@ -186,17 +254,23 @@ func (d *Device) connectToEndpoint(ctx context.Context, endpoint schema.Endpoint
 	conn, ok = d.conn[addr.URL()]
 	if ok {
 		c.Close()
+		d.requests[conn]++
 		return addr, conn, nil
 	}
 	c.RegisterCloseHandler(func(error) {
 		d.lock.Lock()
 		defer d.lock.Unlock()
-		delete(d.conn, addr.URL())
+		if d.conn[addr.URL()] == c {
+			delete(d.conn, addr.URL())
+		}
 	})
 	d.conn[addr.URL()] = c
+	d.requests[c]++
 	return addr, c, nil
 }
 
+// connectToEndpoints returns a pooled connection to the first reachable endpoint. The caller must release it
+// by releaseConnection when the request is finished.
 func (d *Device) connectToEndpoints(ctx context.Context, endpoints []schema.Endpoint) (net.Addr, *coap.ClientCloseHandler, error) {
 	errors := make([]error, 0, 4)
 
@@ -0,0 +1,61 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevice_CloseConnections(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure())
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, err := c.GetDeviceByMulticast(ctx, dev.ID(), dev.DiscoveryConfiguration())
+	require.NoError(t, err)
+	defer device.Close(ctx)
+	links, err := device.GetResourceLinks(ctx, device.GetEndpoints())
+	require.NoError(t, err)
+	link, err := core.GetResourceLink(links, "/light/1")
+	require.NoError(t, err)
+
+	// the request is in progress until it is released
+	inProgress := make(chan struct{})
+	release := make(chan struct{})
+	dev.SetFailure(func(method codes.Code, href string) error {
+		if method == codes.GET && href == "/light/1" {
+			close(inProgress)
+			<-release
+		}
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() {
+		var v interface{}
+		done <- device.GetResource(ctx, link, &v)
+	}()
+	select {
+	case <-inProgress:
+	case <-ctx.Done():
+		require.FailNow(t, "request was not sent")
+	}
+
+	err = device.CloseConnections()
+	require.NoError(t, err)
+	close(release)
+	// the connection is closed after the request in progress finishes
+	require.NoError(t, <-done)
+
+	dev.SetFailure(nil)
+	var v interface{}
+	err = device.GetResource(ctx, link, &v)
+	require.NoError(t, err)
+}
@@ -10,7 +10,8 @@ import (
 )
 
 func TestClient_GetDeviceByIP_IP4(t *testing.T) {
-	ip := test.MustFindDeviceIP(test.TestDeviceName, test.IP4)
+	NewTestDevice(t, test.WithInsecure(), test.WithAddress(TestDeviceAddress))
+	ip := "127.0.0.3"
 
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
@@ -31,7 +32,8 @@ func TestClient_GetDeviceByIP_IP4(t *testing.T) {
 }
 
 func TestClient_GetDeviceByIP_IP6(t *testing.T) {
-	ip := test.MustFindDeviceIP(test.TestSecureDeviceName, test.IP6)
+	NewTestSecureDevice(t, test.WithAddress(TestDeviceAddressIP6))
+	ip := "::1"
 
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
@@ -3,28 +3,43 @@ package core_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	ocf "github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/test"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestDeviceDiscovery(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure())
 	c := ocf.NewClient()
 	timeout, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 	h := testDeviceHandler{}
-	err := c.GetDevicesV2(timeout, ocf.DefaultDiscoveryConfiguration(), &h)
+	err := c.GetDevicesV2(timeout, dev.DiscoveryConfiguration(), &h)
 	require.NoError(t, err)
+	require.Equal(t, []string{dev.ID()}, h.getDeviceIDs())
 }
 
 type testDeviceHandler struct {
+	lock      sync.Mutex
+	deviceIDs []string
 }
 
 func (h *testDeviceHandler) Handle(ctx context.Context, d *ocf.Device) {
 	defer d.Close(ctx)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.deviceIDs = append(h.deviceIDs, d.DeviceID())
+}
+
+func (h *testDeviceHandler) getDeviceIDs() []string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.deviceIDs
 }
 
 func (h *testDeviceHandler) Error(err error) {
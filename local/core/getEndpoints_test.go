@@ -5,17 +5,16 @@ import (
 	"testing"
 	"time"
 
-	ocf "github.com/plgd-dev/sdk/local/core"
 	"github.com/plgd-dev/sdk/test"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestDevice_GetEndpoints(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(TestDeviceName)
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	secureDev := NewTestSecureDevice(t)
 	type args struct {
-		deviceID string
+		dev *test.Device
 	}
 	tests := []struct {
 		name string
@@ -24,13 +23,13 @@ func TestDevice_GetEndpoints(t *testing.T) {
 		{
 			name: "secure",
 			args: args{
-				deviceID: secureDeviceID,
+				dev: secureDev,
 			},
 		},
 		{
 			name: "insecure",
 			args: args{
-				deviceID: deviceID,
+				dev: dev,
 			},
 		},
 	}
@@ -39,12 +38,11 @@ func TestDevice_GetEndpoints(t *testing.T) {
 			c, err := NewTestSecureClient()
 			require.NoError(t, err)
 			defer c.Close()
-			deviceID := tt.args.deviceID
 			require := require.New(t)
 			timeout, cancelTimeout := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancelTimeout()
 
-			device, err := c.GetDeviceByMulticast(timeout, deviceID, ocf.DefaultDiscoveryConfiguration())
+			device, err := c.GetDeviceByMulticast(timeout, tt.args.dev.ID(), tt.args.dev.DiscoveryConfiguration())
 			require.NoError(err)
 			defer device.Close(timeout)
 
@@ -5,15 +5,12 @@ import (
 	"testing"
 	"time"
 
-	ocf "github.com/plgd-dev/sdk/local/core"
-	"github.com/plgd-dev/sdk/test"
-
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestGetOwnership(t *testing.T) {
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestSecureDevice(t)
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer c.Close()
@@ -21,8 +18,7 @@ func TestGetOwnership(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	deviceID := secureDeviceID
-	device, err := c.GetDeviceByMulticast(ctx, deviceID, ocf.DefaultDiscoveryConfiguration())
+	device, err := c.GetDeviceByMulticast(ctx, dev.ID(), dev.DiscoveryConfiguration())
 	require.NoError(t, err)
 	defer device.Close(ctx)
 	eps := device.GetEndpoints()
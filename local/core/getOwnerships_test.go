@@ -14,18 +14,18 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func testGetOwnerShips(ctx context.Context, t *testing.T, c *Client, ownStatus ocf.DiscoverOwnershipStatus, found bool) {
+func testGetOwnerShips(ctx context.Context, t *testing.T, c *Client, dev *test.Device, ownStatus ocf.DiscoverOwnershipStatus, found bool) {
 	timeout, cancel := context.WithTimeout(ctx, 1*time.Second)
 	defer cancel()
 
 	var h testOwnerShipHandler
-	err := c.GetOwnerships(timeout, ocf.DefaultDiscoveryConfiguration(), ownStatus, &h)
+	err := c.GetOwnerships(timeout, dev.DiscoveryConfiguration(), ownStatus, &h)
 	require.NoError(t, err)
 	assert.Equal(t, found, h.anyFound)
 }
 
 func TestGetOwnerships(t *testing.T) {
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestSecureDevice(t)
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer c.Close()
@@ -33,12 +33,11 @@ func TestGetOwnerships(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	testGetOwnerShips(ctx, t, c, ocf.DiscoverAllDevices, true)
-	testGetOwnerShips(ctx, t, c, ocf.DiscoverDisownedDevices, true)
-	testGetOwnerShips(ctx, t, c, ocf.DiscoverOwnedDevices, false)
+	testGetOwnerShips(ctx, t, c, dev, ocf.DiscoverAllDevices, true)
+	testGetOwnerShips(ctx, t, c, dev, ocf.DiscoverDisownedDevices, true)
+	testGetOwnerShips(ctx, t, c, dev, ocf.DiscoverOwnedDevices, false)
 
-	deviceID := secureDeviceID
-	device, err := c.GetDeviceByMulticast(ctx, deviceID, ocf.DefaultDiscoveryConfiguration())
+	device, err := c.GetDeviceByMulticast(ctx, dev.ID(), dev.DiscoveryConfiguration())
 	require.NoError(t, err)
 	defer device.Close(ctx)
 	eps := device.GetEndpoints()
@@ -48,8 +47,8 @@ func TestGetOwnerships(t *testing.T) {
 	err = device.Own(ctx, links, c.mfgOtm)
 	require.NoError(t, err)
 
-	testGetOwnerShips(ctx, t, c, ocf.DiscoverDisownedDevices, false)
-	testGetOwnerShips(ctx, t, c, ocf.DiscoverOwnedDevices, true)
+	testGetOwnerShips(ctx, t, c, dev, ocf.DiscoverDisownedDevices, false)
+	testGetOwnerShips(ctx, t, c, dev, ocf.DiscoverOwnedDevices, true)
 
 	err = device.Disown(ctx, links)
 	require.NoError(t, err)
@@ -33,6 +33,7 @@ func (d *Device) GetResourceWithCodec(
 	if err != nil {
 		return fmt.Errorf("cannot get resource %v: %w", link.Href, err)
 	}
+	defer d.releaseConnection(client)
 	return client.GetResourceWithCodec(ctx, link.Href, codec, response, options...)
 }
 
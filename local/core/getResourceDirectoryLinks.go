@@ -0,0 +1,33 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// GetResourceDirectoryLinks gets links published to the resource directory (oic.wk.rd) via unicast UDP.
+// Links of all devices are returned, the device of the link is set by DeviceID.
+func (c *Client) GetResourceDirectoryLinks(ctx context.Context, addr string, options ...coap.OptionFunc) (schema.ResourceLinks, error) {
+	conn, err := coap.DialUDP(ctx, addr, coap.WithErrors(c.errFunc))
+	if err != nil {
+		return nil, MakeUnavailable(fmt.Errorf("cannot connect to resource directory %v: %w", addr, err))
+	}
+	defer conn.Close()
+
+	var links schema.ResourceLinks
+	options = append(options, coap.WithAccept(message.AppOcfCbor))
+	err = conn.GetResourceWithCodec(ctx, "/oic/res", DiscoverDeviceCodec{}, &links, options...)
+	if err != nil {
+		return nil, MakeDataLoss(fmt.Errorf("cannot get links from resource directory %v: %w", addr, err))
+	}
+	for i, link := range links {
+		if link.DeviceID == "" {
+			links[i].DeviceID = link.GetDeviceID()
+		}
+	}
+	return links, nil
+}
@@ -28,6 +28,7 @@ func (d *Device) GetResourceLinks(ctx context.Context, endpoints []schema.Endpoi
 	if err != nil {
 		return nil, MakeDataLoss(fmt.Errorf("cannot get resource links for %v with endpoints %+v: %w", d.DeviceID(), endpoints, err))
 	}
+	defer d.releaseConnection(client)
 	links, err := getResourceLinks(ctx, addr, client, options...)
 	if err != nil {
 		return links, MakeDataLoss(fmt.Errorf("cannot get resource links for %v: %w", d.DeviceID(), err))
@@ -42,3 +43,13 @@ func GetResourceLink(links schema.ResourceLinks, href string) (schema.ResourceLi
 	}
 	return link, nil
 }
+
+// GetSecureResourceLink returns the link of the resource with secure endpoints only.
+func GetSecureResourceLink(links schema.ResourceLinks, href string) (schema.ResourceLink, error) {
+	link, err := GetResourceLink(links, href)
+	if err != nil {
+		return schema.ResourceLink{}, err
+	}
+	link.Endpoints = link.GetSecureEndpoints()
+	return link, nil
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+const (
+	// CollectionResourceType is the resource type of the collection.
+	CollectionResourceType = "oic.wk.col"
+	// LinkListInterface is the interface which returns links of the collection.
+	LinkListInterface = "oic.if.ll"
+)
+
+// ResourceLinkNode is a node of the resource tree. Children are set for collections.
+type ResourceLinkNode struct {
+	schema.ResourceLink
+	Parent   *ResourceLinkNode   `json:"-"`
+	Children []*ResourceLinkNode `json:"children,omitempty"`
+}
+
+func (n *ResourceLinkNode) isAncestor(href string) bool {
+	for p := n; p != nil; p = p.Parent {
+		if p.Href == href {
+			return true
+		}
+	}
+	return false
+}
+
+type resourceLinksTreeBuilder struct {
+	device *Device
+	roots  map[string]*ResourceLinkNode
+	// expanded contains nodes with fetched children, a collection shared by more parents has a node under each of them.
+	expanded map[*ResourceLinkNode]bool
+	options  []coap.OptionFunc
+}
+
+func (b *resourceLinksTreeBuilder) getCollectionLinks(ctx context.Context, link schema.ResourceLink) (schema.ResourceLinks, error) {
+	options := append([]coap.OptionFunc{coap.WithInterface(LinkListInterface)}, b.options...)
+	var links schema.ResourceLinks
+	err := b.device.GetResourceWithCodec(ctx, link, ocf.VNDOCFCBORCodec{}, &links, options...)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (b *resourceLinksTreeBuilder) expand(ctx context.Context, node *ResourceLinkNode) error {
+	if !node.HasType(CollectionResourceType) || b.expanded[node] {
+		return nil
+	}
+	b.expanded[node] = true
+	links, err := b.getCollectionLinks(ctx, node.ResourceLink)
+	if err != nil {
+		return fmt.Errorf("cannot get links of collection %v: %w", node.Href, err)
+	}
+	for _, link := range links {
+		if node.isAncestor(link.Href) {
+			// cycle, only ancestors of the current path are skipped
+			continue
+		}
+		if len(link.Endpoints) == 0 {
+			link.Endpoints = node.Endpoints
+		}
+		child, ok := b.roots[link.Href]
+		if !ok || child.Parent != nil {
+			child = &ResourceLinkNode{
+				ResourceLink: link,
+			}
+		}
+		child.Parent = node
+		node.Children = append(node.Children, child)
+		if err := b.expand(ctx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetResourceLinksTree follows collections (oic.wk.col) via the link list interface and returns links as a tree.
+// The links of a collection are placed under it, a collection linked by more collections is placed under each of them
+// and links back to an ancestor, which create a cycle, are skipped.
+func (d *Device) GetResourceLinksTree(ctx context.Context, links schema.ResourceLinks, options ...coap.OptionFunc) ([]*ResourceLinkNode, error) {
+	nodes := make([]*ResourceLinkNode, 0, len(links))
+	b := resourceLinksTreeBuilder{
+		device:   d,
+		roots:    make(map[string]*ResourceLinkNode, len(links)),
+		expanded: make(map[*ResourceLinkNode]bool),
+		options:  options,
+	}
+	for _, link := range links {
+		node := &ResourceLinkNode{
+			ResourceLink: link,
+		}
+		nodes = append(nodes, node)
+		b.roots[link.Href] = node
+	}
+	for _, node := range nodes {
+		if err := b.expand(ctx, node); err != nil {
+			return nil, MakeDataLoss(fmt.Errorf("cannot get resource links tree for %v: %w", d.DeviceID(), err))
+		}
+	}
+	roots := make([]*ResourceLinkNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Parent == nil {
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
@@ -0,0 +1,78 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func findNode(nodes []*core.ResourceLinkNode, href string) *core.ResourceLinkNode {
+	for _, n := range nodes {
+		if n.Href == href {
+			return n
+		}
+	}
+	return nil
+}
+
+func requireChildren(t *testing.T, node *core.ResourceLinkNode, hrefs ...string) {
+	got := make([]string, 0, len(node.Children))
+	for _, c := range node.Children {
+		require.Equal(t, node, c.Parent, "parent of %v", c.Href)
+		got = append(got, c.Href)
+	}
+	require.Equal(t, hrefs, got, "children of %v", node.Href)
+}
+
+func TestDevice_GetResourceLinksTree(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure(),
+		test.WithCollection("/col/1", "/col/shared"),
+		// /col/2 and /col/nested link each other
+		test.WithCollection("/col/2", "/col/shared", "/col/nested"),
+		test.WithCollection("/col/nested", "/light/2", "/col/2"),
+		test.WithCollection("/col/shared", "/light/1"),
+	)
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer c.Close()
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelTimeout()
+
+	device, err := c.GetDeviceByMulticast(timeout, dev.ID(), dev.DiscoveryConfiguration())
+	require.NoError(t, err)
+	defer device.Close(timeout)
+	links, err := device.GetResourceLinks(timeout, device.GetEndpoints())
+	require.NoError(t, err)
+
+	got, err := device.GetResourceLinksTree(timeout, links)
+	require.NoError(t, err)
+	for _, node := range got {
+		require.Nil(t, node.Parent)
+	}
+	for _, href := range []string{"/col/nested", "/col/shared", "/light/1", "/light/2"} {
+		require.Nil(t, findNode(got, href), "%v is a child", href)
+	}
+	require.NotNil(t, findNode(got, "/oic/d"))
+	require.Empty(t, findNode(got, "/oic/d").Children)
+
+	col1 := findNode(got, "/col/1")
+	require.NotNil(t, col1)
+	requireChildren(t, col1, "/col/shared")
+	requireChildren(t, col1.Children[0], "/light/1")
+	require.Empty(t, col1.Children[0].Children[0].Children)
+
+	col2 := findNode(got, "/col/2")
+	require.NotNil(t, col2)
+	requireChildren(t, col2, "/col/shared", "/col/nested")
+	// the shared collection has children under both parents
+	shared := col2.Children[0]
+	require.NotSame(t, col1.Children[0], shared)
+	requireChildren(t, shared, "/light/1")
+	// the link back to /col/2 is skipped
+	requireChildren(t, col2.Children[1], "/light/2")
+}
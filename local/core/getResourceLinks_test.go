@@ -12,10 +12,10 @@ import (
 )
 
 func TestDevice_GetResourceLinks(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(TestDeviceName)
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	secureDev := NewTestSecureDevice(t)
 	type args struct {
-		deviceID string
+		dev *test.Device
 	}
 	tests := []struct {
 		name    string
@@ -25,13 +25,13 @@ func TestDevice_GetResourceLinks(t *testing.T) {
 		{
 			name: "secure",
 			args: args{
-				deviceID: secureDeviceID,
+				dev: secureDev,
 			},
 		},
 		{
 			name: "insecure",
 			args: args{
-				deviceID: deviceID,
+				dev: dev,
 			},
 		},
 	}
@@ -40,12 +40,11 @@ func TestDevice_GetResourceLinks(t *testing.T) {
 			c, err := NewTestSecureClient()
 			require.NoError(t, err)
 			defer c.Close()
-			deviceID := tt.args.deviceID
 			require := require.New(t)
 			timeout, cancelTimeout := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancelTimeout()
 
-			device, err := c.GetDeviceByMulticast(timeout, deviceID, core.DefaultDiscoveryConfiguration())
+			device, err := c.GetDeviceByMulticast(timeout, tt.args.dev.ID(), tt.args.dev.DiscoveryConfiguration())
 			require.NoError(err)
 			defer device.Close(timeout)
 			eps := device.GetEndpoints()
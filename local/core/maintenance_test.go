@@ -30,8 +30,7 @@ func TestDevice_Reboot(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name:    "valid - iotivity-lite doesn't support reboot",
-			wantErr: true,
+			name: "valid",
 		},
 	}
 
@@ -40,11 +39,11 @@ func TestDevice_Reboot(t *testing.T) {
 			c, err := NewTestSecureClient()
 			require.NoError(t, err)
 			defer c.Close()
-			deviceID := test.MustFindDeviceByName(TestDeviceName)
+			dev := NewTestDevice(t, test.WithInsecure())
 			require := require.New(t)
 			timeout, cancelTimeout := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancelTimeout()
-			device, err := c.GetDeviceByMulticast(timeout, deviceID, ocf.DefaultDiscoveryConfiguration())
+			device, err := c.GetDeviceByMulticast(timeout, dev.ID(), dev.DiscoveryConfiguration())
 			require.NoError(err)
 			defer device.Close(timeout)
 			eps := device.GetEndpoints()
@@ -67,11 +66,11 @@ func TestDevice_FactoryReset(t *testing.T) {
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer c.Close()
-	deviceID := test.MustFindDeviceByName(TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
 	require := require.New(t)
 	timeout, cancelTimeout := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancelTimeout()
-	device, err := c.GetDeviceByMulticast(timeout, deviceID, ocf.DefaultDiscoveryConfiguration())
+	device, err := c.GetDeviceByMulticast(timeout, dev.ID(), dev.DiscoveryConfiguration())
 	require.NoError(err)
 	defer device.Close(timeout)
 	eps := device.GetEndpoints()
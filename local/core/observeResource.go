@@ -48,6 +48,7 @@ func (d *Device) StopObservingResource(
 	}
 	d.observations.Delete(observationID)
 	o := v.(*observation)
+	defer d.closeUnusedConnection(o.client)
 	err := o.Stop(ctx)
 	if err != nil {
 		return MakeCanceled(fmt.Errorf("could not cancel observation %s: %w", observationID, err))
@@ -56,15 +57,20 @@ func (d *Device) StopObservingResource(
 	return nil
 }
 
+// stopObservations stops observations of the closed device, their handlers are notified by OnClose.
 func (d *Device) stopObservations(ctx context.Context) error {
-	obs := make([]string, 0, 12)
+	obs := make(map[string]*observation, 12)
 	d.observations.Range(func(key, value interface{}) bool {
-		observationID := key.(string)
-		obs = append(obs, observationID)
-		return false
+		obs[key.(string)] = value.(*observation)
+		return true
 	})
 	var errors []error
-	for _, observationID := range obs {
+	for observationID, o := range obs {
+		o.handler.OnClose()
+		if _, ok := d.observations.Load(observationID); !ok {
+			// the handler stopped the observation
+			continue
+		}
 		err := d.StopObservingResource(ctx, observationID)
 		if err != nil {
 			errors = append(errors, err)
@@ -127,6 +133,7 @@ func (d *Device) observeResource(
 	if err != nil {
 		return "", MakeInternal(fmt.Errorf("cannot observe resource %v: %w", link.Href, err))
 	}
+	defer d.releaseConnection(client)
 
 	options = append(options, kitNetCoap.WithAccept(codec.ContentFormat()))
 
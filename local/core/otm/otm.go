@@ -0,0 +1,84 @@
+package otm
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+
+	kitSecurity "github.com/plgd-dev/kit/security"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+type CertificateSigner = interface {
+	//csr is encoded by PEM and returns PEM
+	Sign(ctx context.Context, csr []byte) ([]byte, error)
+}
+
+// ProvisionOwnerCredentials signs the CSR of the device by the signer and replaces the identity certificate
+// and the trusted CA of the device, it is shared by clients of ownership transfer methods.
+func ProvisionOwnerCredentials(ctx context.Context, tlsClient *kitNetCoap.ClientCloseHandler, signer CertificateSigner, ownerID, deviceID string) error {
+	/*setup credentials - PostOwnerCredential*/
+	var csr schema.CertificateSigningRequestResponse
+	err := tlsClient.GetResource(ctx, "/oic/sec/csr", &csr)
+	if err != nil {
+		return fmt.Errorf("cannot get csr for setup device owner credentials: %w", err)
+	}
+
+	signedCsr, err := signer.Sign(ctx, csr.PEM())
+	if err != nil {
+		return fmt.Errorf("cannot sign csr for setup device owner credentials: %w", err)
+	}
+
+	certsFromChain, err := kitSecurity.ParseX509FromPEM(signedCsr)
+	if err != nil {
+		return fmt.Errorf("Failed to parse chain of X509 certs: %w", err)
+	}
+
+	var deviceCredential schema.CredentialResponse
+	err = tlsClient.GetResource(ctx, "/oic/sec/cred", &deviceCredential, kitNetCoap.WithCredentialSubject(deviceID))
+	if err != nil {
+		return fmt.Errorf("cannot get device credential to setup device owner credentials: %w", err)
+	}
+
+	for _, cred := range deviceCredential.Credentials {
+		switch {
+		case cred.Usage == schema.CredentialUsage_CERT && cred.Type == schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE,
+			cred.Usage == schema.CredentialUsage_TRUST_CA && cred.Type == schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE:
+			err = tlsClient.DeleteResource(ctx, "/oic/sec/cred", nil, kitNetCoap.WithCredentialId(cred.ID))
+			if err != nil {
+				return fmt.Errorf("cannot delete device credentials %v (%v) to setup device owner credentials: %w", cred.ID, cred.Usage, err)
+			}
+		}
+	}
+
+	setIdentityDeviceCredential := schema.CredentialUpdateRequest{
+		ResourceOwner: ownerID,
+		Credentials: []schema.Credential{
+			schema.Credential{
+				Subject: deviceID,
+				Type:    schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE,
+				Usage:   schema.CredentialUsage_CERT,
+				PublicData: &schema.CredentialPublicData{
+					DataInternal: string(signedCsr),
+					Encoding:     schema.CredentialPublicDataEncoding_PEM,
+				},
+			},
+			schema.Credential{
+				Subject: ownerID,
+				Type:    schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE,
+				Usage:   schema.CredentialUsage_TRUST_CA,
+				PublicData: &schema.CredentialPublicData{
+					DataInternal: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certsFromChain[len(certsFromChain)-1].Raw})),
+					Encoding:     schema.CredentialPublicDataEncoding_PEM,
+				},
+			},
+		},
+	}
+	err = tlsClient.UpdateResource(ctx, "/oic/sec/cred", setIdentityDeviceCredential, nil)
+	if err != nil {
+		return fmt.Errorf("cannot set device identity credentials: %w", err)
+	}
+
+	return nil
+}
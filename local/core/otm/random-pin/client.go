@@ -0,0 +1,115 @@
+package randompin
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/pion/dtls/v2"
+	kitNet "github.com/plgd-dev/kit/net"
+	"github.com/plgd-dev/sdk/local/core/otm"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// PSKIterations is the count of PBKDF2 iterations used to derive PSK from the PIN.
+	PSKIterations = 1000
+	// PSKLength is the length of the derived PSK in bytes.
+	PSKLength = 16
+)
+
+// CipherSuites are offered by the random PIN method. OCF requires TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256, but the pinned
+// pion/dtls implements only the plain PSK key exchange and the ECDHE_PSK one cannot be added by a custom cipher suite,
+// so the PSK suites with the same ciphers are used until pion/dtls is upgraded.
+var CipherSuites = []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_CBC_SHA256, dtls.TLS_PSK_WITH_AES_128_CCM_8}
+
+type CertificateSigner = otm.CertificateSigner
+
+// GetPINFunc returns the PIN which is displayed by the device after the random PIN method was selected.
+type GetPINFunc = func(ctx context.Context, deviceID string) (string, error)
+
+type Client struct {
+	signer   CertificateSigner
+	deviceID string
+	getPIN   GetPINFunc
+	dialDTLS DialDTLS
+}
+
+type DialDTLS = func(ctx context.Context, addr string, dtlsCfg *dtls.Config, opts ...kitNetCoap.DialOptionFunc) (*coap.ClientCloseHandler, error)
+
+type OptionFunc func(Client) Client
+
+func WithDialDTLS(dial DialDTLS) OptionFunc {
+	return func(cfg Client) Client {
+		if dial != nil {
+			cfg.dialDTLS = dial
+		}
+		return cfg
+	}
+}
+
+// NewClient creates the random PIN ownership transfer method client for the device. The handshake uses CipherSuites,
+// which don't contain TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256 required by OCF, so only devices accepting the PSK suites can be owned.
+func NewClient(signer CertificateSigner, deviceID string, getPIN GetPINFunc, opts ...OptionFunc) *Client {
+	c := Client{
+		signer:   signer,
+		deviceID: deviceID,
+		getPIN:   getPIN,
+		dialDTLS: kitNetCoap.DialUDPSecure,
+	}
+	for _, o := range opts {
+		c = o(c)
+	}
+	return &c
+}
+
+func (*Client) Type() schema.OwnerTransferMethod {
+	return schema.SharedPin
+}
+
+// DerivePSK derives pre-shared key from the PIN, the device ID is used as the salt.
+func DerivePSK(pin, deviceID string) ([]byte, error) {
+	id, err := uuid.FromString(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device id %v: %w", deviceID, err)
+	}
+	return pbkdf2.Key([]byte(pin), id.Bytes(), PSKIterations, PSKLength, sha256.New), nil
+}
+
+func (c *Client) Dial(ctx context.Context, addr kitNet.Addr, opts ...kitNetCoap.DialOptionFunc) (*kitNetCoap.ClientCloseHandler, error) {
+	switch schema.Scheme(addr.GetScheme()) {
+	case schema.UDPSecureScheme:
+		pin, err := c.getPIN(ctx, c.deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get PIN: %w", err)
+		}
+		psk, err := DerivePSK(pin, c.deviceID)
+		if err != nil {
+			return nil, err
+		}
+		id, err := uuid.FromString(c.deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device id %v: %w", c.deviceID, err)
+		}
+		tlsConfig := dtls.Config{
+			PSK: func([]byte) ([]byte, error) {
+				return psk, nil
+			},
+			PSKIdentityHint: id.Bytes(),
+			CipherSuites:    CipherSuites,
+			ConnectContextMaker: func() (context.Context, func()) {
+				return context.WithCancel(ctx)
+			},
+		}
+		return c.dialDTLS(ctx, addr.String(), &tlsConfig, opts...)
+	}
+	return nil, fmt.Errorf("cannot dial to url %v: scheme %v not supported", addr.URL(), addr.GetScheme())
+}
+
+func (c *Client) ProvisionOwnerCredentials(ctx context.Context, tlsClient *kitNetCoap.ClientCloseHandler, ownerID, deviceID string) error {
+	return otm.ProvisionOwnerCredentials(ctx, tlsClient, c.signer, ownerID, deviceID)
+}
@@ -0,0 +1,265 @@
+package randompin_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/dtls/v2"
+	kitNet "github.com/plgd-dev/kit/net"
+	"github.com/plgd-dev/sdk/app"
+	"github.com/plgd-dev/sdk/local"
+	randompin "github.com/plgd-dev/sdk/local/core/otm/random-pin"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDeviceID = "00000000-0000-0000-0000-000000000001"
+	testOwnerID  = "00000000-0000-0000-0000-000000000002"
+	testTimeout  = time.Second * 3
+)
+
+func newTestDevice(t *testing.T, pin string) (kitNet.Addr, func()) {
+	psk, err := randompin.DerivePSK(pin, testDeviceID)
+	require.NoError(t, err)
+	l, err := dtls.Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, &dtls.Config{
+		PSK: func([]byte) ([]byte, error) {
+			return psk, nil
+		},
+		PSKIdentityHint: []byte("device"),
+		CipherSuites:    randompin.CipherSuites,
+	})
+	require.NoError(t, err)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				buf := make([]byte, 1024)
+				for {
+					if _, err := c.Read(buf); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	addr, err := kitNet.ParseString("coaps", l.Addr().String())
+	require.NoError(t, err)
+	return addr, func() {
+		l.Close()
+	}
+}
+
+func TestDerivePSK(t *testing.T) {
+	tests := []struct {
+		name     string
+		pin      string
+		deviceID string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			pin:      "51384972",
+			deviceID: testDeviceID,
+			want:     "20f4498939fd490dbff7961f169ad510",
+		},
+		{
+			name:     "salted by device id",
+			pin:      "51384972",
+			deviceID: "b5a2a42e-b285-42f1-a36b-034c8fc8efd5",
+			want:     "b15b2534fffe26e50d82aed2d3efc7b7",
+		},
+		{
+			name:     "invalid device id",
+			pin:      "51384972",
+			deviceID: "invalid",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := randompin.DerivePSK(tt.pin, tt.deviceID)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, randompin.PSKLength)
+			require.Equal(t, tt.want, hex.EncodeToString(got))
+		})
+	}
+}
+
+func TestClient_Dial(t *testing.T) {
+	const pin = "51384972"
+	tests := []struct {
+		name    string
+		pin     string
+		pinErr  error
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			pin:  pin,
+		},
+		{
+			name:    "invalid pin",
+			pin:     "00000000",
+			wantErr: true,
+		},
+		{
+			name:    "pin is not available",
+			pinErr:  fmt.Errorf("canceled by user"),
+			wantErr: true,
+		},
+	}
+	addr, shutdown := newTestDevice(t, pin)
+	defer shutdown()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+			defer cancel()
+			c := randompin.NewClient(nil, testDeviceID, func(ctx context.Context, deviceID string) (string, error) {
+				require.Equal(t, testDeviceID, deviceID)
+				return tt.pin, tt.pinErr
+			})
+			conn, err := c.Dial(ctx, addr)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		})
+	}
+}
+
+// pinApp returns the PIN displayed by the fake device, or the wrong one.
+type pinApp struct {
+	*app.App
+	pins      chan string
+	deviceIDs chan string
+	wrongPIN  bool
+}
+
+func (a *pinApp) GetRandomPIN(ctx context.Context, deviceID string) (string, error) {
+	select {
+	case a.deviceIDs <- deviceID:
+	default:
+	}
+	select {
+	case pin := <-a.pins:
+		if a.wrongPIN {
+			return "x" + pin, nil
+		}
+		return pin, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func newCA(t *testing.T) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "RootCA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestOwnDevice(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrongPIN bool
+		wantErr  bool
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:     "wrong pin",
+			wrongPIN: true,
+			wantErr:  true,
+		},
+	}
+	caPEM, caKeyPEM := newCA(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pins := make(chan string, 4)
+			dev, err := test.NewDevice(test.WithRandomPIN(func(pin string) {
+				pins <- pin
+			}))
+			require.NoError(t, err)
+			defer dev.Close()
+			require.Contains(t, dev.Doxm().SupportedOwnerTransferMethods, schema.SharedPin)
+
+			a, err := app.NewApp(nil)
+			require.NoError(t, err)
+			pinApp := &pinApp{App: a, pins: pins, deviceIDs: make(chan string, 4), wrongPIN: tt.wrongPIN}
+			c, err := local.NewClientFromConfig(&local.Config{
+				DeviceOwnershipSDK: &local.DeviceOwnershipSDKConfig{
+					ID:      testOwnerID,
+					Cert:    string(caPEM),
+					CertKey: string(caKeyPEM),
+				},
+			}, pinApp, test.NewIdentityCertificateSigner, func(error) {})
+			require.NoError(t, err)
+			defer c.Close(context.Background())
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			err = c.Initialization(ctx)
+			require.NoError(t, err)
+			store := local.NewMemoryDeviceStore()
+			err = store.Store(ctx, local.DeviceRecord{ID: dev.ID(), Endpoints: dev.Endpoints()})
+			require.NoError(t, err)
+			c.SetDeviceStore(store)
+
+			deviceID, err := c.OwnDevice(ctx, dev.ID(), local.WithOTM(local.OTMType_RandomPin))
+			// the PIN is requested for the device which displays it
+			require.Equal(t, dev.ID(), <-pinApp.deviceIDs)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.False(t, dev.Doxm().Owned)
+				return
+			}
+			require.NoError(t, err)
+			doxm := dev.Doxm()
+			require.True(t, doxm.Owned)
+			require.Equal(t, testOwnerID, doxm.OwnerID)
+			require.Equal(t, schema.SharedPin, doxm.SelectedOwnerTransferMethod)
+			require.NotEmpty(t, dev.Credentials().Credentials)
+
+			err = c.DisownDevice(ctx, deviceID)
+			require.NoError(t, err)
+			require.False(t, dev.Doxm().Owned)
+		})
+	}
+}
@@ -21,8 +21,14 @@ type OTMClient interface {
 
 type ActionDuringOwnFunc = func(ctx context.Context, client *kitNetCoap.ClientCloseHandler) (string, error)
 
+// OwnerACLPolicyFunc builds the access control list which is set to the device during the ownership transfer.
+type OwnerACLPolicyFunc = func(links schema.ResourceLinks, ownerID string) acl.UpdateRequest
+
 type ownCfg struct {
 	actionDuringOwn ActionDuringOwnFunc
+	ownerACLPolicy  OwnerACLPolicyFunc
+	journal         *OwnJournal
+	failurePolicy   OwnFailurePolicy
 }
 
 type OwnOption = func(ownCfg) ownCfg
@@ -36,18 +42,38 @@ func WithActionDuringOwn(actionDuringOwn ActionDuringOwnFunc) OwnOption {
 	}
 }
 
+// WithOwnerACLPolicy allows to set the access control list of the owned device, by default DefaultOwnerACLPolicy is used.
+func WithOwnerACLPolicy(ownerACLPolicy OwnerACLPolicyFunc) OwnOption {
+	return func(o ownCfg) ownCfg {
+		if ownerACLPolicy != nil {
+			o.ownerACLPolicy = ownerACLPolicy
+		}
+		return o
+	}
+}
+
+// WithOwnJournal records steps of the ownership transfer to the journal. When the journal
+// of the previous failed transfer is resumable, Own continues from the last successful step.
+func WithOwnJournal(journal *OwnJournal) OwnOption {
+	return func(o ownCfg) ownCfg {
+		o.journal = journal
+		return o
+	}
+}
+
+// WithOwnFailurePolicy sets what Own does with the device when a step fails, by default changes are undone.
+func WithOwnFailurePolicy(failurePolicy OwnFailurePolicy) OwnOption {
+	return func(o ownCfg) ownCfg {
+		o.failurePolicy = failurePolicy
+		return o
+	}
+}
+
 type connUpdateResourcer interface {
 	UpdateResource(context.Context, string, interface{}, interface{}, ...kitNetCoap.OptionFunc) error
 }
 
 func disown(ctx context.Context, conn connUpdateResourcer) error {
-	deadline, ok := ctx.Deadline()
-	if !ok || time.Until(deadline) < time.Second {
-		ctx1, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		ctx = ctx1
-
-	}
 	setResetProvisionState := schema.ProvisionStatusUpdateRequest{
 		DeviceOnboardingState: &schema.DeviceOnboardingState{
 			CurrentOrPendingOperationalState: schema.OperationalState_RESET,
@@ -56,8 +82,34 @@ func disown(ctx context.Context, conn connUpdateResourcer) error {
 	return conn.UpdateResource(ctx, "/oic/sec/pstat", setResetProvisionState, nil)
 }
 
+func setOperationalMode(ctx context.Context, conn connUpdateResourcer, mode schema.OperationalMode) error {
+	updateProvisionState := schema.ProvisionStatusUpdateRequest{
+		CurrentOperationalMode: mode,
+	}
+	/*pstat doesn't send any content for select OperationalMode*/
+	return conn.UpdateResource(ctx, "/oic/sec/pstat", updateProvisionState, nil)
+}
+
+type connDeleteResourcer interface {
+	DeleteResource(context.Context, string, interface{}, ...kitNetCoap.OptionFunc) error
+}
+
+// deleteOwnerCredentials removes credentials set by OTMClient.ProvisionOwnerCredentials.
+func deleteOwnerCredentials(ctx context.Context, conn connDeleteResourcer, ownerID, deviceID string) error {
+	for _, subject := range []string{deviceID, ownerID} {
+		err := conn.DeleteResource(ctx, "/oic/sec/cred", nil, kitNetCoap.WithCredentialSubject(subject))
+		if err != nil {
+			return fmt.Errorf("cannot delete credentials of %v: %w", subject, err)
+		}
+	}
+	return nil
+}
+
 func setOTM(ctx context.Context, conn connUpdateResourcer, selectOwnerTransferMethod schema.OwnerTransferMethod) error {
-	selectOTM := schema.DoxmUpdate{
+	// oxmsel is sent explicitly, because schema.DoxmUpdate omits just works
+	selectOTM := struct {
+		SelectOwnerTransferMethod schema.OwnerTransferMethod `json:"oxmsel"`
+	}{
 		SelectOwnerTransferMethod: selectOwnerTransferMethod,
 	}
 	/*doxm doesn't send any content for update*/
@@ -78,18 +130,9 @@ func (d *Device) selectOTM(ctx context.Context, selectOwnerTransferMethod schema
 	return setOTM(ctx, coapConn, selectOwnerTransferMethod)
 }
 
-func (d *Device) setACL(ctx context.Context, links schema.ResourceLinks, ownerID string) error {
-	link, err := GetResourceLink(links, "/oic/sec/acl2")
-	if err != nil {
-		return err
-	}
-
-	// CleanUp acls rules
-	err = d.DeleteResource(ctx, link, nil)
-	if err != nil {
-		return err
-	}
-
+// DefaultOwnerACLPolicy grants the owner access to all resources and anonymous read access to
+// the discovery resources.
+func DefaultOwnerACLPolicy(links schema.ResourceLinks, ownerID string) acl.UpdateRequest {
 	cloudResources := make([]acl.Resource, 0, 1)
 	for _, href := range links.GetResourceHrefs(cloud.ConfigurationResourceType) {
 		cloudResources = append(cloudResources, acl.Resource{
@@ -99,7 +142,7 @@ func (d *Device) setACL(ctx context.Context, links schema.ResourceLinks, ownerID
 	}
 
 	/*acl2 set owner of resource*/
-	setACL := acl.UpdateRequest{
+	return acl.UpdateRequest{
 		AccessControlList: []acl.AccessControl{
 			{
 				Permission: acl.AllPermissions,
@@ -187,8 +230,87 @@ func (d *Device) setACL(ctx context.Context, links schema.ResourceLinks, ownerID
 			},
 		},
 	}
+}
+
+func (d *Device) setACL(ctx context.Context, links schema.ResourceLinks, ownerID string, ownerACLPolicy OwnerACLPolicyFunc) error {
+	link, err := GetResourceLink(links, "/oic/sec/acl2")
+	if err != nil {
+		return err
+	}
+
+	// CleanUp acls rules
+	err = d.DeleteResource(ctx, link, nil)
+	if err != nil {
+		return err
+	}
+
+	return d.UpdateResource(ctx, link, ownerACLPolicy(links, ownerID), nil)
+}
+
+// rollbackContext returns the context for the rollback. The context of the failed step is kept unless
+// it is canceled or its deadline is nearly reached, because the rollback would fail by it too.
+func rollbackContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if ctx.Err() != nil || (ok && time.Until(deadline) < time.Second) {
+		return context.WithTimeout(context.Background(), time.Second)
+	}
+	return ctx, func() {}
+}
+
+// rollbackOwn undoes the changes of the ownership transfer recorded in the journal in the reverse order.
+func (d *Device) rollbackOwn(ctx context.Context, tlsClient *kitNetCoap.ClientCloseHandler, journal *OwnJournal) {
+	step := journal.LastSuccessfulStep()
+	partialCredentials := journal.lastStep() == OwnStep_ProvisionOwnerCredentials
+	if step < OwnStep_SelectOTM {
+		// the ownership state of the device was not changed
+		return
+	}
+	ctx, cancel := rollbackContext(ctx)
+	defer cancel()
+
+	var errors []error
+	// the operational mode is restored before the reset, which can close the session
+	if step >= OwnStep_SetOperationalMode {
+		err := setOperationalMode(ctx, tlsClient, journal.getPreviousOperationalMode())
+		if err != nil {
+			errors = append(errors, fmt.Errorf("cannot restore operational mode: %w", err))
+		}
+	}
+	switch {
+	case step >= OwnStep_SetOwner:
+		// the owner cannot be unset, so the device is reset to the ready for ownership transfer state
+		err := disown(ctx, tlsClient)
+		if err != nil && !connectionWasClosed(ctx, err) {
+			errors = append(errors, fmt.Errorf("cannot reset device: %w", err))
+		}
+	case step == OwnStep_ProvisionOwnerCredentials, partialCredentials:
+		// credentials could be partially set by the failed step
+		err := deleteOwnerCredentials(ctx, tlsClient, journal.getOwnerID(), d.DeviceID())
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+	// the owned device refuses to select OTM, so it is restored after the reset
+	err := d.selectOTM(ctx, journal.getPreviousOTM())
+	if err != nil {
+		errors = append(errors, fmt.Errorf("cannot restore selected OTM: %w", err))
+	}
+
+	err = nil
+	if len(errors) > 0 {
+		err = fmt.Errorf("%+v", errors)
+	}
+	if journal.record(OwnStep_Rollback, err) != nil {
+		d.cfg.errFunc(fmt.Errorf("cannot rollback ownership of device %v: %w", d.DeviceID(), err))
+	}
+}
 
-	return d.UpdateResource(ctx, link, setACL, nil)
+func (d *Device) handleOwnFailure(ctx context.Context, tlsClient *kitNetCoap.ClientCloseHandler, cfg ownCfg, err error) error {
+	if cfg.failurePolicy == OwnFailurePolicy_Resume && cfg.journal.IsResumable() {
+		return err
+	}
+	d.rollbackOwn(ctx, tlsClient, cfg.journal)
+	return err
 }
 
 // Own set ownership of device
@@ -210,11 +332,15 @@ func (d *Device) Own(
 			}
 			return d.DeviceID(), nil
 		},
+		ownerACLPolicy: DefaultOwnerACLPolicy,
 	}
-	const errMsg = "cannot own device: %w"
 	for _, opt := range options {
 		cfg = opt(cfg)
 	}
+	if cfg.journal == nil {
+		cfg.journal = NewOwnJournal()
+	}
+	journal := cfg.journal
 
 	ownership, err := d.GetOwnership(ctx, links)
 	if err != nil {
@@ -228,6 +354,9 @@ func (d *Device) Own(
 
 	if ownership.Owned {
 		if ownership.OwnerID == sdkID {
+			if journal.IsResumable() && journal.getOwnerID() == sdkID {
+				return d.resumeOwn(ctx, cfg)
+			}
 			return nil
 		}
 		return MakePermissionDenied(fmt.Errorf("device is already owned by %v", ownership.OwnerID))
@@ -245,7 +374,11 @@ func (d *Device) Own(
 		return MakeUnavailable(fmt.Errorf("ownership transfer method '%v' is unsupported, supported are: %v", otmClient.Type(), ownership.SupportedOwnerTransferMethods))
 	}
 
-	err = d.selectOTM(ctx, otmClient.Type())
+	journal.start(sdkID)
+	journal.setPreviousOTM(ownership.SelectedOwnerTransferMethod)
+	err = journal.run(OwnStep_SelectOTM, func() error {
+		return d.selectOTM(ctx, otmClient.Type())
+	})
 	if err != nil {
 		return MakeInternal(fmt.Errorf("cannot select otm: %w", err))
 	}
@@ -281,51 +414,66 @@ func (d *Device) Own(
 	}
 	if tlsClient == nil {
 		if len(errors) == 0 {
-			return MakeInternal(fmt.Errorf("cannot get udp/tcp secure address: not found"))
+			err = journal.record(OwnStep_ConnectOTM, MakeInternal(fmt.Errorf("cannot get udp/tcp secure address: not found")))
+		} else {
+			err = journal.record(OwnStep_ConnectOTM, MakeInternal(fmt.Errorf("cannot get udp/tcp secure address: %+v", errors)))
 		}
-		return MakeInternal(fmt.Errorf("cannot get udp/tcp secure address: %+v", errors))
+		return d.handleOwnFailure(ctx, nil, cfg, err)
 	}
 	defer tlsClient.Close()
+	journal.setSecureEndpoints(secureEndpoints)
+	journal.record(OwnStep_ConnectOTM, nil)
 
-	var provisionState schema.ProvisionStatusResponse
-	err = tlsClient.GetResource(ctx, "/oic/sec/pstat", &provisionState)
-	if err != nil {
-		return MakeInternal(fmt.Errorf("cannot get provision state %w", err))
-	}
+	err = journal.run(OwnStep_SetOperationalMode, func() error {
+		var provisionState schema.ProvisionStatusResponse
+		err := tlsClient.GetResource(ctx, "/oic/sec/pstat", &provisionState)
+		if err != nil {
+			return MakeInternal(fmt.Errorf("cannot get provision state %w", err))
+		}
 
-	if provisionState.DeviceOnboardingState.Pending {
-		return MakeInternal(fmt.Errorf("device pending for operation state %v", provisionState.DeviceOnboardingState.CurrentOrPendingOperationalState))
-	}
+		if provisionState.DeviceOnboardingState.Pending {
+			return MakeInternal(fmt.Errorf("device pending for operation state %v", provisionState.DeviceOnboardingState.CurrentOrPendingOperationalState))
+		}
 
-	if provisionState.DeviceOnboardingState.CurrentOrPendingOperationalState != schema.OperationalState_RFOTM {
-		return MakeInternal(fmt.Errorf("device operation state %v is not %v", provisionState.DeviceOnboardingState.CurrentOrPendingOperationalState, schema.OperationalState_RFOTM))
-	}
+		if provisionState.DeviceOnboardingState.CurrentOrPendingOperationalState != schema.OperationalState_RFOTM {
+			return MakeInternal(fmt.Errorf("device operation state %v is not %v", provisionState.DeviceOnboardingState.CurrentOrPendingOperationalState, schema.OperationalState_RFOTM))
+		}
 
-	if !provisionState.SupportedOperationalModes.Has(schema.OperationalMode_CLIENT_DIRECTED) {
-		return MakeUnavailable(fmt.Errorf("device supports %v, but only %v is supported", provisionState.SupportedOperationalModes, schema.OperationalMode_CLIENT_DIRECTED))
-	}
+		if !provisionState.SupportedOperationalModes.Has(schema.OperationalMode_CLIENT_DIRECTED) {
+			return MakeUnavailable(fmt.Errorf("device supports %v, but only %v is supported", provisionState.SupportedOperationalModes, schema.OperationalMode_CLIENT_DIRECTED))
+		}
 
-	updateProvisionState := schema.ProvisionStatusUpdateRequest{
-		CurrentOperationalMode: schema.OperationalMode_CLIENT_DIRECTED,
-	}
-	/*pstat doesn't send any content for select OperationalMode*/
-	err = tlsClient.UpdateResource(ctx, "/oic/sec/pstat", updateProvisionState, nil)
+		journal.setPreviousOperationalMode(provisionState.CurrentOperationalMode)
+		err = setOperationalMode(ctx, tlsClient, schema.OperationalMode_CLIENT_DIRECTED)
+		if err != nil {
+			return MakeInternal(fmt.Errorf("cannot update provision state %w", err))
+		}
+		return nil
+	})
 	if err != nil {
-		return MakeInternal(fmt.Errorf("cannot update provision state %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, err)
 	}
 
 	if cfg.actionDuringOwn != nil {
-		deviceID, err := cfg.actionDuringOwn(ctx, tlsClient)
+		err = journal.run(OwnStep_ActionDuringOwn, func() error {
+			deviceID, err := cfg.actionDuringOwn(ctx, tlsClient)
+			if err != nil {
+				return err
+			}
+			d.setDeviceID(deviceID)
+			return nil
+		})
 		if err != nil {
-			return err
+			return d.handleOwnFailure(ctx, tlsClient, cfg, err)
 		}
-		d.setDeviceID(deviceID)
 	}
 
 	/*setup credentials */
-	err = otmClient.ProvisionOwnerCredentials(ctx, tlsClient, sdkID, d.DeviceID())
+	err = journal.run(OwnStep_ProvisionOwnerCredentials, func() error {
+		return otmClient.ProvisionOwnerCredentials(ctx, tlsClient, sdkID, d.DeviceID())
+	})
 	if err != nil {
-		return MakeAborted(fmt.Errorf("cannot provision owner %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, MakeAborted(fmt.Errorf("cannot provision owner %w", err)))
 	}
 
 	setDeviceOwner := schema.DoxmUpdate{
@@ -333,60 +481,61 @@ func (d *Device) Own(
 	}
 
 	/*doxm doesn't send any content for select OTM*/
-	err = tlsClient.UpdateResource(ctx, schema.DoxmHref, setDeviceOwner, nil)
+	err = journal.run(OwnStep_SetOwner, func() error {
+		return tlsClient.UpdateResource(ctx, schema.DoxmHref, setDeviceOwner, nil)
+	})
 	if err != nil {
-		return MakeUnavailable(fmt.Errorf("cannot set device owner %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, MakeUnavailable(fmt.Errorf("cannot set device owner %w", err)))
 	}
 
 	/*verify ownership*/
-	var verifyOwner schema.Doxm
-	err = tlsClient.GetResource(ctx, schema.DoxmHref, &verifyOwner)
-	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
+	err = journal.run(OwnStep_VerifyOwner, func() error {
+		var verifyOwner schema.Doxm
+		err := tlsClient.GetResource(ctx, schema.DoxmHref, &verifyOwner)
+		if err != nil {
+			return MakeUnavailable(fmt.Errorf("cannot verify owner %w", err))
 		}
-		return MakeUnavailable(fmt.Errorf("cannot verify owner %w", err))
-	}
-	if verifyOwner.OwnerID != sdkID {
-		return MakeInternal(err)
-	}
-
-	setDeviceOwned := schema.DoxmUpdate{
-		ResourceOwner: sdkID,
-		Owned:         true,
+		if verifyOwner.OwnerID != sdkID {
+			return MakeInternal(fmt.Errorf("cannot verify owner: device is owned by %v", verifyOwner.OwnerID))
+		}
+		return nil
+	})
+	if err != nil {
+		return d.handleOwnFailure(ctx, tlsClient, cfg, err)
 	}
 
 	/*pstat set owner of resource*/
 	setOwnerProvisionState := schema.ProvisionStatusUpdateRequest{
 		ResourceOwner: sdkID,
 	}
-	err = tlsClient.UpdateResource(ctx, "/oic/sec/pstat", setOwnerProvisionState, nil)
+	err = journal.run(OwnStep_SetProvisionStatusOwner, func() error {
+		return tlsClient.UpdateResource(ctx, "/oic/sec/pstat", setOwnerProvisionState, nil)
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
-		return MakeInternal(fmt.Errorf("cannot set owner of resource pstat %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, MakeInternal(fmt.Errorf("cannot set owner of resource pstat %w", err)))
 	}
 
 	/*acl2 set owner of resource*/
 	setOwnerACL := acl.UpdateRequest{
 		ResourceOwner: sdkID,
 	}
-	err = tlsClient.UpdateResource(ctx, "/oic/sec/acl2", setOwnerACL, nil)
+	err = journal.run(OwnStep_SetAccessControlOwner, func() error {
+		return tlsClient.UpdateResource(ctx, "/oic/sec/acl2", setOwnerACL, nil)
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
-		return MakeInternal(fmt.Errorf("cannot set owner of resource acl2: %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, MakeInternal(fmt.Errorf("cannot set owner of resource acl2: %w", err)))
 	}
 
+	setDeviceOwned := schema.DoxmUpdate{
+		ResourceOwner: sdkID,
+		Owned:         true,
+	}
 	/*doxm doesn't send any content for select OTM*/
-	err = tlsClient.UpdateResource(ctx, schema.DoxmHref, setDeviceOwned, nil)
+	err = journal.run(OwnStep_SetOwned, func() error {
+		return tlsClient.UpdateResource(ctx, schema.DoxmHref, setDeviceOwned, nil)
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
-		return MakeInternal(fmt.Errorf("cannot set device owned %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, MakeInternal(fmt.Errorf("cannot set device owned %w", err)))
 	}
 
 	/*set device to provision opertaion mode*/
@@ -395,13 +544,11 @@ func (d *Device) Own(
 			CurrentOrPendingOperationalState: schema.OperationalState_RFPRO,
 		},
 	}
-
-	err = tlsClient.UpdateResource(ctx, "/oic/sec/pstat", provisionOperationState, nil)
+	err = journal.run(OwnStep_SetProvisioningState, func() error {
+		return tlsClient.UpdateResource(ctx, "/oic/sec/pstat", provisionOperationState, nil)
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
-		return MakeInternal(fmt.Errorf("cannot set device to provision operation mode: %w", err))
+		return d.handleOwnFailure(ctx, tlsClient, cfg, MakeInternal(fmt.Errorf("cannot set device to provision operation mode: %w", err)))
 	}
 
 	//For Servers based on OCF 1.0, PostOwnerAcl can be executed using
@@ -412,40 +559,65 @@ func (d *Device) Own(
 	//OC_STACK_UNAUTHORIZED_REQ. After such a failure, OwnerAclHandler
 	//will close the current session and re-establish a new session,
 	//using the Owner Credential.
+	err = d.finishOwn(ctx, sdkID, cfg)
+	if err != nil {
+		return d.handleOwnFailure(ctx, tlsClient, cfg, err)
+	}
+	return nil
+}
 
-	links, err = d.GetResourceLinks(ctx, secureEndpoints)
+// finishOwn executes steps of the ownership transfer which use the owner credentials.
+func (d *Device) finishOwn(ctx context.Context, sdkID string, cfg ownCfg) error {
+	journal := cfg.journal
+	const errMsg = "cannot own device: %w"
+	var links schema.ResourceLinks
+	err := journal.run(OwnStep_GetResourceLinks, func() error {
+		var err error
+		links, err = d.GetResourceLinks(ctx, journal.getSecureEndpoints())
+		return err
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
 		return MakeUnavailable(fmt.Errorf("cannot get resource links: %w", err))
 	}
 
 	/*set owner acl*/
-	err = d.setACL(ctx, links, sdkID)
+	err = journal.run(OwnStep_SetAccessControl, func() error {
+		return d.setACL(ctx, links, sdkID, cfg.ownerACLPolicy)
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
 		return MakeInternal(fmt.Errorf("cannot update resource acl: %w", err))
 	}
 
 	// Provision the device to switch back to normal operation.
-	p, err := d.Provision(ctx, links)
-	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
+	err = journal.run(OwnStep_SetNormalOperationState, func() error {
+		p, err := d.Provision(ctx, links)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf(errMsg, err)
-	}
-
-	err = p.Close(ctx)
+		return p.Close(ctx)
+	})
 	if err != nil {
-		if errDisown := disown(ctx, tlsClient); errDisown != nil {
-			d.cfg.errFunc(fmt.Errorf("cannot disown device: %w", errDisown))
-		}
 		return fmt.Errorf(errMsg, err)
 	}
-
 	return nil
 }
+
+// resumeOwn continues the ownership transfer recorded by the journal.
+func (d *Device) resumeOwn(ctx context.Context, cfg ownCfg) error {
+	journal := cfg.journal
+	err := d.finishOwn(ctx, journal.getOwnerID(), cfg)
+	if err == nil {
+		return nil
+	}
+	if cfg.failurePolicy == OwnFailurePolicy_Resume {
+		return err
+	}
+	_, client, errConn := d.connectToEndpoints(ctx, journal.getSecureEndpoints())
+	if errConn != nil {
+		d.cfg.errFunc(fmt.Errorf("cannot rollback ownership of device %v: %w", d.DeviceID(), errConn))
+		return err
+	}
+	defer d.releaseConnection(client)
+	d.rollbackOwn(ctx, client, journal)
+	return err
+}
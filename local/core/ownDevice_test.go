@@ -9,12 +9,14 @@ import (
 	"github.com/plgd-dev/sdk/local/core"
 	"github.com/plgd-dev/sdk/pkg/net/coap"
 	"github.com/plgd-dev/sdk/schema"
-	"github.com/plgd-dev/sdk/test"
+	"github.com/plgd-dev/sdk/schema/acl"
+	"github.com/plgd-dev/sdk/schema/cloud"
 	"github.com/stretchr/testify/require"
 )
 
 func TestClient_ownDeviceMfg(t *testing.T) {
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestSecureDevice(t)
+	secureDeviceID := dev.ID()
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer c.Close()
@@ -23,7 +25,7 @@ func TestClient_ownDeviceMfg(t *testing.T) {
 	timeout, cancelTimeout := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancelTimeout()
 
-	device, err := c.GetDeviceByMulticast(timeout, deviceID, core.DefaultDiscoveryConfiguration())
+	device, err := c.GetDeviceByMulticast(timeout, deviceID, dev.DiscoveryConfiguration())
 	require.NoError(err)
 	defer device.Close(timeout)
 	eps := device.GetEndpoints()
@@ -36,8 +38,8 @@ func TestClient_ownDeviceMfg(t *testing.T) {
 	require.NoError(err)
 
 	// try disown second time
-	secureDeviceID = test.MustFindDeviceByName(test.TestSecureDeviceName)
-	device, err = c.GetDeviceByMulticast(timeout, secureDeviceID, core.DefaultDiscoveryConfiguration())
+	secureDeviceID = dev.ID()
+	device, err = c.GetDeviceByMulticast(timeout, secureDeviceID, dev.DiscoveryConfiguration())
 	require.NoError(err)
 	defer device.Close(timeout)
 	eps = device.GetEndpoints()
@@ -46,8 +48,8 @@ func TestClient_ownDeviceMfg(t *testing.T) {
 	err = device.Disown(timeout, links)
 	require.NoError(err)
 
-	secureDeviceID = test.MustFindDeviceByName(test.TestSecureDeviceName)
-	device, err = c.GetDeviceByMulticast(timeout, secureDeviceID, core.DefaultDiscoveryConfiguration())
+	secureDeviceID = dev.ID()
+	device, err = c.GetDeviceByMulticast(timeout, secureDeviceID, dev.DiscoveryConfiguration())
 	require.NoError(err)
 	eps = device.GetEndpoints()
 	links, err = device.GetResourceLinks(timeout, eps)
@@ -71,7 +73,7 @@ func TestClient_ownDeviceMfg(t *testing.T) {
 	require.NoError(err)
 	require.NotEqual(t, secureDeviceID, device.DeviceID())
 
-	device, err = c.GetDeviceByMulticast(timeout, device.DeviceID(), core.DefaultDiscoveryConfiguration())
+	device, err = c.GetDeviceByMulticast(timeout, device.DeviceID(), dev.DiscoveryConfiguration())
 	require.NoError(err)
 	eps = device.GetEndpoints()
 	links, err = device.GetResourceLinks(timeout, eps)
@@ -81,7 +83,7 @@ func TestClient_ownDeviceMfg(t *testing.T) {
 }
 
 func TestClient_ownDeviceJustWorks(t *testing.T) {
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestSecureDevice(t)
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer c.Close()
@@ -89,7 +91,7 @@ func TestClient_ownDeviceJustWorks(t *testing.T) {
 	timeout, cancelTimeout := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancelTimeout()
 
-	device, err := c.GetDeviceByMulticast(timeout, secureDeviceID, core.DefaultDiscoveryConfiguration())
+	device, err := c.GetDeviceByMulticast(timeout, dev.ID(), dev.DiscoveryConfiguration())
 	require.NoError(err)
 	defer device.Close(timeout)
 	eps := device.GetEndpoints()
@@ -101,3 +103,28 @@ func TestClient_ownDeviceJustWorks(t *testing.T) {
 	err = device.Disown(timeout, links)
 	require.NoError(err)
 }
+
+func TestDefaultOwnerACLPolicy(t *testing.T) {
+	ownerID := "00000000-0000-0000-0000-000000000001"
+	links := schema.ResourceLinks{
+		{
+			Href:          cloud.ConfigurationResourceHref,
+			ResourceTypes: cloud.ConfigurationResourceTypes,
+		},
+	}
+	got := core.DefaultOwnerACLPolicy(links, ownerID)
+	require.Len(t, got.AccessControlList, 5)
+	for _, ace := range got.AccessControlList[:4] {
+		require.NotNil(t, ace.Subject.Subject_Device)
+		require.Equal(t, ownerID, ace.Subject.Subject_Device.DeviceID)
+	}
+	require.Equal(t, []acl.Resource{
+		{
+			Href:       cloud.ConfigurationResourceHref,
+			Interfaces: []string{"*"},
+		},
+	}, got.AccessControlList[1].Resources)
+	require.NotNil(t, got.AccessControlList[4].Subject.Subject_Connection)
+	require.Equal(t, acl.ConnectionType_ANON_CLEAR, got.AccessControlList[4].Subject.Subject_Connection.Type)
+	require.Equal(t, acl.Permission_READ, got.AccessControlList[4].Permission)
+}
@@ -0,0 +1,201 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// OwnStep is a step of the ownership transfer which is recorded to the OwnJournal.
+type OwnStep int
+
+const (
+	OwnStep_SelectOTM OwnStep = iota + 1
+	OwnStep_ConnectOTM
+	OwnStep_SetOperationalMode
+	OwnStep_ActionDuringOwn
+	OwnStep_ProvisionOwnerCredentials
+	OwnStep_SetOwner
+	OwnStep_VerifyOwner
+	OwnStep_SetProvisionStatusOwner
+	OwnStep_SetAccessControlOwner
+	OwnStep_SetOwned
+	OwnStep_SetProvisioningState
+	OwnStep_GetResourceLinks
+	OwnStep_SetAccessControl
+	OwnStep_SetNormalOperationState
+	// OwnStep_Rollback records the undo of the changes after a failure.
+	OwnStep_Rollback
+)
+
+var ownStepNames = map[OwnStep]string{
+	OwnStep_SelectOTM:                 "select OTM",
+	OwnStep_ConnectOTM:                "connect via OTM",
+	OwnStep_SetOperationalMode:        "set operational mode",
+	OwnStep_ActionDuringOwn:           "action during own",
+	OwnStep_ProvisionOwnerCredentials: "provision owner credentials",
+	OwnStep_SetOwner:                  "set owner",
+	OwnStep_VerifyOwner:               "verify owner",
+	OwnStep_SetProvisionStatusOwner:   "set owner of pstat",
+	OwnStep_SetAccessControlOwner:     "set owner of acl2",
+	OwnStep_SetOwned:                  "set owned",
+	OwnStep_SetProvisioningState:      "set provisioning state",
+	OwnStep_GetResourceLinks:          "get resource links",
+	OwnStep_SetAccessControl:          "set access control",
+	OwnStep_SetNormalOperationState:   "set normal operation state",
+	OwnStep_Rollback:                  "rollback",
+}
+
+func (s OwnStep) String() string {
+	if v, ok := ownStepNames[s]; ok {
+		return v
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// OwnFailurePolicy defines what Own does with the device when a step fails.
+type OwnFailurePolicy int
+
+const (
+	// OwnFailurePolicy_Rollback undoes changes of the successful steps.
+	OwnFailurePolicy_Rollback OwnFailurePolicy = 0
+	// OwnFailurePolicy_Resume keeps the device owned when it was already switched to the provisioning state
+	// by OwnStep_SetProvisioningState, so the next Own with the same journal continues from the last successful step.
+	// Failures of the earlier steps, including the steps after the owner credentials were set, are undone as for
+	// OwnFailurePolicy_Rollback, because the device ready for the ownership transfer accepts only the OTM session.
+	OwnFailurePolicy_Resume OwnFailurePolicy = 1
+)
+
+// OwnJournalEntry is the record of the executed step.
+type OwnJournalEntry struct {
+	Step OwnStep
+	Time time.Time
+	Err  error
+}
+
+// OwnJournal records steps of the ownership transfer of a device.
+type OwnJournal struct {
+	lock            sync.Mutex
+	entries         []OwnJournalEntry
+	progress        OwnStep
+	ownerID         string
+	secureEndpoints schema.Endpoints
+	// previous values of the device are restored by the rollback
+	previousOTM             schema.OwnerTransferMethod
+	previousOperationalMode schema.OperationalMode
+}
+
+func NewOwnJournal() *OwnJournal {
+	return &OwnJournal{}
+}
+
+// Entries returns all recorded steps.
+func (j *OwnJournal) Entries() []OwnJournalEntry {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	entries := make([]OwnJournalEntry, len(j.entries))
+	copy(entries, j.entries)
+	return entries
+}
+
+// LastSuccessfulStep returns the last successfully finished step of the ownership transfer, 0 means none.
+func (j *OwnJournal) LastSuccessfulStep() OwnStep {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.progress
+}
+
+// Completed returns true when the ownership transfer finished.
+func (j *OwnJournal) Completed() bool {
+	return j.LastSuccessfulStep() == OwnStep_SetNormalOperationState
+}
+
+// IsResumable returns true when the device is owned, but the ownership transfer didn't finish.
+func (j *OwnJournal) IsResumable() bool {
+	step := j.LastSuccessfulStep()
+	return step >= OwnStep_SetProvisioningState && step < OwnStep_SetNormalOperationState
+}
+
+func (j *OwnJournal) lastStep() OwnStep {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if len(j.entries) == 0 {
+		return 0
+	}
+	return j.entries[len(j.entries)-1].Step
+}
+
+func (j *OwnJournal) start(ownerID string) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.progress = 0
+	j.ownerID = ownerID
+	j.secureEndpoints = nil
+}
+
+func (j *OwnJournal) setSecureEndpoints(endpoints schema.Endpoints) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.secureEndpoints = endpoints
+}
+
+func (j *OwnJournal) getSecureEndpoints() schema.Endpoints {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.secureEndpoints
+}
+
+func (j *OwnJournal) setPreviousOTM(otm schema.OwnerTransferMethod) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.previousOTM = otm
+}
+
+func (j *OwnJournal) getPreviousOTM() schema.OwnerTransferMethod {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.previousOTM
+}
+
+func (j *OwnJournal) setPreviousOperationalMode(mode schema.OperationalMode) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.previousOperationalMode = mode
+}
+
+func (j *OwnJournal) getPreviousOperationalMode() schema.OperationalMode {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.previousOperationalMode
+}
+
+func (j *OwnJournal) getOwnerID() string {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.ownerID
+}
+
+func (j *OwnJournal) record(step OwnStep, err error) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.entries = append(j.entries, OwnJournalEntry{
+		Step: step,
+		Time: time.Now(),
+		Err:  err,
+	})
+	switch {
+	case step == OwnStep_Rollback:
+		if err == nil {
+			j.progress = 0
+		}
+	case err == nil:
+		j.progress = step
+	}
+	return err
+}
+
+func (j *OwnJournal) run(step OwnStep, f func() error) error {
+	return j.record(step, f())
+}
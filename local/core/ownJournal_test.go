@@ -0,0 +1,262 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	kitNet "github.com/plgd-dev/kit/net"
+	ocf "github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/server"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+// failNth fails the nth request with the method to the href.
+func failNth(method codes.Code, href string, n int32) test.FailureFunc {
+	var count int32
+	return func(m codes.Code, h string) error {
+		if m != method || h != href {
+			return nil
+		}
+		if atomic.AddInt32(&count, 1) == n {
+			return server.NewError(codes.ServiceUnavailable, fmt.Errorf("injected failure"))
+		}
+		return nil
+	}
+}
+
+// request is the nth request with the method to the href.
+type request struct {
+	method codes.Code
+	href   string
+	n      int32
+}
+
+// failingDialOTM fails to connect to the device.
+type failingDialOTM struct {
+	ocf.OTMClient
+}
+
+func (failingDialOTM) Dial(context.Context, kitNet.Addr, ...coap.DialOptionFunc) (*coap.ClientCloseHandler, error) {
+	return nil, fmt.Errorf("injected failure")
+}
+
+func TestClient_ownDeviceJournal(t *testing.T) {
+	// the operational mode and the selected OTM differ from values set by Own, so the rollback can be verified
+	const operationalMode = schema.OperationalMode_SERVER_DIRECTED_UTILIZING_SINGLE_SERVICE
+	tests := []struct {
+		name      string
+		step      ocf.OwnStep
+		failure   request
+		dialFails bool
+		resumable bool
+	}{
+		{
+			name:    "select OTM",
+			step:    ocf.OwnStep_SelectOTM,
+			failure: request{codes.POST, schema.DoxmHref, 1},
+		},
+		{
+			name:      "connect OTM",
+			step:      ocf.OwnStep_ConnectOTM,
+			dialFails: true,
+		},
+		{
+			name:    "set operational mode",
+			step:    ocf.OwnStep_SetOperationalMode,
+			failure: request{codes.GET, "/oic/sec/pstat", 1},
+		},
+		{
+			name:    "action during own",
+			step:    ocf.OwnStep_ActionDuringOwn,
+			failure: request{codes.POST, schema.DoxmHref, 2},
+		},
+		{
+			name:    "provision owner credentials",
+			step:    ocf.OwnStep_ProvisionOwnerCredentials,
+			failure: request{codes.POST, "/oic/sec/cred", 1},
+		},
+		{
+			name:    "set owner",
+			step:    ocf.OwnStep_SetOwner,
+			failure: request{codes.POST, schema.DoxmHref, 3},
+		},
+		{
+			name:    "verify owner",
+			step:    ocf.OwnStep_VerifyOwner,
+			failure: request{codes.GET, schema.DoxmHref, 2},
+		},
+		{
+			name:    "set owner of pstat",
+			step:    ocf.OwnStep_SetProvisionStatusOwner,
+			failure: request{codes.POST, "/oic/sec/pstat", 2},
+		},
+		{
+			name:    "set owner of acl2",
+			step:    ocf.OwnStep_SetAccessControlOwner,
+			failure: request{codes.POST, "/oic/sec/acl2", 1},
+		},
+		{
+			name:    "set owned",
+			step:    ocf.OwnStep_SetOwned,
+			failure: request{codes.POST, schema.DoxmHref, 4},
+		},
+		{
+			name:    "set provisioning state",
+			step:    ocf.OwnStep_SetProvisioningState,
+			failure: request{codes.POST, "/oic/sec/pstat", 3},
+		},
+		{
+			name:      "set access control",
+			step:      ocf.OwnStep_SetAccessControl,
+			failure:   request{codes.DELETE, "/oic/sec/acl2", 1},
+			resumable: true,
+		},
+		{
+			name:      "set normal operation state",
+			step:      ocf.OwnStep_SetNormalOperationState,
+			failure:   request{codes.POST, "/oic/sec/pstat", 4},
+			resumable: true,
+		},
+	}
+
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer c.Close()
+
+	policies := map[string]ocf.OwnFailurePolicy{
+		"rollback": ocf.OwnFailurePolicy_Rollback,
+		"resume":   ocf.OwnFailurePolicy_Resume,
+	}
+	for policyName, policy := range policies {
+		for _, tt := range tests {
+			t.Run(policyName+"/"+tt.name, func(t *testing.T) {
+				dev := NewTestSecureDevice(t,
+					test.WithOperationalModes(schema.OperationalMode_CLIENT_DIRECTED|operationalMode, operationalMode),
+					test.WithFailure(failNth(tt.failure.method, tt.failure.href, tt.failure.n)))
+				ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+				defer cancel()
+				device, err := c.GetDeviceByMulticast(ctx, dev.ID(), dev.DiscoveryConfiguration())
+				require.NoError(t, err)
+				defer device.Close(ctx)
+				links, err := device.GetResourceLinks(ctx, device.GetEndpoints())
+				require.NoError(t, err)
+
+				var otm ocf.OTMClient = c.mfgOtm
+				if tt.dialFails {
+					otm = failingDialOTM{OTMClient: c.mfgOtm}
+				}
+				journal := ocf.NewOwnJournal()
+				err = device.Own(ctx, links, otm, ocf.WithOwnJournal(journal), ocf.WithOwnFailurePolicy(policy))
+				require.Error(t, err)
+
+				var failed bool
+				for _, e := range journal.Entries() {
+					if e.Step == tt.step && e.Err != nil {
+						failed = true
+					}
+				}
+				require.True(t, failed, "step %v didn't fail: %+v", tt.step, journal.Entries())
+
+				if policy == ocf.OwnFailurePolicy_Resume && tt.resumable {
+					require.True(t, journal.IsResumable())
+					require.Equal(t, tt.step-1, journal.LastSuccessfulStep())
+					require.True(t, dev.Doxm().Owned)
+				} else {
+					// the changes are undone
+					require.False(t, journal.IsResumable())
+					require.Equal(t, ocf.OwnStep(0), journal.LastSuccessfulStep())
+					if tt.step > ocf.OwnStep_SelectOTM {
+						last := journal.Entries()[len(journal.Entries())-1]
+						require.Equal(t, ocf.OwnStep_Rollback, last.Step)
+						require.NoError(t, last.Err)
+					}
+					doxm := dev.Doxm()
+					require.False(t, doxm.Owned)
+					require.Equal(t, schema.JustWorks, doxm.SelectedOwnerTransferMethod)
+					require.Equal(t, operationalMode, dev.ProvisionStatus().CurrentOperationalMode)
+					require.Empty(t, dev.Credentials().Credentials)
+				}
+
+				// the next Own with the journal resumes or starts again
+				dev.SetFailure(nil)
+				err = device.Own(ctx, links, c.mfgOtm, ocf.WithOwnJournal(journal), ocf.WithOwnFailurePolicy(policy))
+				require.NoError(t, err)
+				require.True(t, journal.Completed())
+				require.True(t, dev.Doxm().Owned)
+				require.True(t, dev.ProvisionStatus().DeviceIsOperational)
+
+				ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				err = device.Disown(ctx, links)
+				require.NoError(t, err)
+			})
+		}
+	}
+}
+
+func TestClient_ownDeviceResumeBeforeProvisioningState(t *testing.T) {
+	// the owner credentials are set, but the device is not switched to the provisioning state yet
+	tests := []struct {
+		name    string
+		step    ocf.OwnStep
+		failure request
+	}{
+		{
+			name:    "set owner",
+			step:    ocf.OwnStep_SetOwner,
+			failure: request{codes.POST, schema.DoxmHref, 3},
+		},
+		{
+			name:    "set owner of acl2",
+			step:    ocf.OwnStep_SetAccessControlOwner,
+			failure: request{codes.POST, "/oic/sec/acl2", 1},
+		},
+		{
+			name:    "set owned",
+			step:    ocf.OwnStep_SetOwned,
+			failure: request{codes.POST, schema.DoxmHref, 4},
+		},
+	}
+
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := NewTestSecureDevice(t, test.WithFailure(failNth(tt.failure.method, tt.failure.href, tt.failure.n)))
+			ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+			defer cancel()
+			device, err := c.GetDeviceByMulticast(ctx, dev.ID(), dev.DiscoveryConfiguration())
+			require.NoError(t, err)
+			defer device.Close(ctx)
+			links, err := device.GetResourceLinks(ctx, device.GetEndpoints())
+			require.NoError(t, err)
+
+			journal := ocf.NewOwnJournal()
+			err = device.Own(ctx, links, c.mfgOtm, ocf.WithOwnJournal(journal), ocf.WithOwnFailurePolicy(ocf.OwnFailurePolicy_Resume))
+			require.Error(t, err)
+
+			var provisioned bool
+			for _, e := range journal.Entries() {
+				if e.Step == ocf.OwnStep_ProvisionOwnerCredentials && e.Err == nil {
+					provisioned = true
+				}
+			}
+			require.True(t, provisioned, "owner credentials were not set: %+v", journal.Entries())
+			require.False(t, journal.IsResumable())
+			last := journal.Entries()[len(journal.Entries())-1]
+			require.Equal(t, ocf.OwnStep_Rollback, last.Step)
+			require.NoError(t, last.Err)
+			require.False(t, dev.Doxm().Owned)
+			require.Empty(t, dev.Credentials().Credentials)
+		})
+	}
+}
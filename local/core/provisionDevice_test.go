@@ -8,7 +8,6 @@ import (
 	"testing"
 	"time"
 
-	ocf "github.com/plgd-dev/sdk/local/core"
 	"github.com/plgd-dev/sdk/schema/acl"
 	"github.com/plgd-dev/sdk/schema/cloud"
 	"github.com/stretchr/testify/require"
@@ -43,7 +42,7 @@ func TestProvisioning(t *testing.T) {
 	require.NoError(t, err)
 	c2, err := NewTestSecureClientWithCert(cert, false, false)
 	require.NoError(t, err)
-	d, err := c2.GetDeviceByMulticast(ctx, c.DeviceID, ocf.DefaultDiscoveryConfiguration())
+	d, err := c2.GetDeviceByMulticast(ctx, c.DeviceID, c.DiscoveryConfiguration)
 	require.NoError(t, err)
 	defer d.Close(ctx)
 	eps := d.GetEndpoints()
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	kitSecurity "github.com/plgd-dev/kit/security"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+func isIdentityCertificate(cred schema.Credential) bool {
+	return cred.Usage == schema.CredentialUsage_CERT && cred.Type == schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE
+}
+
+func (d *Device) getIdentityCredentials(ctx context.Context, link schema.ResourceLink) ([]schema.Credential, error) {
+	var resp schema.CredentialResponse
+	err := d.GetResource(ctx, link, &resp, kitNetCoap.WithCredentialSubject(d.DeviceID()))
+	if err != nil {
+		return nil, err
+	}
+	creds := make([]schema.Credential, 0, 1)
+	for _, cred := range resp.Credentials {
+		// device could ignore the query
+		if cred.Subject == d.DeviceID() && isIdentityCertificate(cred) {
+			creds = append(creds, cred)
+		}
+	}
+	return creds, nil
+}
+
+// GetIdentityCertificate returns the identity certificate of the owned device.
+func (d *Device) GetIdentityCertificate(ctx context.Context, links schema.ResourceLinks) (*x509.Certificate, error) {
+	const errMsg = "cannot get identity certificate: %w"
+	link, err := GetSecureResourceLink(links, "/oic/sec/cred")
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	creds, err := d.getIdentityCredentials(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+	var cert *x509.Certificate
+	for _, cred := range creds {
+		if cred.PublicData == nil {
+			continue
+		}
+		certs, err := kitSecurity.ParseX509FromPEM(cred.PublicData.Data())
+		if err != nil {
+			return nil, fmt.Errorf(errMsg, err)
+		}
+		if cert == nil || certs[0].NotAfter.After(cert.NotAfter) {
+			cert = certs[0]
+		}
+	}
+	if cert == nil {
+		return nil, MakeNotFound(fmt.Errorf(errMsg, fmt.Errorf("not found")))
+	}
+	return cert, nil
+}
+
+// RenewIdentityCertificate signs the certificate signing request of the device and replaces the identity certificate.
+// It returns the expiration of the new certificate.
+func (d *Device) RenewIdentityCertificate(ctx context.Context, links schema.ResourceLinks, signer CertificateSigner) (time.Time, error) {
+	const errMsg = "cannot renew identity certificate: %w"
+	csrLink, err := GetSecureResourceLink(links, "/oic/sec/csr")
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, err)
+	}
+	credLink, err := GetSecureResourceLink(links, "/oic/sec/cred")
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, err)
+	}
+
+	var csr schema.CertificateSigningRequestResponse
+	err = d.GetResource(ctx, csrLink, &csr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, fmt.Errorf("cannot get csr: %w", err))
+	}
+	signedCsr, err := signer.Sign(ctx, csr.PEM())
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, fmt.Errorf("cannot sign csr: %w", err))
+	}
+	certs, err := kitSecurity.ParseX509FromPEM(signedCsr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, err)
+	}
+
+	p, err := d.Provision(ctx, links)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, err)
+	}
+	err = replaceIdentityCredential(ctx, p, credLink, signedCsr)
+	errClose := p.Close(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(errMsg, err)
+	}
+	if errClose != nil {
+		return time.Time{}, fmt.Errorf(errMsg, errClose)
+	}
+	return certs[0].NotAfter, nil
+}
+
+// replaceIdentityCredential sets the new identity certificate first, so the device has always one.
+func replaceIdentityCredential(ctx context.Context, p *ProvisioningClient, link schema.ResourceLink, cert []byte) error {
+	oldCreds, err := p.getIdentityCredentials(ctx, link)
+	if err != nil {
+		return fmt.Errorf("cannot get identity credentials: %w", err)
+	}
+	setIdentityCredential := schema.CredentialUpdateRequest{
+		Credentials: []schema.Credential{
+			{
+				Subject: p.DeviceID(),
+				Type:    schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE,
+				Usage:   schema.CredentialUsage_CERT,
+				PublicData: &schema.CredentialPublicData{
+					DataInternal: string(cert),
+					Encoding:     schema.CredentialPublicDataEncoding_PEM,
+				},
+			},
+		},
+	}
+	err = p.UpdateResource(ctx, link, setIdentityCredential, nil)
+	if err != nil {
+		return fmt.Errorf("cannot set identity credential: %w", err)
+	}
+	for _, cred := range oldCreds {
+		err = p.DeleteResource(ctx, link, nil, kitNetCoap.WithCredentialId(cred.ID))
+		if err != nil {
+			return fmt.Errorf("cannot delete identity credential %v: %w", cred.ID, err)
+		}
+	}
+	return nil
+}
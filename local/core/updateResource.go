@@ -32,6 +32,7 @@ func (d *Device) UpdateResourceWithCodec(
 	if err != nil {
 		return MakeInternal(fmt.Errorf("cannot update resource %v: %w", link.Href, err))
 	}
+	defer d.releaseConnection(client)
 	options = append(options, kitNetCoap.WithAccept(codec.ContentFormat()))
 
 	return client.UpdateResourceWithCodec(ctx, link.Href, codec, request, response, options...)
@@ -0,0 +1,47 @@
+package local
+
+import (
+	"context"
+	"fmt"
+
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// CreateResource creates a child resource in the collection identified by href, it returns the href of the created resource.
+func (c *Client) CreateResource(
+	ctx context.Context,
+	deviceID string,
+	href string,
+	request interface{},
+	opts ...CreateOption,
+) (string, error) {
+	cfg := createOptions{
+		codec: codecOcf.VNDOCFCBORCodec{},
+	}
+	for _, o := range opts {
+		cfg = o.applyOnCreate(cfg)
+	}
+
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return "", err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetResourceLink(links, href)
+	if err != nil {
+		return "", err
+	}
+
+	var created schema.ResourceLink
+	err = d.CreateResourceWithCodec(ctx, link, cfg.codec, request, &created, cfg.opts...)
+	if err != nil {
+		return "", err
+	}
+	if created.Href == "" {
+		return "", fmt.Errorf("cannot create resource in %v: href of the created resource is not set", href)
+	}
+	return created.Href, nil
+}
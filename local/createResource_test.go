@@ -0,0 +1,96 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateResource(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure(), test.WithCollection("/lights", "/light/1"))
+	deviceID := dev.ID()
+	type args struct {
+		deviceID string
+		href     string
+		data     interface{}
+		opts     []local.CreateOption
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    test.Light
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			args: args{
+				deviceID: deviceID,
+				href:     "/lights",
+				data: map[string]interface{}{
+					"rt":  []string{"core.light"},
+					"if":  []string{"oic.if.rw", "oic.if.baseline"},
+					"rep": map[string]interface{}{"name": "Created", "power": uint64(3)},
+				},
+			},
+			want: test.Light{Name: "Created", Power: 3},
+		},
+		{
+			name: "not a collection",
+			args: args{
+				deviceID: deviceID,
+				href:     "/light/1",
+				data: map[string]interface{}{
+					"rt": []string{"oic.r.switch.binary"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid href",
+			args: args{
+				deviceID: deviceID,
+				href:     "/invalid/href",
+				data: map[string]interface{}{
+					"rt": []string{"oic.r.switch.binary"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+
+	c := NewTestClient()
+	defer c.Close(context.Background())
+	StoreTestDevices(t, c, dev)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			got, err := c.CreateResource(ctx, tt.args.deviceID, tt.args.href, tt.args.data, tt.args.opts...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, dev.Server().Links(nil, nil).GetResourceHrefs("core.light"), got)
+
+			// the created resource is the child of the collection and it has the requested state
+			var links schema.ResourceLinks
+			err = c.GetResource(ctx, tt.args.deviceID, tt.args.href, &links)
+			require.NoError(t, err)
+			_, ok := links.GetResourceLink(got)
+			require.True(t, ok)
+			var rep test.Light
+			err = c.GetResource(ctx, tt.args.deviceID, got, &rep)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, rep)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/local/core"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+const credentialsHref = "/oic/sec/cred"
+
+func (c *Client) getCredentials(ctx context.Context, deviceID string, options ...kitNetCoap.OptionFunc) ([]schema.Credential, error) {
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetSecureResourceLink(links, credentialsHref)
+	if err != nil {
+		return nil, err
+	}
+	var resp schema.CredentialResponse
+	err = d.GetResourceWithCodec(ctx, link, codecOcf.VNDOCFCBORCodec{}, &resp, options...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get credentials of device %v: %w", deviceID, err)
+	}
+	return resp.Credentials, nil
+}
+
+// GetCredentials returns credentials of the owned device.
+func (c *Client) GetCredentials(ctx context.Context, deviceID string) ([]schema.Credential, error) {
+	return c.getCredentials(ctx, deviceID)
+}
+
+// GetCredential returns the credential identified by credentialID.
+func (c *Client) GetCredential(ctx context.Context, deviceID string, credentialID int) (schema.Credential, error) {
+	creds, err := c.getCredentials(ctx, deviceID, kitNetCoap.WithCredentialId(credentialID))
+	if err != nil {
+		return schema.Credential{}, err
+	}
+	// device could ignore the query
+	for _, cred := range creds {
+		if cred.ID == credentialID {
+			return cred, nil
+		}
+	}
+	return schema.Credential{}, core.MakeNotFound(fmt.Errorf("cannot find credential %v of device %v", credentialID, deviceID))
+}
+
+// DeleteCredential removes the credential identified by credentialID.
+func (c *Client) DeleteCredential(ctx context.Context, deviceID string, credentialID int) error {
+	return c.provisionDevice(ctx, deviceID, credentialsHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		return p.DeleteResource(ctx, link, nil, kitNetCoap.WithCredentialId(credentialID))
+	})
+}
+
+// DeleteCredentialsBySubject removes all credentials of the subject.
+func (c *Client) DeleteCredentialsBySubject(ctx context.Context, deviceID string, subject string) error {
+	return c.provisionDevice(ctx, deviceID, credentialsHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		return p.DeleteResource(ctx, link, nil, kitNetCoap.WithCredentialSubject(subject))
+	})
+}
+
+func credentialHasCertificate(cred schema.Credential, cert *x509.Certificate) bool {
+	if cred.PublicData == nil {
+		return false
+	}
+	data := cred.PublicData.Data()
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return bytes.Equal(data, cert.Raw)
+}
+
+// ReplaceTrustAnchor sets the certificate authority as the only trust anchor of the subject.
+// The new certificate authority is added before the old ones are removed, so the device always trusts the subject.
+func (c *Client) ReplaceTrustAnchor(ctx context.Context, deviceID string, subject string, ca *x509.Certificate) error {
+	return c.provisionDevice(ctx, deviceID, credentialsHref, func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error {
+		var resp schema.CredentialResponse
+		err := p.GetResource(ctx, link, &resp)
+		if err != nil {
+			return err
+		}
+		var found bool
+		remove := make([]int, 0, 1)
+		for _, cred := range resp.Credentials {
+			if cred.Subject != subject || cred.Usage != schema.CredentialUsage_TRUST_CA {
+				continue
+			}
+			if !found && credentialHasCertificate(cred, ca) {
+				found = true
+				continue
+			}
+			remove = append(remove, cred.ID)
+		}
+		if !found {
+			err = p.AddCertificateAuthority(ctx, subject, ca)
+			if err != nil {
+				return err
+			}
+		}
+		for _, id := range remove {
+			err = p.DeleteResource(ctx, link, nil, kitNetCoap.WithCredentialId(id))
+			if err != nil {
+				return fmt.Errorf("cannot remove credential %v: %w", id, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+package local_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Credentials(t *testing.T) {
+	dev := NewTestDevice(t)
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	deviceID, err := c.OwnDevice(ctx, dev.ID())
+	require.NoError(t, err)
+	defer func() {
+		err := c.DisownDevice(ctx, deviceID)
+		require.NoError(t, err)
+	}()
+
+	creds, err := c.GetCredentials(ctx, deviceID)
+	require.NoError(t, err)
+	require.NotEmpty(t, creds)
+
+	cred, err := c.GetCredential(ctx, deviceID, creds[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, creds[0], cred)
+
+	_, err = c.GetCredential(ctx, deviceID, -1)
+	require.Error(t, err)
+
+	block, _ := pem.Decode(MfgTrustedCA)
+	require.NotNil(t, block)
+	ca, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	const subject = "00000000-0000-0000-0000-000000000001"
+	countTrustCA := func() []schema.Credential {
+		creds, err := c.GetCredentials(ctx, deviceID)
+		require.NoError(t, err)
+		res := make([]schema.Credential, 0, 1)
+		for _, cred := range creds {
+			if cred.Subject == subject && cred.Usage == schema.CredentialUsage_TRUST_CA {
+				res = append(res, cred)
+			}
+		}
+		return res
+	}
+	for i := 0; i < 2; i++ {
+		err = c.ReplaceTrustAnchor(ctx, deviceID, subject, ca)
+		require.NoError(t, err)
+		require.Len(t, countTrustCA(), 1)
+	}
+
+	err = c.DeleteCredential(ctx, deviceID, countTrustCA()[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, countTrustCA())
+
+	err = c.ReplaceTrustAnchor(ctx, deviceID, subject, ca)
+	require.NoError(t, err)
+	err = c.DeleteCredentialsBySubject(ctx, deviceID, subject)
+	require.NoError(t, err)
+	require.Empty(t, countTrustCA())
+
+	// the rotation replaces the old certificate authority of the subject and keeps other credentials
+	block, _ = pem.Decode(IdentityTrustedCA)
+	require.NotNil(t, block)
+	newCA, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	const otherSubject = "00000000-0000-0000-0000-000000000002"
+	err = c.ReplaceTrustAnchor(ctx, deviceID, otherSubject, ca)
+	require.NoError(t, err)
+	err = c.ReplaceTrustAnchor(ctx, deviceID, subject, ca)
+	require.NoError(t, err)
+	others := func() []schema.Credential {
+		creds, err := c.GetCredentials(ctx, deviceID)
+		require.NoError(t, err)
+		res := make([]schema.Credential, 0, len(creds))
+		for _, cred := range creds {
+			if cred.Subject != subject || cred.Usage != schema.CredentialUsage_TRUST_CA {
+				res = append(res, cred)
+			}
+		}
+		return res
+	}
+	before := others()
+
+	err = c.ReplaceTrustAnchor(ctx, deviceID, subject, newCA)
+	require.NoError(t, err)
+	got := countTrustCA()
+	require.Len(t, got, 1)
+	require.True(t, hasCertificate(got[0], newCA))
+	require.False(t, hasCertificate(got[0], ca))
+	require.Equal(t, before, others())
+}
+
+func hasCertificate(cred schema.Credential, cert *x509.Certificate) bool {
+	if cred.PublicData == nil {
+		return false
+	}
+	data := cred.PublicData.Data()
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return bytes.Equal(data, cert.Raw)
+}
@@ -0,0 +1,36 @@
+package local
+
+import (
+	"context"
+
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/local/core"
+)
+
+func (c *Client) DeleteResource(
+	ctx context.Context,
+	deviceID string,
+	href string,
+	response interface{},
+	opts ...DeleteOption,
+) error {
+	cfg := deleteOptions{
+		codec: codecOcf.VNDOCFCBORCodec{},
+	}
+	for _, o := range opts {
+		cfg = o.applyOnDelete(cfg)
+	}
+
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetResourceLink(links, href)
+	if err != nil {
+		return err
+	}
+
+	return d.DeleteResourceWithCodec(ctx, link, cfg.codec, response, cfg.opts...)
+}
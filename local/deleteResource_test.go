@@ -0,0 +1,106 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DeleteResource(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure(), test.WithCollection("/lights", "/light/1"))
+	deviceID := dev.ID()
+	type args struct {
+		deviceID string
+		href     string
+		opts     []local.DeleteOption
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			args: args{
+				deviceID: deviceID,
+				href:     "/lights/1",
+			},
+		},
+		{
+			name: "deleted",
+			args: args{
+				deviceID: deviceID,
+				href:     "/lights/1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "not deletable",
+			args: args{
+				deviceID: deviceID,
+				href:     "/light/1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "not deletable with interface",
+			args: args{
+				deviceID: deviceID,
+				href:     "/light/1",
+				opts:     []local.DeleteOption{local.WithInterface("oic.if.baseline")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid href",
+			args: args{
+				deviceID: deviceID,
+				href:     "/invalid/href",
+			},
+			wantErr: true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+
+	c := NewTestClient()
+	defer c.Close(context.Background())
+	StoreTestDevices(t, c, dev)
+	created, err := c.CreateResource(ctx, deviceID, "/lights", map[string]interface{}{
+		"rt":  []string{"core.light"},
+		"rep": map[string]interface{}{"name": "Created"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/lights/1", created)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			var got interface{}
+			err := c.DeleteResource(ctx, tt.args.deviceID, tt.args.href, &got, tt.args.opts...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// the resource is gone from the device and from the collection
+			_, ok := dev.Server().Links(nil, nil).GetResourceLink(tt.args.href)
+			require.False(t, ok)
+			var links schema.ResourceLinks
+			err = c.GetResource(ctx, tt.args.deviceID, "/lights", &links)
+			require.NoError(t, err)
+			_, ok = links.GetResourceLink(tt.args.href)
+			require.False(t, ok)
+			var rep interface{}
+			err = c.GetResource(ctx, tt.args.deviceID, tt.args.href, &rep)
+			require.Error(t, err)
+		})
+	}
+}
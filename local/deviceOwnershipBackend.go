@@ -105,6 +105,15 @@ type appDeviceOwnershipBackend struct {
 	getRootCertificateAuthorities func() ([]*x509.Certificate, error)
 	manufacturerCertificate       tls.Certificate
 	manufacturerCACert            []*x509.Certificate
+	app                           ApplicationCallback
+}
+
+func (a appDeviceOwnershipBackend) GetRandomPIN(ctx context.Context, deviceID string) (string, error) {
+	pinApp, ok := a.app.(RandomPINApplicationCallback)
+	if !ok {
+		return "", fmt.Errorf("application callback doesn't support random PIN")
+	}
+	return pinApp.GetRandomPIN(ctx, deviceID)
 }
 
 func (a appDeviceOwnershipBackend) GetRootCertificateAuthorities() ([]*x509.Certificate, error) {
@@ -137,6 +146,12 @@ func (o *deviceOwnershipBackend) OwnDevice(ctx context.Context, deviceID string,
 		otmClient = otm
 	case OTMType_JustWorks:
 		otmClient = justworks.NewClient(identCert, justworks.WithDialDTLS(o.dialDTLS))
+	case OTMType_RandomPin:
+		otm, err := getOTMRandomPin(o.app, identCert, o.dialDTLS, deviceID)
+		if err != nil {
+			return "", err
+		}
+		otmClient = otm
 	default:
 		return "", fmt.Errorf("unsupported ownership transfer method: %v", otmType)
 	}
@@ -203,6 +218,7 @@ func (o *deviceOwnershipBackend) setManufacturerCertificate(ctx context.Context,
 		getRootCertificateAuthorities: o.app.GetRootCertificateAuthorities,
 		manufacturerCACert:            caCert,
 		manufacturerCertificate:       cert,
+		app:                           o.app,
 	}
 
 	return nil
@@ -257,6 +273,10 @@ func (o *deviceOwnershipBackend) GetIdentityCACerts() ([]*x509.Certificate, erro
 	return o.identityCACert, nil
 }
 
+func (o *deviceOwnershipBackend) GetIdentityCertificateSigner(ctx context.Context) (core.CertificateSigner, error) {
+	return caSigner.NewIdentityCertificateSigner(o.caClient), nil
+}
+
 func (o *deviceOwnershipBackend) GetAccessTokenURL(ctx context.Context) (string, error) {
 	return o.accessTokenURL, nil
 }
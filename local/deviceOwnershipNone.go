@@ -43,6 +43,10 @@ func (o *deviceOwnershipNone) GetIdentityCertificate() (tls.Certificate, error)
 	return tls.Certificate{}, fmt.Errorf("not supported")
 }
 
+func (o *deviceOwnershipNone) GetIdentityCertificateSigner(ctx context.Context) (core.CertificateSigner, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
 func (o *deviceOwnershipNone) GetAccessTokenURL(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("not supported")
 }
@@ -6,11 +6,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/plgd-dev/sdk/local/core"
 	justworks "github.com/plgd-dev/sdk/local/core/otm/just-works"
 	"github.com/plgd-dev/sdk/local/core/otm/manufacturer"
+	randompin "github.com/plgd-dev/sdk/local/core/otm/random-pin"
 
 	"github.com/google/uuid"
 	"github.com/karrick/tparse/v2"
@@ -32,15 +34,25 @@ type DeviceOwnershipSDKConfig struct {
 type deviceOwnershipSDK struct {
 	sdkDeviceID          string
 	createIdentitySigner func() (core.CertificateSigner, error)
-	identityCertificate  tls.Certificate
-	identityCACert       []*x509.Certificate
 	dialTLS              core.DialTLS
 	dialDTLS             core.DialDTLS
 	app                  ApplicationCallback
+	errors               func(error)
+
+	lock                           sync.Mutex
+	identityCertificate            tls.Certificate
+	identityCACert                 []*x509.Certificate
+	onIdentityCertificateRotated   func(tls.Certificate)
+	stopIdentityCertificateRenewal func()
 }
 
+// identityCertificateRenewalRetryInterval is used when the renewal of the SDK identity certificate fails.
+const identityCertificateRenewalRetryInterval = time.Minute
+
+// NewDeviceOwnershipSDKFromConfig creates the device owner which signs certificates by the configured CA.
 func NewDeviceOwnershipSDKFromConfig(app ApplicationCallback, dialTLS core.DialTLS,
-	dialDLTS core.DialDTLS, cfg *DeviceOwnershipSDKConfig, createSigner func(caCert []*x509.Certificate, caKey crypto.PrivateKey, validNotBefore time.Time, validNotAfter time.Time) core.CertificateSigner) (*deviceOwnershipSDK, error) {
+	dialDLTS core.DialDTLS, cfg *DeviceOwnershipSDKConfig, createSigner func(caCert []*x509.Certificate, caKey crypto.PrivateKey, validNotBefore time.Time, validNotAfter time.Time) core.CertificateSigner,
+	opts ...DeviceOwnershipSDKOption) (*deviceOwnershipSDK, error) {
 	certExpiry := time.Hour * 24 * 365 * 10
 	var err error
 	if cfg.CertExpiry != nil {
@@ -58,11 +70,20 @@ func NewDeviceOwnershipSDKFromConfig(app ApplicationCallback, dialTLS core.DialT
 		return nil, fmt.Errorf("invalid ID for device ownership SDK: %w", err)
 	}
 
-	return NewDeviceOwnershipSDK(app, uid.String(), dialTLS, dialDLTS, &signerCert, cfg.ValidFrom, certExpiry, createSigner)
+	return NewDeviceOwnershipSDK(app, uid.String(), dialTLS, dialDLTS, &signerCert, cfg.ValidFrom, certExpiry, createSigner, opts...)
 }
 
+// NewDeviceOwnershipSDK creates the device owner which signs certificates by the signerCert,
+// WithError reports failed renewals of the SDK identity certificate.
 func NewDeviceOwnershipSDK(app ApplicationCallback, sdkDeviceID string, dialTLS core.DialTLS,
-	dialDTLS core.DialDTLS, signerCert *tls.Certificate, validFrom string, certExpiry time.Duration, createSigner func(caCert []*x509.Certificate, caKey crypto.PrivateKey, validNotBefore time.Time, validNotAfter time.Time) core.CertificateSigner) (*deviceOwnershipSDK, error) {
+	dialDTLS core.DialDTLS, signerCert *tls.Certificate, validFrom string, certExpiry time.Duration, createSigner func(caCert []*x509.Certificate, caKey crypto.PrivateKey, validNotBefore time.Time, validNotAfter time.Time) core.CertificateSigner,
+	opts ...DeviceOwnershipSDKOption) (*deviceOwnershipSDK, error) {
+	cfg := deviceOwnershipSDKOptions{
+		err: func(error) {},
+	}
+	for _, o := range opts {
+		cfg = o.applyOnDeviceOwnershipSDK(cfg)
+	}
 	if validFrom == "" {
 		validFrom = "now-1m"
 	}
@@ -88,10 +109,18 @@ func NewDeviceOwnershipSDK(app ApplicationCallback, sdkDeviceID string, dialTLS
 		app:      app,
 		dialTLS:  dialTLS,
 		dialDTLS: dialDTLS,
+		errors:   cfg.err,
 	}, nil
 }
 
 func (o *deviceOwnershipSDK) Close(ctx context.Context) error {
+	o.lock.Lock()
+	stop := o.stopIdentityCertificateRenewal
+	o.stopIdentityCertificateRenewal = nil
+	o.lock.Unlock()
+	if stop != nil {
+		stop()
+	}
 	return nil
 }
 
@@ -109,6 +138,14 @@ func getOTMManufacturer(app ApplicationCallback, signer core.CertificateSigner,
 	return manufacturer.NewClient(mfgCert, mfgCA, signer, manufacturer.WithDialDTLS(dialDTLS), manufacturer.WithDialTLS(dialTLS)), nil
 }
 
+func getOTMRandomPin(app ApplicationCallback, signer core.CertificateSigner, dialDTLS core.DialDTLS, deviceID string) (core.OTMClient, error) {
+	pinApp, ok := app.(RandomPINApplicationCallback)
+	if !ok {
+		return nil, fmt.Errorf("application callback doesn't support random PIN")
+	}
+	return randompin.NewClient(signer, deviceID, pinApp.GetRandomPIN, randompin.WithDialDTLS(dialDTLS)), nil
+}
+
 func (o *deviceOwnershipSDK) OwnDevice(ctx context.Context, deviceID string, otmType OTMType, own ownFunc, opts ...core.OwnOption) (string, error) {
 	signer, err := o.createIdentitySigner()
 	if err != nil {
@@ -124,24 +161,115 @@ func (o *deviceOwnershipSDK) OwnDevice(ctx context.Context, deviceID string, otm
 		otmClient = otm
 	case OTMType_JustWorks:
 		otmClient = justworks.NewClient(signer, justworks.WithDialDTLS(o.dialDTLS))
+	case OTMType_RandomPin:
+		otm, err := getOTMRandomPin(o.app, signer, o.dialDTLS, deviceID)
+		if err != nil {
+			return "", err
+		}
+		otmClient = otm
 	default:
 		return "", fmt.Errorf("unsupported ownership transfer method: %v", otmType)
 	}
 	return own(ctx, deviceID, otmClient, opts...)
 }
 
-func (o *deviceOwnershipSDK) Initialization(ctx context.Context) error {
+func (o *deviceOwnershipSDK) generateIdentityCertificate(ctx context.Context) error {
 	signer, err := o.createIdentitySigner()
 	if err != nil {
 		return err
 	}
 	cert, caCert, err := GenerateSDKIdentityCertificate(ctx, signer, o.sdkDeviceID)
+	if err != nil {
+		return err
+	}
+	o.lock.Lock()
 	o.identityCertificate = cert
 	o.identityCACert = caCert
-	return err
+	o.lock.Unlock()
+	return nil
+}
+
+func (o *deviceOwnershipSDK) Initialization(ctx context.Context) error {
+	err := o.generateIdentityCertificate(ctx)
+	if err != nil {
+		return err
+	}
+	o.startIdentityCertificateRenewal()
+	return nil
+}
+
+// getIdentityCertificateRenewalTime returns the time when 2/3 of the remaining validity of the certificate elapses.
+func getIdentityCertificateRenewalTime(cert tls.Certificate) time.Time {
+	now := time.Now()
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return now.Add(identityCertificateRenewalRetryInterval)
+		}
+	}
+	remaining := leaf.NotAfter.Sub(now)
+	if remaining <= 0 {
+		return now.Add(identityCertificateRenewalRetryInterval)
+	}
+	return now.Add(remaining / 3 * 2)
+}
+
+// startIdentityCertificateRenewal reissues the identity certificate before it expires.
+func (o *deviceOwnershipSDK) startIdentityCertificateRenewal() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.stopIdentityCertificateRenewal != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	o.stopIdentityCertificateRenewal = func() {
+		cancel()
+		wg.Wait()
+	}
+	renewAt := getIdentityCertificateRenewalTime(o.identityCertificate)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			t := time.NewTimer(time.Until(renewAt))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+			err := o.generateIdentityCertificate(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					o.errors(fmt.Errorf("cannot renew SDK identity certificate: %w", err))
+				}
+				renewAt = time.Now().Add(identityCertificateRenewalRetryInterval)
+				continue
+			}
+			o.lock.Lock()
+			cert := o.identityCertificate
+			onRotated := o.onIdentityCertificateRotated
+			o.lock.Unlock()
+			renewAt = getIdentityCertificateRenewalTime(cert)
+			if onRotated != nil {
+				onRotated(cert)
+			}
+		}
+	}()
+}
+
+func (o *deviceOwnershipSDK) setOnIdentityCertificateRotated(onRotated func(tls.Certificate)) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.onIdentityCertificateRotated = onRotated
 }
 
 func (o *deviceOwnershipSDK) GetIdentityCertificate() (tls.Certificate, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
 	if o.identityCertificate.PrivateKey == nil {
 		return tls.Certificate{}, fmt.Errorf("client is not initialized")
 	}
@@ -149,12 +277,18 @@ func (o *deviceOwnershipSDK) GetIdentityCertificate() (tls.Certificate, error) {
 }
 
 func (o *deviceOwnershipSDK) GetIdentityCACerts() ([]*x509.Certificate, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
 	if o.identityCACert == nil {
 		return nil, fmt.Errorf("client is not initialized")
 	}
 	return o.identityCACert, nil
 }
 
+func (o *deviceOwnershipSDK) GetIdentityCertificateSigner(ctx context.Context) (core.CertificateSigner, error) {
+	return o.createIdentitySigner()
+}
+
 func (o *deviceOwnershipSDK) GetAccessTokenURL(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("not supported")
 }
@@ -0,0 +1,242 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// DeviceRecord is the state of the device stored by DeviceStore.
+type DeviceRecord struct {
+	ID              string               `json:"id"`
+	DeviceTypes     []string             `json:"deviceTypes,omitempty"`
+	Endpoints       schema.Endpoints     `json:"endpoints"`
+	Resources       schema.ResourceLinks `json:"resources,omitempty"`
+	Ownership       *schema.Doxm         `json:"ownership,omitempty"`
+	OwnershipStatus OwnershipStatus      `json:"ownershipStatus,omitempty"`
+	LastSeen        time.Time            `json:"lastSeen"`
+}
+
+// DeviceStore persists known devices, so they are reachable without the multicast discovery.
+type DeviceStore interface {
+	// Load returns the record of the device, ok is false when the device is unknown.
+	Load(ctx context.Context, deviceID string) (record DeviceRecord, ok bool, err error)
+	// Store inserts or replaces the record of the device.
+	Store(ctx context.Context, record DeviceRecord) error
+	// Delete removes the record of the device.
+	Delete(ctx context.Context, deviceID string) error
+	// List returns records of all devices ordered by ID.
+	List(ctx context.Context) ([]DeviceRecord, error)
+}
+
+// MemoryDeviceStore keeps records in the memory.
+type MemoryDeviceStore struct {
+	lock    sync.Mutex
+	records map[string]DeviceRecord
+}
+
+func NewMemoryDeviceStore() *MemoryDeviceStore {
+	return &MemoryDeviceStore{
+		records: make(map[string]DeviceRecord),
+	}
+}
+
+func (s *MemoryDeviceStore) Load(ctx context.Context, deviceID string) (DeviceRecord, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.records[deviceID]
+	return r, ok, nil
+}
+
+func (s *MemoryDeviceStore) Store(ctx context.Context, record DeviceRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records[record.ID] = record
+	return nil
+}
+
+func (s *MemoryDeviceStore) Delete(ctx context.Context, deviceID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.records, deviceID)
+	return nil
+}
+
+func (s *MemoryDeviceStore) List(ctx context.Context) ([]DeviceRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return sortDeviceRecords(s.records), nil
+}
+
+func sortDeviceRecords(records map[string]DeviceRecord) []DeviceRecord {
+	res := make([]DeviceRecord, 0, len(records))
+	for _, r := range records {
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// deviceStoreLookupTimeout limits the connection to endpoints of the stored device, so the multicast discovery has time when the device moved.
+const deviceStoreLookupTimeout = time.Second * 3
+
+// SetDeviceStore sets the store of known devices, it must be called before the client is used.
+func (c *Client) SetDeviceStore(store DeviceStore) {
+	c.deviceStore = store
+}
+
+func (c *Client) reportError(err error) {
+	if c.errors != nil {
+		c.errors(err)
+	}
+}
+
+func (c *Client) updateDeviceRecord(ctx context.Context, deviceID string, update func(r *DeviceRecord)) {
+	if c.deviceStore == nil {
+		return
+	}
+	r, ok, err := c.deviceStore.Load(ctx, deviceID)
+	if err != nil {
+		c.reportError(fmt.Errorf("cannot load device %v from store: %w", deviceID, err))
+		return
+	}
+	if !ok {
+		r = DeviceRecord{
+			ID:              deviceID,
+			OwnershipStatus: OwnershipStatus_Unknown,
+		}
+	}
+	update(&r)
+	err = c.deviceStore.Store(ctx, r)
+	if err != nil {
+		c.reportError(fmt.Errorf("cannot store device %v: %w", deviceID, err))
+	}
+}
+
+func (c *Client) storeRefDevice(ctx context.Context, refDev *RefDevice, links schema.ResourceLinks) {
+	c.updateDeviceRecord(ctx, refDev.DeviceID(), func(r *DeviceRecord) {
+		r.DeviceTypes = refDev.Device().DeviceTypes()
+		r.Endpoints = refDev.GetEndpoints()
+		r.Resources = links
+		r.LastSeen = time.Now()
+	})
+}
+
+func (c *Client) storeDeviceDetails(ctx context.Context, d DeviceDetails) {
+	c.updateDeviceRecord(ctx, d.ID, func(r *DeviceRecord) {
+		if link, ok := schema.ResourceLinks(d.Resources).GetResourceLink("/oic/d"); ok {
+			r.DeviceTypes = link.ResourceTypes
+		}
+		if len(d.Endpoints) > 0 {
+			r.Endpoints = d.Endpoints
+		}
+		r.Resources = d.Resources
+		r.Ownership = d.Ownership
+		r.OwnershipStatus = d.OwnershipStatus
+		r.LastSeen = time.Now()
+	})
+}
+
+func (c *Client) storeOwnershipStatus(ctx context.Context, deviceID string, status OwnershipStatus, ownerID string) {
+	c.updateDeviceRecord(ctx, deviceID, func(r *DeviceRecord) {
+		r.OwnershipStatus = status
+		if r.Ownership != nil {
+			r.Ownership.DeviceID = deviceID
+			r.Ownership.Owned = status == OwnershipStatus_Owned
+			r.Ownership.OwnerID = ownerID
+		}
+	})
+}
+
+func (c *Client) deleteDeviceRecord(ctx context.Context, deviceID string) {
+	if c.deviceStore == nil {
+		return
+	}
+	err := c.deviceStore.Delete(ctx, deviceID)
+	if err != nil {
+		c.reportError(fmt.Errorf("cannot delete device %v from store: %w", deviceID, err))
+	}
+}
+
+// moveDeviceRecord stores the record of the device under the new ID, it is used when the device ID was changed by own.
+func (c *Client) moveDeviceRecord(ctx context.Context, oldDeviceID, newDeviceID string) {
+	if c.deviceStore == nil {
+		return
+	}
+	r, ok, err := c.deviceStore.Load(ctx, oldDeviceID)
+	if err != nil {
+		c.reportError(fmt.Errorf("cannot load device %v from store: %w", oldDeviceID, err))
+		return
+	}
+	if !ok {
+		return
+	}
+	r.ID = newDeviceID
+	if r.Ownership != nil {
+		ownership := *r.Ownership
+		ownership.DeviceID = newDeviceID
+		r.Ownership = &ownership
+	}
+	err = c.deviceStore.Store(ctx, r)
+	if err != nil {
+		c.reportError(fmt.Errorf("cannot store device %v: %w", newDeviceID, err))
+		return
+	}
+	c.deleteDeviceRecord(ctx, oldDeviceID)
+}
+
+// resetDeviceRecord forgets the resources and the ownership of the factory reset device, the endpoints are kept
+// so the device stays reachable.
+func (c *Client) resetDeviceRecord(ctx context.Context, deviceID string) {
+	c.updateDeviceRecord(ctx, deviceID, func(r *DeviceRecord) {
+		r.Resources = nil
+		r.Ownership = nil
+		r.OwnershipStatus = OwnershipStatus_Unknown
+	})
+}
+
+// getRefDeviceFromStore connects to the device via stored endpoints.
+func (c *Client) getRefDeviceFromStore(ctx context.Context, deviceID string) (*RefDevice, schema.ResourceLinks, bool) {
+	if c.deviceStore == nil {
+		return nil, nil, false
+	}
+	r, ok, err := c.deviceStore.Load(ctx, deviceID)
+	if err != nil {
+		c.reportError(fmt.Errorf("cannot load device %v from store: %w", deviceID, err))
+		return nil, nil, false
+	}
+	if !ok || len(r.Endpoints) == 0 {
+		return nil, nil, false
+	}
+	newRefDev := NewRefDevice(c.client.NewDevice(r.ID, r.DeviceTypes, r.Endpoints))
+	refDev, stored, err := c.deviceCache.TryStoreDeviceToTemporaryCache(newRefDev)
+	if err != nil {
+		newRefDev.Release(ctx)
+		return nil, nil, false
+	}
+	if !stored {
+		newRefDev.Release(ctx)
+	}
+
+	timeout := deviceStoreLookupTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline)/2 < timeout {
+		timeout = time.Until(deadline) / 2
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	links, err := getLinksRefDevice(lookupCtx, refDev, c.disableUDPEndpoints)
+	if err != nil {
+		refDev.Device().Close(ctx)
+		c.deviceCache.RemoveDevice(ctx, refDev.DeviceID(), refDev)
+		refDev.Release(ctx)
+		return nil, nil, false
+	}
+	c.storeRefDevice(ctx, refDev, links)
+	return refDev, links, true
+}
@@ -0,0 +1,143 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// fileDeviceStoreLastSeenResolution limits writes caused only by LastSeen, which changes with every discovery of the device.
+const fileDeviceStoreLastSeenResolution = time.Minute
+
+// FileDeviceStore keeps records in the JSON file. A change rewrites the whole file, so records which
+// differ only by LastSeen are written at most once per fileDeviceStoreLastSeenResolution.
+type FileDeviceStore struct {
+	path string
+
+	lock          sync.Mutex
+	records       map[string]DeviceRecord
+	savedLastSeen map[string]time.Time
+}
+
+// NewFileDeviceStore loads records from the file, the file is created by the first change.
+func NewFileDeviceStore(path string) (*FileDeviceStore, error) {
+	records := make(map[string]DeviceRecord)
+	savedLastSeen := make(map[string]time.Time)
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("cannot read device store %v: %w", path, err)
+	default:
+		var list []DeviceRecord
+		err = json.Unmarshal(data, &list)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode device store %v: %w", path, err)
+		}
+		for _, r := range list {
+			records[r.ID] = r
+			savedLastSeen[r.ID] = r.LastSeen
+		}
+	}
+	return &FileDeviceStore{
+		path:          path,
+		records:       records,
+		savedLastSeen: savedLastSeen,
+	}, nil
+}
+
+// save writes records to the temporary file and renames it, so the file is never partially written.
+func (s *FileDeviceStore) save() error {
+	data, err := json.MarshalIndent(sortDeviceRecords(s.records), "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode device store: %w", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot write device store %v: %w", s.path, err)
+	}
+	_, err = tmp.Write(data)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot write device store %v: %w", s.path, err)
+	}
+	s.savedLastSeen = make(map[string]time.Time, len(s.records))
+	for id, r := range s.records {
+		s.savedLastSeen[id] = r.LastSeen
+	}
+	return nil
+}
+
+// needsSave reports whether the record differs from the written one.
+func (s *FileDeviceStore) needsSave(record DeviceRecord) bool {
+	old, ok := s.records[record.ID]
+	if !ok {
+		return true
+	}
+	if record.LastSeen.Sub(s.savedLastSeen[record.ID]) >= fileDeviceStoreLastSeenResolution {
+		return true
+	}
+	old.LastSeen = time.Time{}
+	record.LastSeen = time.Time{}
+	return !reflect.DeepEqual(old, record)
+}
+
+func (s *FileDeviceStore) Load(ctx context.Context, deviceID string) (DeviceRecord, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.records[deviceID]
+	return r, ok, nil
+}
+
+func (s *FileDeviceStore) Store(ctx context.Context, record DeviceRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	old, ok := s.records[record.ID]
+	if !s.needsSave(record) {
+		s.records[record.ID] = record
+		return nil
+	}
+	s.records[record.ID] = record
+	err := s.save()
+	if err != nil {
+		if ok {
+			s.records[record.ID] = old
+		} else {
+			delete(s.records, record.ID)
+		}
+	}
+	return err
+}
+
+func (s *FileDeviceStore) Delete(ctx context.Context, deviceID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	old, ok := s.records[deviceID]
+	if !ok {
+		return nil
+	}
+	delete(s.records, deviceID)
+	err := s.save()
+	if err != nil {
+		s.records[deviceID] = old
+	}
+	return err
+}
+
+func (s *FileDeviceStore) List(ctx context.Context) ([]DeviceRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return sortDeviceRecords(s.records), nil
+}
@@ -0,0 +1,158 @@
+package local_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDeviceStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deviceStore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "devices.json")
+	ctx := context.Background()
+
+	s, err := local.NewFileDeviceStore(path)
+	require.NoError(t, err)
+	list, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	records := []local.DeviceRecord{
+		{
+			ID:        "00000000-0000-0000-0000-000000000002",
+			Endpoints: schema.Endpoints{{URI: "coap://192.168.1.2:5683"}},
+		},
+		{
+			ID:              "00000000-0000-0000-0000-000000000001",
+			DeviceTypes:     []string{"oic.wk.d"},
+			Endpoints:       schema.Endpoints{{URI: "coaps://192.168.1.1:5684"}},
+			OwnershipStatus: local.OwnershipStatus_Owned,
+			LastSeen:        time.Now().UTC().Truncate(time.Second),
+		},
+	}
+	for _, r := range records {
+		err = s.Store(ctx, r)
+		require.NoError(t, err)
+	}
+
+	s, err = local.NewFileDeviceStore(path)
+	require.NoError(t, err)
+	list, err = s.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []local.DeviceRecord{records[1], records[0]}, list)
+
+	err = s.Delete(ctx, records[0].ID)
+	require.NoError(t, err)
+	s, err = local.NewFileDeviceStore(path)
+	require.NoError(t, err)
+	_, ok, err := s.Load(ctx, records[0].ID)
+	require.NoError(t, err)
+	require.False(t, ok)
+	got, ok, err := s.Load(ctx, records[1].ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, records[1], got)
+
+	// unchanged records and recent LastSeen aren't written
+	err = os.Remove(path)
+	require.NoError(t, err)
+	err = s.Store(ctx, records[1])
+	require.NoError(t, err)
+	seen := records[1]
+	seen.LastSeen = seen.LastSeen.Add(time.Second)
+	err = s.Store(ctx, seen)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	got, _, err = s.Load(ctx, records[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, seen, got)
+
+	seen.LastSeen = seen.LastSeen.Add(time.Hour)
+	err = s.Store(ctx, seen)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	err = os.Remove(path)
+	require.NoError(t, err)
+	changed := seen
+	changed.Endpoints = schema.Endpoints{{URI: "coaps://192.168.1.3:5684"}}
+	err = s.Store(ctx, changed)
+	require.NoError(t, err)
+	s, err = local.NewFileDeviceStore(path)
+	require.NoError(t, err)
+	got, ok, err = s.Load(ctx, records[1].ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, changed, got)
+}
+
+func TestClient_GetDeviceFromStore(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
+	store := local.NewMemoryDeviceStore()
+
+	ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+
+	c := NewTestClient()
+	c.SetDeviceStore(store)
+	_, err := c.GetDeviceByMulticast(ctx, deviceID, local.WithDiscoveryConfigration(NewTestDiscoveryConfiguration(dev)))
+	require.NoError(t, err)
+	err = c.Close(ctx)
+	require.NoError(t, err)
+
+	r, ok, err := store.Load(ctx, deviceID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotEmpty(t, r.Endpoints)
+
+	// new client without multicast reaches the device via stored endpoints
+	c = NewTestClient()
+	defer c.Close(context.Background())
+	c.SetDeviceStore(store)
+	_, err = c.GetDeviceByMulticast(ctx, deviceID, local.WithDiscoveryConfigration(core.DiscoveryConfiguration{}))
+	require.NoError(t, err)
+}
+
+func TestClient_DisownInsecureDeviceResetsRecord(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
+	c := NewTestClient()
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	store := StoreTestDevices(t, c, dev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+	_, err := c.GetDeviceByMulticast(ctx, deviceID, local.WithDiscoveryConfigration(core.DiscoveryConfiguration{}))
+	require.NoError(t, err)
+	r, ok, err := store.Load(ctx, deviceID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotEmpty(t, r.Resources)
+
+	// the insecure device is factory reset
+	err = c.DisownDevice(ctx, deviceID)
+	require.NoError(t, err)
+	r, ok, err = store.Load(ctx, deviceID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, r.Resources)
+	require.Nil(t, r.Ownership)
+	require.Equal(t, dev.Endpoints(), r.Endpoints)
+}
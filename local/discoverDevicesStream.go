@@ -0,0 +1,217 @@
+package local
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+type DeviceEvent_type uint8
+
+// DeviceEvent_DISCOVERED the device answered for the first time.
+const DeviceEvent_DISCOVERED DeviceEvent_type = 0
+
+// DeviceEvent_UPDATED the device was discovered before and its set of endpoints or its ownership was changed.
+const DeviceEvent_UPDATED DeviceEvent_type = 1
+
+type DeviceEvent struct {
+	Event  DeviceEvent_type
+	Device DeviceDetails
+}
+
+type devicesStream struct {
+	ctx     context.Context
+	ownerID string
+	events  chan<- DeviceEvent
+	store   func(ctx context.Context, d DeviceDetails)
+	// queued signals the sender that events were queued.
+	queued chan struct{}
+
+	lock       sync.Mutex
+	devices    map[string]DeviceDetails
+	ownerships map[string]schema.Doxm
+	queue      []DeviceEvent
+}
+
+// push queues the event under the lock, so events of the device are sent in the order of changes.
+func (s *devicesStream) push(event DeviceEvent_type, d DeviceDetails) {
+	s.queue = append(s.queue, DeviceEvent{Event: event, Device: d})
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+func (s *devicesStream) pop() []DeviceEvent {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	queue := s.queue
+	s.queue = nil
+	return queue
+}
+
+// flush sends queued events, it returns false when the ctx is done.
+func (s *devicesStream) flush() bool {
+	for _, e := range s.pop() {
+		s.store(s.ctx, e.Device)
+		select {
+		case s.events <- e:
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// run sends queued events without the lock until the ctx is done or the discovery finished, then it closes the channel.
+func (s *devicesStream) run(finished <-chan struct{}) {
+	defer close(s.events)
+	for s.flush() {
+		select {
+		case <-s.queued:
+		case <-finished:
+			s.flush()
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *devicesStream) onDevice(d DeviceDetails) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	old, ok := s.devices[d.ID]
+	devs := map[string]DeviceDetails{d.ID: d}
+	if ok {
+		devs = mergeDevices([]DeviceDetails{old, d})
+		if d.Ownership != nil {
+			merged := devs[d.ID]
+			merged.Ownership = d.Ownership
+			merged.OwnershipStatus = d.OwnershipStatus
+			devs[d.ID] = merged
+		}
+	}
+	devs = setOwnership(s.ownerID, devs, s.ownerships)
+	d = devs[d.ID]
+	s.devices[d.ID] = d
+	if !ok {
+		s.push(DeviceEvent_DISCOVERED, d)
+		return
+	}
+	if !equalEndpoints(d.Endpoints, old.Endpoints) || !equalOwnership(d, old) {
+		s.push(DeviceEvent_UPDATED, d)
+	}
+}
+
+func (s *devicesStream) onOwnership(doxm schema.Doxm) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ownerships[doxm.DeviceID] = doxm
+	old, ok := s.devices[doxm.DeviceID]
+	if !ok {
+		return
+	}
+	d := old
+	d.Ownership = nil
+	d = setOwnership(s.ownerID, map[string]DeviceDetails{d.ID: d}, map[string]schema.Doxm{doxm.DeviceID: doxm})[d.ID]
+	if equalOwnership(d, old) {
+		return
+	}
+	s.devices[d.ID] = d
+	s.push(DeviceEvent_UPDATED, d)
+}
+
+// equalEndpoints compares endpoints as sets.
+func equalEndpoints(a, b schema.Endpoints) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[schema.Endpoint]int, len(a))
+	for _, e := range a {
+		set[e]++
+	}
+	for _, e := range b {
+		if set[e] == 0 {
+			return false
+		}
+		set[e]--
+	}
+	return true
+}
+
+func equalOwnership(a, b DeviceDetails) bool {
+	if a.OwnershipStatus != b.OwnershipStatus {
+		return false
+	}
+	if a.Ownership == nil || b.Ownership == nil {
+		return a.Ownership == b.Ownership
+	}
+	return reflect.DeepEqual(*a.Ownership, *b.Ownership)
+}
+
+// DiscoverDevicesStream discovers devices in the local mode like GetDevices, but it sends the device to the returned
+// channel as soon as it answers. When the ownership of the device arrives later, the device is sent again
+// with the DeviceEvent_UPDATED event. The channel is closed when the ctx is done.
+func (c *Client) DiscoverDevicesStream(
+	ctx context.Context,
+	opts ...GetDevicesOption,
+) (<-chan DeviceEvent, error) {
+	cfg := getDevicesOptions{
+		err:                    c.errors,
+		getDetails:             getDetails,
+		discoveryConfiguration: core.DefaultDiscoveryConfiguration(),
+	}
+	for _, o := range opts {
+		cfg = o.applyOnGetDevices(cfg)
+	}
+	if cfg.err == nil {
+		cfg.err = func(error) {}
+	}
+	getDetails := func(ctx context.Context, d *core.Device, links schema.ResourceLinks) (interface{}, error) {
+		return cfg.getDetails(ctx, d, patchResourceLinksEndpoints(links, c.disableUDPEndpoints))
+	}
+
+	ownerID, _ := c.client.GetSdkOwnerID()
+	events := make(chan DeviceEvent)
+	s := &devicesStream{
+		ctx:        ctx,
+		ownerID:    ownerID,
+		events:     events,
+		store:      c.storeDeviceDetails,
+		queued:     make(chan struct{}, 1),
+		devices:    make(map[string]DeviceDetails),
+		ownerships: make(map[string]schema.Doxm),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		c.getResourceDirectoriesDevices(ctx, cfg.resourceDirectories, cfg.resourceTypes, cfg.err, s.onDevice)
+	}()
+	go func() {
+		defer wg.Done()
+		ownershipsHandler := newDiscoveryOwnershipsHandler(ctx, cfg.err, s.onOwnership)
+		if err := c.client.GetOwnerships(ctx, cfg.discoveryConfiguration, core.DiscoverAllDevices, ownershipsHandler); err != nil {
+			cfg.err(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		handler := newDiscoveryHandler(ctx, cfg.resourceTypes, cfg.err, s.onDevice, getDetails, c.deviceCache, c.disableUDPEndpoints)
+		if err := c.client.GetDevicesV2(ctx, cfg.discoveryConfiguration, handler); err != nil {
+			cfg.err(err)
+		}
+	}()
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	go s.run(finished)
+	return events, nil
+}
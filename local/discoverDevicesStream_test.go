@@ -0,0 +1,66 @@
+package local_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sameEndpoints(a, b schema.Endpoints) bool {
+	sorted := func(eps schema.Endpoints) schema.Endpoints {
+		eps = append(schema.Endpoints(nil), eps...)
+		sort.Slice(eps, func(i, j int) bool { return eps[i].URI < eps[j].URI })
+		return eps
+	}
+	return assert.ObjectsAreEqual(sorted(a), sorted(b))
+}
+
+func TestClient_DiscoverDevicesStream(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure())
+	secureDev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	events, err := c.DiscoverDevicesStream(ctx, local.WithDiscoveryConfigration(NewTestDiscoveryConfiguration(dev, secureDev)))
+	require.NoError(t, err)
+
+	devices := make(map[string]local.DeviceDetails)
+	for e := range events {
+		old, discovered := devices[e.Device.ID]
+		if e.Event == local.DeviceEvent_DISCOVERED {
+			require.False(t, discovered)
+		} else {
+			require.True(t, discovered)
+			// the update carries a change of endpoints or ownership
+			changed := !sameEndpoints(old.Endpoints, e.Device.Endpoints) ||
+				old.OwnershipStatus != e.Device.OwnershipStatus ||
+				!assert.ObjectsAreEqual(old.Ownership, e.Device.Ownership)
+			require.True(t, changed, "%+v", e.Device)
+		}
+		devices[e.Device.ID] = e.Device
+	}
+	require.Error(t, ctx.Err())
+
+	d, ok := devices[dev.ID()]
+	require.True(t, ok)
+	require.Equal(t, TestDeviceName, d.Details.(*schema.Device).Name)
+
+	d, ok = devices[secureDev.ID()]
+	require.True(t, ok)
+	require.Equal(t, TestSecureDeviceName, d.Details.(*schema.Device).Name)
+	require.NotNil(t, d.Ownership)
+	require.Equal(t, local.OwnershipStatus_ReadyToBeOwned, d.OwnershipStatus)
+}
@@ -17,8 +17,18 @@ func (c *Client) DisownDevice(ctx context.Context, deviceID string) error {
 
 	ok := d.IsSecured()
 	if !ok {
-		return d.FactoryReset(ctx, links)
+		err = d.FactoryReset(ctx, links)
+		if err != nil {
+			return err
+		}
+		c.resetDeviceRecord(ctx, d.DeviceID())
+		return nil
 	}
 
-	return d.Disown(ctx, links)
+	err = d.Disown(ctx, links)
+	if err != nil {
+		return err
+	}
+	c.storeOwnershipStatus(ctx, d.DeviceID(), OwnershipStatus_ReadyToBeOwned, "")
+	return nil
 }
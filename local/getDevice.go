@@ -59,6 +59,7 @@ func (c *Client) GetRefDeviceByIP(
 		refDev.Release(ctx)
 		return nil, nil, fmt.Errorf("cannot get links for device %v: %w", refDev.DeviceID(), err)
 	}
+	c.storeRefDevice(ctx, refDev, links)
 	return refDev, patchResourceLinksEndpoints(links, c.disableUDPEndpoints), nil
 }
 
@@ -78,6 +79,10 @@ func (c *Client) GetRefDevice(
 	if ok {
 		return refDev, links, nil
 	}
+	refDev, links, ok = c.getRefDeviceFromStore(ctx, deviceID)
+	if ok {
+		return refDev, links, nil
+	}
 	dev, err := c.client.GetDeviceByMulticast(ctx, deviceID, cfg.discoveryConfiguration)
 	if err != nil {
 		return nil, nil, err
@@ -98,6 +103,7 @@ func (c *Client) GetRefDevice(
 		refDev.Release(ctx)
 		return nil, nil, fmt.Errorf("cannot get links for device %v: %w", deviceID, err)
 	}
+	c.storeRefDevice(ctx, refDev, links)
 	return refDev, patchResourceLinksEndpoints(links, c.disableUDPEndpoints), nil
 }
 
@@ -139,11 +145,13 @@ func (c *Client) GetDeviceByMulticast(ctx context.Context, deviceID string, opts
 	}
 	ownerID, _ := c.client.GetSdkOwnerID()
 
-	return setOwnership(ownerID, map[string]DeviceDetails{
+	devDetails = setOwnership(ownerID, map[string]DeviceDetails{
 		devDetails.ID: devDetails,
 	}, map[string]schema.Doxm{
 		doxm.DeviceID: doxm,
-	})[devDetails.ID], nil
+	})[devDetails.ID]
+	c.storeDeviceDetails(ctx, devDetails)
+	return devDetails, nil
 }
 
 // GetDeviceByIP gets the device directly via IP address and multicast listen port 5683.
@@ -185,9 +193,11 @@ func (c *Client) GetDeviceByIP(ctx context.Context, ip string, opts ...GetDevice
 	}
 	ownerID, _ := c.client.GetSdkOwnerID()
 
-	return setOwnership(ownerID, map[string]DeviceDetails{
+	devDetails = setOwnership(ownerID, map[string]DeviceDetails{
 		devDetails.ID: devDetails,
 	}, map[string]schema.Doxm{
 		doxm.DeviceID: doxm,
-	})[devDetails.ID], nil
+	})[devDetails.ID]
+	c.storeDeviceDetails(ctx, devDetails)
+	return devDetails, nil
 }
@@ -27,43 +27,23 @@ func sortResources(s []schema.ResourceLink) []schema.ResourceLink {
 	return v
 }
 
-func NewTestDeviceSimulator(deviceID, deviceName string) local.DeviceDetails {
-	return local.DeviceDetails{
-		ID: deviceID,
-		Details: &schema.Device{
-			ID:            deviceID,
-			Name:          deviceName,
-			ResourceTypes: []string{"oic.d.cloudDevice", "oic.wk.d"},
-			Interfaces:    []string{"oic.if.r", "oic.if.baseline"},
-		},
-		Resources:       sortResources(append(test.TestDevsimResources, test.TestDevsimPrivateResources...)),
+// NewTestDeviceDetails returns details of the fake device without endpoints of resources.
+func NewTestDeviceDetails(dev *test.Device) local.DeviceDetails {
+	d := dev.Server().Device()
+	d.ProtocolIndependentID = ""
+	details := local.DeviceDetails{
+		ID:              d.ID,
+		Details:         &d,
+		Resources:       cleanUpResources(sortResources(dev.Server().Links(nil, nil))),
 		OwnershipStatus: local.OwnershipStatus_Unknown,
 	}
-}
-
-func NewTestSecureDeviceSimulator(deviceID, deviceName string) local.DeviceDetails {
-	return local.DeviceDetails{
-		ID: deviceID,
-		Details: &schema.Device{
-			ID:            deviceID,
-			Name:          deviceName,
-			ResourceTypes: []string{"oic.d.cloudDevice", "oic.wk.d"},
-			Interfaces:    []string{"oic.if.r", "oic.if.baseline"},
-		},
-		IsSecured: true,
-		Ownership: &schema.Doxm{
-			ResourceOwner:                 "00000000-0000-0000-0000-000000000000",
-			SupportedOwnerTransferMethods: []schema.OwnerTransferMethod{schema.JustWorks, schema.ManufacturerCertificate},
-			OwnerID:                       "00000000-0000-0000-0000-000000000000",
-			DeviceID:                      deviceID,
-			SupportedCredentialTypes:      schema.CredentialType(schema.CredentialType_SYMMETRIC_PAIR_WISE | schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE),
-			SelectedOwnerTransferMethod:   schema.JustWorks,
-			Interfaces:                    []string{"oic.if.rw", "oic.if.baseline"},
-			ResourceTypes:                 []string{"oic.r.doxm"},
-		},
-		Resources:       sortResources(append(append(test.TestDevsimResources, test.TestDevsimPrivateResources...), test.TestDevsimSecResources...)),
-		OwnershipStatus: local.OwnershipStatus_ReadyToBeOwned,
+	if len(dev.Endpoints()) > 1 {
+		doxm := dev.Doxm()
+		details.IsSecured = true
+		details.Ownership = &doxm
+		details.OwnershipStatus = local.OwnershipStatus_ReadyToBeOwned
 	}
+	return details
 }
 
 func cleanUpResources(s []schema.ResourceLink) []schema.ResourceLink {
@@ -72,14 +52,15 @@ func cleanUpResources(s []schema.ResourceLink) []schema.ResourceLink {
 		l.Endpoints = nil
 		l.Policy = nil
 		l.Anchor = ""
+		l.DeviceID = ""
 		a = append(a, l)
 	}
 	return a
 }
 
 func TestClient_GetDevice(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	secureDev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
 	type args struct {
 		deviceID string
 	}
@@ -92,16 +73,16 @@ func TestClient_GetDevice(t *testing.T) {
 		{
 			name: "valid",
 			args: args{
-				deviceID: deviceID,
+				deviceID: dev.ID(),
 			},
-			want: NewTestDeviceSimulator(deviceID, test.TestDeviceName),
+			want: NewTestDeviceDetails(dev),
 		},
 		{
 			name: "valid - secure",
 			args: args{
-				deviceID: secureDeviceID,
+				deviceID: secureDev.ID(),
 			},
-			want: NewTestSecureDeviceSimulator(secureDeviceID, test.TestSecureDeviceName),
+			want: NewTestDeviceDetails(secureDev),
 		},
 		{
 			name: "not-found",
@@ -118,12 +99,13 @@ func TestClient_GetDevice(t *testing.T) {
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer c.Close(context.Background())
+	discoveryConfiguration := local.WithDiscoveryConfigration(NewTestDiscoveryConfiguration(dev, secureDev))
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cancel := context.WithTimeout(ctx, time.Second)
 			defer cancel()
-			got, err := c.GetDeviceByMulticast(ctx, tt.args.deviceID)
+			got, err := c.GetDeviceByMulticast(ctx, tt.args.deviceID, discoveryConfiguration)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -139,10 +121,8 @@ func TestClient_GetDevice(t *testing.T) {
 }
 
 func TestClient_GetDeviceByIP(t *testing.T) {
-	deviceIDip4 := test.MustFindDeviceByName(test.TestDeviceName)
-	ip4 := test.MustFindDeviceIP(test.TestDeviceName, test.IP4)
-	deviceIDip6 := test.MustFindDeviceByName(test.TestSecureDeviceName)
-	ip6 := test.MustFindDeviceIP(test.TestSecureDeviceName, test.IP6)
+	dev := NewTestDevice(t, test.WithInsecure(), test.WithAddress(TestDeviceAddress))
+	secureDev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName), test.WithAddress(TestDeviceAddressIP6))
 	type args struct {
 		ip string
 	}
@@ -155,16 +135,16 @@ func TestClient_GetDeviceByIP(t *testing.T) {
 		{
 			name: "ip4",
 			args: args{
-				ip: ip4,
+				ip: "127.0.0.2",
 			},
-			want: NewTestDeviceSimulator(deviceIDip4, test.TestDeviceName),
+			want: NewTestDeviceDetails(dev),
 		},
 		{
 			name: "ip6",
 			args: args{
-				ip: ip6,
+				ip: "::1",
 			},
-			want: NewTestSecureDeviceSimulator(deviceIDip6, test.TestSecureDeviceName),
+			want: NewTestDeviceDetails(secureDev),
 		},
 		{
 			name: "not-found",
@@ -66,17 +66,38 @@ func (c *Client) GetDevices(
 	ownershipsHandler := newDiscoveryOwnershipsHandler(ctx, cfg.err, ownerships)
 	go c.client.GetOwnerships(ctx, cfg.discoveryConfiguration, core.DiscoverAllDevices, ownershipsHandler)
 
+	var rdDevices []DeviceDetails
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.getResourceDirectoriesDevices(ctx, cfg.resourceDirectories, cfg.resourceTypes, cfg.err, func(d DeviceDetails) {
+			m.Lock()
+			defer m.Unlock()
+			rdDevices = append(rdDevices, d)
+		})
+	}()
+
 	handler := newDiscoveryHandler(ctx, cfg.resourceTypes, cfg.err, devices, getDetails, c.deviceCache, c.disableUDPEndpoints)
 	if err := c.client.GetDevicesV2(ctx, cfg.discoveryConfiguration, handler); err != nil {
+		cancel()
+		wg.Wait()
 		return nil, err
 	}
+	wg.Wait()
 
 	m.Lock()
 	defer m.Unlock()
+	// devices discovered by the multicast have details, so they are preferred
+	res = append(res, rdDevices...)
 
 	ownerID, _ := c.client.GetSdkOwnerID()
 
-	return setOwnership(ownerID, mergeDevices(res), resOwnerships), nil
+	devs := setOwnership(ownerID, mergeDevices(res), resOwnerships)
+	for _, d := range devs {
+		c.storeDeviceDetails(ctx, d)
+	}
+	return devs, nil
 }
 
 // GetDevicesWithHandler discovers devices using a CoAP multicast request via UDP.
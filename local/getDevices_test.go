@@ -2,7 +2,6 @@ package local_test
 
 import (
 	"context"
-	"fmt"
 	"testing"
 	"time"
 
@@ -14,34 +13,35 @@ import (
 )
 
 func TestDeviceDiscovery(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	secureDev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
-	devices, err := c.GetDevices(ctx)
+	devices, err := c.GetDevices(ctx, local.WithDiscoveryConfigration(NewTestDiscoveryConfiguration(dev, secureDev)))
 	require.NoError(t, err)
 	defer func() {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
 
-	d := devices[deviceID]
+	d := devices[dev.ID()]
 	require.NotEmpty(t, d)
-	assert.Equal(t, test.TestDeviceName, d.Details.(*schema.Device).Name)
+	assert.Equal(t, TestDeviceName, d.Details.(*schema.Device).Name)
 
-	d = devices[secureDeviceID]
-	fmt.Println(d)
+	d = devices[secureDev.ID()]
 	require.NotNil(t, d)
-	assert.Equal(t, test.TestSecureDeviceName, d.Details.(*schema.Device).Name)
+	assert.Equal(t, TestSecureDeviceName, d.Details.(*schema.Device).Name)
 	require.NotNil(t, d.Ownership)
-	assert.Equal(t, d.Ownership.OwnerID, "00000000-0000-0000-0000-000000000000")
+	assert.False(t, d.Ownership.Owned)
+	assert.Equal(t, local.OwnershipStatus_ReadyToBeOwned, d.OwnershipStatus)
 }
 
 func TestDeviceDiscoveryWithFilter(t *testing.T) {
-	secureDeviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	secureDev := NewTestDevice(t)
+	discoveryConfiguration := local.WithDiscoveryConfigration(NewTestDiscoveryConfiguration(secureDev))
 	c := NewTestClient()
 	defer func() {
 		err := c.Close(context.Background())
@@ -50,13 +50,13 @@ func TestDeviceDiscoveryWithFilter(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
-	devices, err := c.GetDevices(ctx, local.WithResourceTypes("oic.wk.d"))
+	devices, err := c.GetDevices(ctx, discoveryConfiguration, local.WithResourceTypes("oic.wk.d"))
 	require.NoError(t, err)
-	assert.NotEmpty(t, devices[secureDeviceID], "unreachable test device")
+	assert.NotEmpty(t, devices[secureDev.ID()], "unreachable test device")
 
 	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	devices, err = c.GetDevices(ctx, local.WithResourceTypes("x.com.device"))
+	devices, err = c.GetDevices(ctx, discoveryConfiguration, local.WithResourceTypes("x.com.device"))
 	require.NoError(t, err)
 	assert.Empty(t, devices, "test device not filtered out")
 }
@@ -0,0 +1,84 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plgd-dev/kit/codec/cbor"
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/local/core"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+)
+
+// BatchInterface is the interface which retrieves/updates all resources of a collection in one request.
+const BatchInterface = "oic.if.b"
+
+// BatchResponse contains representations of the collection resources indexed by href.
+type BatchResponse = map[string]kitNetCoap.DecodeFunc
+
+type batchItem struct {
+	Href           string      `json:"href"`
+	Representation interface{} `json:"rep"`
+}
+
+// batchItemHref converts absolute href (ocf://deviceID/href) to the href of the device.
+func batchItemHref(href string) string {
+	const prefix = "ocf://"
+	if !strings.HasPrefix(href, prefix) {
+		return href
+	}
+	v := strings.TrimPrefix(href, prefix)
+	idx := strings.Index(v, "/")
+	if idx < 0 {
+		return "/"
+	}
+	return v[idx:]
+}
+
+func decodeBatchItems(data []byte) (BatchResponse, error) {
+	if len(data) == 0 {
+		return BatchResponse{}, nil
+	}
+	var items []batchItem
+	if err := cbor.Decode(data, &items); err != nil {
+		return nil, fmt.Errorf("cannot decode batch response: %w", err)
+	}
+	resp := make(BatchResponse, len(items))
+	for _, item := range items {
+		rep := item.Representation
+		resp[batchItemHref(item.Href)] = func(v interface{}) error {
+			data, err := cbor.Encode(rep)
+			if err != nil {
+				return err
+			}
+			return cbor.Decode(data, v)
+		}
+	}
+	return resp, nil
+}
+
+// GetResourceBatch retrieves all resources of the collection in one request via the batch interface.
+func (c *Client) GetResourceBatch(
+	ctx context.Context,
+	deviceID string,
+	href string,
+) (BatchResponse, error) {
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetResourceLink(links, href)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = d.GetResourceWithCodec(ctx, link, codecOcf.RawVNDOCFCBORCodec{}, &data, kitNetCoap.WithInterface(BatchInterface))
+	if err != nil {
+		return nil, err
+	}
+	return decodeBatchItems(data)
+}
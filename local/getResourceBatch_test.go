@@ -0,0 +1,68 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetResourceBatch(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure(), test.WithCollection("/lights", "/light/1", "/light/2"))
+	deviceID := dev.ID()
+	type args struct {
+		deviceID string
+		href     string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantHrefs []string
+		wantErr   bool
+	}{
+		{
+			name: "valid",
+			args: args{
+				deviceID: deviceID,
+				href:     "/lights",
+			},
+			wantHrefs: []string{"/light/1", "/light/2"},
+		},
+		{
+			name: "invalid href",
+			args: args{
+				deviceID: deviceID,
+				href:     "/invalid/href",
+			},
+			wantErr: true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+
+	c := NewTestClient()
+	defer c.Close(context.Background())
+	StoreTestDevices(t, c, dev)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			got, err := c.GetResourceBatch(ctx, tt.args.deviceID, tt.args.href)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, href := range tt.wantHrefs {
+				require.Contains(t, got, href)
+				var rep map[string]interface{}
+				err = got[href](&rep)
+				require.NoError(t, err)
+				require.NotEmpty(t, rep)
+			}
+		})
+	}
+}
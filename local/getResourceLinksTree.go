@@ -0,0 +1,18 @@
+package local
+
+import (
+	"context"
+
+	"github.com/plgd-dev/sdk/local/core"
+)
+
+// GetResourceLinksTree returns resource links of the device as a tree, where links of collections are their children.
+func (c *Client) GetResourceLinksTree(ctx context.Context, deviceID string) ([]*core.ResourceLinkNode, error) {
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Release(ctx)
+
+	return d.GetResourceLinksTree(ctx, links)
+}
@@ -11,7 +11,8 @@ import (
 )
 
 func TestClient_GetResource(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 	type args struct {
 		deviceID string
 		href     string
@@ -30,7 +31,7 @@ func TestClient_GetResource(t *testing.T) {
 				href:     "/oc/con",
 			},
 			want: map[string]interface{}{
-				"n": test.TestDeviceName,
+				"n": TestDeviceName,
 			},
 		},
 		{
@@ -43,7 +44,7 @@ func TestClient_GetResource(t *testing.T) {
 			wantErr: false,
 			want: map[string]interface{}{
 				"if": []interface{}{"oic.if.rw", "oic.if.baseline"},
-				"n":  test.TestDeviceName,
+				"n":  TestDeviceName,
 				"rt": []interface{}{"oic.wk.con"},
 			},
 		},
@@ -62,6 +63,7 @@ func TestClient_GetResource(t *testing.T) {
 
 	c := NewTestClient()
 	defer c.Close(context.Background())
+	StoreTestDevices(t, c, dev)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,54 @@
+package local
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+)
+
+// identityCertificateRotator is implemented by device owners which renew the identity certificate of the client.
+type identityCertificateRotator = interface {
+	setOnIdentityCertificateRotated(onRotated func(tls.Certificate))
+}
+
+// RegisterIdentityCertificateRotationHandler registers the handler which is called when the identity certificate of the client was renewed.
+func (c *Client) RegisterIdentityCertificateRotationHandler(h func(cert tls.Certificate)) int {
+	c.identityCertificateRotationHandlersLock.Lock()
+	defer c.identityCertificateRotationHandlersLock.Unlock()
+	if c.identityCertificateRotationHandlers == nil {
+		c.identityCertificateRotationHandlers = make(map[int]func(tls.Certificate))
+	}
+	c.lastIdentityCertificateRotationHandlerID++
+	c.identityCertificateRotationHandlers[c.lastIdentityCertificateRotationHandlerID] = h
+	return c.lastIdentityCertificateRotationHandlerID
+}
+
+// UnregisterIdentityCertificateRotationHandler removes the handler registered by RegisterIdentityCertificateRotationHandler.
+func (c *Client) UnregisterIdentityCertificateRotationHandler(id int) {
+	c.identityCertificateRotationHandlersLock.Lock()
+	defer c.identityCertificateRotationHandlersLock.Unlock()
+	delete(c.identityCertificateRotationHandlers, id)
+}
+
+func (c *Client) getIdentityCertificateRotationHandlers() []func(tls.Certificate) {
+	c.identityCertificateRotationHandlersLock.Lock()
+	defer c.identityCertificateRotationHandlersLock.Unlock()
+	handlers := make([]func(tls.Certificate), 0, len(c.identityCertificateRotationHandlers))
+	for _, h := range c.identityCertificateRotationHandlers {
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
+
+// onIdentityCertificateRotated closes connections to devices, so they are reestablished with the new certificate.
+func (c *Client) onIdentityCertificateRotated(cert tls.Certificate) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	err := c.deviceCache.CloseConnections(ctx)
+	if err != nil && c.errors != nil {
+		c.errors(err)
+	}
+	for _, h := range c.getIdentityCertificateRotationHandlers() {
+		h(cert)
+	}
+}
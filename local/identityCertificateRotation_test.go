@@ -0,0 +1,125 @@
+package local_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_IdentityCertificateRotation(t *testing.T) {
+	// validFrom is now-1m by default, so the certificate expires in 3 seconds
+	certExpiry := "63s"
+	cfg := local.Config{
+		DeviceOwnershipSDK: &local.DeviceOwnershipSDKConfig{
+			ID:         CertIdentity,
+			Cert:       string(IdentityIntermediateCA),
+			CertKey:    string(IdentityIntermediateCAKey),
+			CertExpiry: &certExpiry,
+		},
+	}
+	c, err := local.NewClientFromConfig(&cfg, &testSetupSecureClient{}, test.NewIdentityCertificateSigner, func(err error) { fmt.Print(err) })
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+
+	rotated := make(chan tls.Certificate, 1)
+	id := c.RegisterIdentityCertificateRotationHandler(func(cert tls.Certificate) {
+		select {
+		case rotated <- cert:
+		default:
+		}
+	})
+	defer c.UnregisterIdentityCertificateRotationHandler(id)
+
+	err = c.Initialization(context.Background())
+	require.NoError(t, err)
+	orig, err := c.GetIdentityCertificate()
+	require.NoError(t, err)
+
+	select {
+	case cert := <-rotated:
+		require.NotEqual(t, orig.Certificate[0], cert.Certificate[0])
+		got, err := c.GetIdentityCertificate()
+		require.NoError(t, err)
+		require.Equal(t, cert.Certificate[0], got.Certificate[0])
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		require.True(t, leaf.NotAfter.After(time.Now()))
+	case <-time.After(time.Second * 5):
+		require.FailNow(t, "identity certificate was not rotated")
+	}
+}
+
+// failingSigner fails to sign, it is used after the first certificate was issued.
+type failingSigner struct{}
+
+func (failingSigner) Sign(ctx context.Context, csr []byte) ([]byte, error) {
+	return nil, fmt.Errorf("injected failure")
+}
+
+// closeRecordingOwner records Close of the device owner.
+type closeRecordingOwner struct {
+	local.DeviceOwner
+	closed chan struct{}
+}
+
+func (o *closeRecordingOwner) Close(ctx context.Context) error {
+	close(o.closed)
+	return o.DeviceOwner.Close(ctx)
+}
+
+func TestClient_IdentityCertificateRenewalErrors(t *testing.T) {
+	signerCert, err := tls.X509KeyPair(IdentityIntermediateCA, IdentityIntermediateCAKey)
+	require.NoError(t, err)
+	var signed bool
+	createSigner := func(caCert []*x509.Certificate, caKey crypto.PrivateKey, validNotBefore time.Time, validNotAfter time.Time) core.CertificateSigner {
+		if signed {
+			return failingSigner{}
+		}
+		signed = true
+		return test.NewIdentityCertificateSigner(caCert, caKey, validNotBefore, validNotAfter)
+	}
+	errors := make(chan error, 4)
+	// validFrom is now-1m by default, so the certificate expires in 3 seconds
+	o, err := local.NewDeviceOwnershipSDK(&testSetupSecureClient{}, CertIdentity, nil, nil, &signerCert, "", time.Second*63, createSigner, local.WithError(func(err error) {
+		select {
+		case errors <- err:
+		default:
+		}
+	}))
+	require.NoError(t, err)
+	owner := &closeRecordingOwner{DeviceOwner: o, closed: make(chan struct{})}
+	c, err := local.NewClient(&testSetupSecureClient{}, owner, time.Second, time.Second, func(error) {})
+	require.NoError(t, err)
+	err = c.Initialization(context.Background())
+	require.NoError(t, err)
+
+	select {
+	case err := <-errors:
+		require.Error(t, err)
+	case <-time.After(time.Second * 5):
+		require.FailNow(t, "renewal failure was not reported")
+	}
+
+	// the device owner passed to NewClient is closed by the caller
+	err = c.Close(context.Background())
+	require.NoError(t, err)
+	select {
+	case <-owner.closed:
+		require.FailNow(t, "device owner was closed by the client")
+	default:
+	}
+	err = owner.Close(context.Background())
+	require.NoError(t, err)
+}
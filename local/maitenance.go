@@ -9,7 +9,12 @@ func (c *Client) FactoryReset(ctx context.Context, deviceID string) error {
 	}
 	defer d.Release(ctx)
 
-	return d.FactoryReset(ctx, links)
+	err = d.FactoryReset(ctx, links)
+	if err != nil {
+		return err
+	}
+	c.resetDeviceRecord(ctx, d.DeviceID())
+	return nil
 }
 
 func (c *Client) Reboot(ctx context.Context, deviceID string) error {
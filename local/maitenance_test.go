@@ -10,7 +10,8 @@ import (
 )
 
 func TestClient_FactoryReset(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 
 	type args struct {
 		deviceID string
@@ -40,6 +41,7 @@ func TestClient_FactoryReset(t *testing.T) {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	store := StoreTestDevices(t, c, dev)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -48,16 +50,22 @@ func TestClient_FactoryReset(t *testing.T) {
 			err := c.FactoryReset(ctx, tt.args.deviceID)
 			if tt.wantErr {
 				require.Error(t, err)
-			} else {
-				require.NoError(t, err)
+				return
 			}
+			require.NoError(t, err)
+			// the stored resources are forgotten, the device stays reachable by the endpoints
+			r, ok, err := store.Load(ctx, tt.args.deviceID)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Empty(t, r.Resources)
+			require.Equal(t, dev.Endpoints(), r.Endpoints)
 		})
 	}
 }
 
-/* TODO: not supported by iotivity-lite devsim
 func TestClient_Reboot(t *testing.T) {
-	deviceID = test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 	type args struct {
 		deviceID string
 	}
@@ -86,6 +94,7 @@ func TestClient_Reboot(t *testing.T) {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	StoreTestDevices(t, c, dev)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -96,9 +105,7 @@ func TestClient_Reboot(t *testing.T) {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
-				time.Sleep(time.Second * 8) // restart devsim takes arround 8seconds
 			}
 		})
 	}
 }
-*/
@@ -7,19 +7,20 @@ import (
 	"time"
 
 	"github.com/plgd-dev/sdk/local"
-	"github.com/plgd-dev/sdk/schema"
 	"github.com/plgd-dev/sdk/test"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestObserveDeviceResources(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 	c := NewTestClient()
 	defer func() {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	StoreTestDevices(t, c, dev)
 
 	h := makeTestDeviceResourcesObservationHandler()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
@@ -33,16 +34,11 @@ LOOP:
 		case res := <-h.res:
 			if res.Link.Href == "/oic/d" {
 				res.Link.Endpoints = nil
+				link, ok := dev.Server().Links(nil, nil).GetResourceLink("/oic/d")
+				require.True(t, ok)
+				link.Endpoints = nil
 				require.Equal(t, local.DeviceResourcesObservationEvent{
-					Link: schema.ResourceLink{
-						Href:          "/oic/d",
-						ResourceTypes: []string{"oic.d.cloudDevice", "oic.wk.d"},
-						Interfaces:    []string{"oic.if.r", "oic.if.baseline"},
-						Anchor:        "ocf://" + deviceID,
-						Policy: &schema.Policy{
-							BitMask: schema.Discoverable | schema.Observable,
-						},
-					},
+					Link:  link,
 					Event: local.DeviceResourcesObservationEvent_ADDED,
 				}, res)
 				break LOOP
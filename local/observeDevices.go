@@ -3,6 +3,7 @@ package local
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -19,9 +20,18 @@ type DevicesObservationEvent_type uint8
 const DevicesObservationEvent_ONLINE DevicesObservationEvent_type = 0
 const DevicesObservationEvent_OFFLINE DevicesObservationEvent_type = 1
 
+// DevicesObservationEvent_UPDATED the online device changed endpoints or ownership.
+const DevicesObservationEvent_UPDATED DevicesObservationEvent_type = 2
+
 type DevicesObservationEvent struct {
 	DeviceID string
 	Event    DevicesObservationEvent_type
+	// OldEndpoints, Endpoints, OldOwnership and Ownership are set only for the UPDATED event,
+	// the ownership is known only with WithOwnershipChanges.
+	OldEndpoints schema.Endpoints
+	Endpoints    schema.Endpoints
+	OldOwnership *schema.Doxm
+	Ownership    *schema.Doxm
 }
 
 type DevicesObservationHandler = interface {
@@ -31,41 +41,118 @@ type DevicesObservationHandler = interface {
 }
 
 type devicesObserver struct {
-	c                      *Client
-	handler                *devicesObservationHandler
-	discoveryConfiguration core.DiscoveryConfiguration
-
-	cancel    context.CancelFunc
-	interval  time.Duration
+	c                       *Client
+	handler                 *devicesObservationHandler
+	discoveryConfiguration  core.DiscoveryConfiguration
+	ownershipChanges        bool
+	offlineAfterMissedPolls int
+
+	cancel   context.CancelFunc
+	interval time.Duration
+	// pollPause is the pause between polls, the presence reports devices so polls are only the fallback.
+	pollPause time.Duration
 	wait      func()
-	deviceIDs map[string]bool
+
+	lock        sync.Mutex
+	devices     map[string]observedDevice
+	missedPolls map[string]int
+	queue       []DevicesObservationEvent
+
+	// sendLock keeps the order of queued events, which are sent without the lock.
+	sendLock sync.Mutex
 }
 
-func newDevicesObserver(c *Client, interval time.Duration, discoveryConfiguration core.DiscoveryConfiguration, handler *devicesObservationHandler) *devicesObserver {
+// observedDevice is the state of the device from the last poll, empty fields are not known yet.
+type observedDevice struct {
+	endpoints schema.Endpoints
+	ownership *schema.Doxm
+}
+
+func endpointsEqual(a, b schema.Endpoints) bool {
+	a = mergeEndpoints(a, nil)
+	b = mergeEndpoints(b, nil)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func ownershipEqual(a, b *schema.Doxm) bool {
+	return reflect.DeepEqual(*a, *b)
+}
+
+// update returns the new state of the device and whether endpoints or the ownership were changed.
+func (d observedDevice) update(found observedDevice) (observedDevice, bool) {
+	var changed bool
+	if len(found.endpoints) > 0 {
+		changed = len(d.endpoints) > 0 && !endpointsEqual(d.endpoints, found.endpoints)
+		d.endpoints = found.endpoints
+	}
+	if found.ownership != nil {
+		changed = changed || (d.ownership != nil && !ownershipEqual(d.ownership, found.ownership))
+		d.ownership = found.ownership
+	}
+	return d, changed
+}
+
+func newDevicesObserver(c *Client, interval time.Duration, cfg observeDevicesOptions, handler *devicesObservationHandler) *devicesObserver {
 	ctx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
 	obs := &devicesObserver{
-		c:                      c,
-		handler:                handler,
-		interval:               interval,
-		discoveryConfiguration: discoveryConfiguration,
-
-		cancel: cancel,
-		wait:   wg.Wait,
+		c:                       c,
+		handler:                 handler,
+		interval:                interval,
+		discoveryConfiguration:  cfg.discoveryConfiguration,
+		ownershipChanges:        cfg.ownershipChanges,
+		offlineAfterMissedPolls: cfg.offlineAfterMissedPolls,
+
+		cancel:      cancel,
+		wait:        wg.Wait,
+		devices:     make(map[string]observedDevice),
+		missedPolls: make(map[string]int),
+	}
+	if cfg.presence {
+		obs.pollPause = interval * (presencePollingIntervals - 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obs.observePresence(ctx)
+		}()
 	}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for obs.poll(ctx) {
+		for obs.poll(ctx) && obs.pause(ctx) {
 		}
 	}()
 	return obs
 }
 
+// pause waits before the next poll, it returns false when the ctx is done.
+func (o *devicesObserver) pause(ctx context.Context) bool {
+	if o.pollPause <= 0 {
+		return true
+	}
+	t := time.NewTimer(o.pollPause)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		o.handler.OnClose()
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
 func (o *devicesObserver) poll(ctx context.Context) bool {
 	pollCtx, cancel := context.WithTimeout(ctx, o.interval)
 	defer cancel()
-	newDeviceIDs, err := o.observe(pollCtx)
+	err := o.observe(pollCtx)
 	select {
 	case <-ctx.Done():
 		o.handler.OnClose()
@@ -75,47 +162,85 @@ func (o *devicesObserver) poll(ctx context.Context) bool {
 			o.handler.Error(err)
 			return false
 		}
-		o.deviceIDs = newDeviceIDs
 		return true
 	}
 }
 
-func (o *devicesObserver) processDevices(devices *sync.Map) (added map[string]bool, removed []string, current map[string]bool) {
-	current = make(map[string]bool)
-	devices.Range(func(key, value interface{}) bool {
-		current[key.(string)] = true
-		return true
-	})
+// processDevices compares devices of the poll with known devices. The device is removed
+// after offlineAfterMissedPolls consecutive polls without its response.
+func (o *devicesObserver) processDevices(found map[string]observedDevice) (added map[string]bool, removed []string, updated []DevicesObservationEvent, current map[string]observedDevice) {
+	current = make(map[string]observedDevice, len(found))
 	added = make(map[string]bool)
-	removed = make([]string, 0, len(current))
-	for deviceID := range o.deviceIDs {
-		_, ok := current[deviceID]
-		if !ok {
+	removed = make([]string, 0, len(o.devices))
+	for deviceID, d := range o.devices {
+		_, ok := found[deviceID]
+		if ok {
+			continue
+		}
+		if o.missedPolls[deviceID]+1 >= o.offlineAfterMissedPolls {
 			removed = append(removed, deviceID)
+			continue
 		}
+		current[deviceID] = d
 	}
-	for deviceID := range current {
-		_, ok := o.deviceIDs[deviceID]
+	for deviceID, f := range found {
+		old, ok := o.devices[deviceID]
+		d, changed := old.update(f)
+		current[deviceID] = d
 		if !ok {
 			added[deviceID] = true
+			continue
+		}
+		if changed {
+			updated = append(updated, DevicesObservationEvent{
+				DeviceID:     deviceID,
+				Event:        DevicesObservationEvent_UPDATED,
+				OldEndpoints: old.endpoints,
+				Endpoints:    d.endpoints,
+				OldOwnership: old.ownership,
+				Ownership:    d.ownership,
+			})
 		}
 	}
 	return
 }
 
-func (o *devicesObserver) emit(ctx context.Context, deviceID string, added bool) error {
+// push queues the ONLINE or OFFLINE event under the lock, so events are sent in the order of changes.
+func (o *devicesObserver) push(deviceID string, added bool) {
 	ev := DevicesObservationEvent_OFFLINE
 	if added {
 		ev = DevicesObservationEvent_ONLINE
 	}
-	return o.handler.Handle(ctx, DevicesObservationEvent{
+	o.queue = append(o.queue, DevicesObservationEvent{
 		DeviceID: deviceID,
 		Event:    ev,
 	})
 }
 
+func (o *devicesObserver) pop() []DevicesObservationEvent {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	queue := o.queue
+	o.queue = nil
+	return queue
+}
+
+// flush sends queued events to the handler without the lock.
+func (o *devicesObserver) flush(ctx context.Context) error {
+	o.sendLock.Lock()
+	defer o.sendLock.Unlock()
+	for _, ev := range o.pop() {
+		err := o.handler.Handle(ctx, ev)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type listDeviceIds struct {
-	devices *sync.Map
+	lock    sync.Mutex
+	devices map[string]observedDevice
 	err     func(err error)
 }
 
@@ -126,7 +251,19 @@ func (o *listDeviceIds) Handle(ctx context.Context, client *client.ClientConn, d
 	if !ok {
 		return
 	}
-	o.devices.Store(d.GetDeviceID(), nil)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	v := o.devices[d.GetDeviceID()]
+	v.endpoints = mergeEndpoints(v.endpoints, d.GetEndpoints())
+	o.devices[d.GetDeviceID()] = v
+}
+
+func (o *listDeviceIds) setOwnership(doxm schema.Doxm) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	v := o.devices[doxm.DeviceID]
+	v.ownership = &doxm
+	o.devices[doxm.DeviceID] = v
 }
 
 // Error gets errors during discovery.
@@ -136,7 +273,20 @@ func (o *listDeviceIds) Error(err error) {
 	}
 }
 
-func (o *devicesObserver) discover(ctx context.Context, handler core.DiscoverDevicesHandler) error {
+// found returns devices which answered to the discovery, the ownership without the device is ignored.
+func (o *listDeviceIds) found() map[string]observedDevice {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	found := make(map[string]observedDevice, len(o.devices))
+	for deviceID, d := range o.devices {
+		if len(d.endpoints) > 0 {
+			found[deviceID] = d
+		}
+	}
+	return found
+}
+
+func (o *devicesObserver) discover(ctx context.Context, handler core.DiscoverDevicesHandler, opts ...coap.OptionFunc) error {
 	multicastConn, err := core.DialDiscoveryAddresses(ctx, o.discoveryConfiguration, o.c.errors)
 	if err != nil {
 		return fmt.Errorf("could not discover devices: %w", err)
@@ -147,34 +297,60 @@ func (o *devicesObserver) discover(ctx context.Context, handler core.DiscoverDev
 		}
 	}()
 	// we want to just get "oic.wk.d" resource, because links will be get via unicast to /oic/res
-	return core.DiscoverDevices(ctx, multicastConn, handler, coap.WithResourceType("oic.wk.d"))
+	opts = append(opts, coap.WithResourceType("oic.wk.d"))
+	return core.DiscoverDevices(ctx, multicastConn, handler, opts...)
 }
 
-func (o *devicesObserver) observe(ctx context.Context) (map[string]bool, error) {
-	newDevices := listDeviceIds{err: o.c.errors, devices: &sync.Map{}}
+func (o *devicesObserver) observe(ctx context.Context) error {
+	newDevices := listDeviceIds{err: o.c.errors, devices: make(map[string]observedDevice)}
 
+	var wg sync.WaitGroup
+	if o.ownershipChanges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := newDiscoveryOwnershipsHandler(ctx, newDevices.Error, newDevices.setOwnership)
+			err := o.c.client.GetOwnerships(ctx, o.discoveryConfiguration, core.DiscoverAllDevices, h)
+			if err != nil {
+				newDevices.Error(err)
+			}
+		}()
+	}
 	err := o.discover(ctx, &newDevices)
+	wg.Wait()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if ctx.Err() == context.Canceled {
-		return nil, ctx.Err()
+		return ctx.Err()
 	}
 
-	added, removed, current := o.processDevices(newDevices.devices)
-	for deviceID := range added {
-		err := o.emit(ctx, deviceID, true)
-		if err != nil {
-			return nil, err
+	o.lock.Lock()
+	found := newDevices.found()
+	added, removed, updated, current := o.processDevices(found)
+	for deviceID := range current {
+		if _, ok := added[deviceID]; ok {
+			continue
+		}
+		if _, ok := found[deviceID]; ok {
+			delete(o.missedPolls, deviceID)
+		} else {
+			o.missedPolls[deviceID]++
 		}
 	}
 	for _, deviceID := range removed {
-		err := o.emit(ctx, deviceID, false)
-		if err != nil {
-			return nil, err
-		}
+		delete(o.missedPolls, deviceID)
+	}
+	o.devices = current
+	for deviceID := range added {
+		o.push(deviceID, true)
+	}
+	o.queue = append(o.queue, updated...)
+	for _, deviceID := range removed {
+		o.push(deviceID, false)
 	}
-	return current, nil
+	o.lock.Unlock()
+	return o.flush(ctx)
 }
 
 func (o *devicesObserver) Cancel() {
@@ -240,18 +416,22 @@ func (c *Client) stopObservingDevices(observationID string) (sync func(), err er
 
 func (c *Client) ObserveDevices(ctx context.Context, handler DevicesObservationHandler, opts ...ObserveDevicesOption) (string, error) {
 	cfg := observeDevicesOptions{
-		discoveryConfiguration: core.DefaultDiscoveryConfiguration(),
+		discoveryConfiguration:  core.DefaultDiscoveryConfiguration(),
+		offlineAfterMissedPolls: 1,
 	}
 	for _, o := range opts {
 		cfg = o.applyOnObserveDevices(cfg)
 	}
+	if cfg.offlineAfterMissedPolls < 1 {
+		return "", fmt.Errorf("invalid number of missed polls %v", cfg.offlineAfterMissedPolls)
+	}
 
 	ID, err := uuid.NewV4()
 	if err != nil {
 		return "", err
 	}
 
-	obs := newDevicesObserver(c, c.observerPollingInterval, cfg.discoveryConfiguration, &devicesObservationHandler{
+	obs := newDevicesObserver(c, c.observerPollingInterval, cfg, &devicesObservationHandler{
 		handler: handler,
 		removeSubscription: func() {
 			c.stopObservingDevices(ID.String())
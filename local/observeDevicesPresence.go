@@ -0,0 +1,78 @@
+package local
+
+import (
+	"context"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/udp/client"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// presenceObservationPolls is how many poll intervals the multicast observation lasts before it is registered again,
+// because devices drop observers which are not reachable and devices which join the network later don't know about it.
+const presenceObservationPolls = 20
+
+// presencePollingIntervals is how many polling intervals pass between polls with the presence. The presence reports
+// new devices, so polls only detect offline devices and devices without the support of the observation.
+const presencePollingIntervals = 3
+
+type presenceHandler struct {
+	ctx context.Context
+	o   *devicesObserver
+}
+
+// Handle is called for the response to the multicast observation and for each notification of the device.
+func (h *presenceHandler) Handle(ctx context.Context, client *client.ClientConn, device schema.ResourceLinks) {
+	defer client.Close()
+	d, ok := device.GetResourceLink("/oic/d")
+	if !ok {
+		return
+	}
+	err := h.o.online(h.ctx, d.GetDeviceID())
+	if err != nil {
+		h.o.handler.Error(err)
+	}
+}
+
+func (h *presenceHandler) Error(err error) {
+	if h.o.c.errors != nil {
+		h.o.c.errors(err)
+	}
+}
+
+// online emits ONLINE for the device reported by the presence without waiting for the next poll.
+func (o *devicesObserver) online(ctx context.Context, deviceID string) error {
+	o.lock.Lock()
+	delete(o.missedPolls, deviceID)
+	if _, ok := o.devices[deviceID]; ok {
+		o.lock.Unlock()
+		return nil
+	}
+	o.devices[deviceID] = observedDevice{}
+	o.push(deviceID, true)
+	o.lock.Unlock()
+	return o.flush(ctx)
+}
+
+// observePresence observes /oic/res via the multicast, so devices which support the observation are reported
+// as soon as they join the network. Devices without the support are still reported by polls.
+func (o *devicesObserver) observePresence(ctx context.Context) {
+	for ctx.Err() == nil {
+		obsCtx, cancel := context.WithTimeout(ctx, o.interval*presenceObservationPolls)
+		err := o.discover(obsCtx, &presenceHandler{ctx: ctx, o: o}, coap.WithObserve())
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+		if o.c.errors != nil {
+			o.c.errors(err)
+		}
+		t := time.NewTimer(o.interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+		case <-t.C:
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/plgd-dev/sdk/app"
 	"github.com/plgd-dev/sdk/local"
 	"github.com/plgd-dev/sdk/test"
 
@@ -30,7 +31,8 @@ LOOP:
 }
 
 func TestObserveDevices(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 	c := NewTestClient()
 	defer func() {
 		err := c.Close(context.Background())
@@ -41,7 +43,7 @@ func TestObserveDevices(t *testing.T) {
 	defer cancel()
 
 	h := makeTestDevicesObservationHandler()
-	ID, err := c.ObserveDevices(ctx, h)
+	ID, err := c.ObserveDevices(ctx, h, local.WithDiscoveryConfigration(dev.DiscoveryConfiguration()))
 	require.NoError(t, err)
 
 	waitForDevicesObservationEvent(ctx, t, h.devs, local.DevicesObservationEvent{
@@ -79,6 +81,134 @@ LOOP:
 	}
 }
 
+func TestObserveDevicesWithPresence(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
+	// the poll reports devices after the interval, so the device reported sooner comes from the presence
+	const pollingInterval = time.Second * 2
+	const missedPolls = 3
+	appCallback, err := app.NewApp(nil)
+	require.NoError(t, err)
+	c, err := local.NewClientFromConfig(&local.Config{
+		KeepAliveConnectionTimeoutSeconds: 1,
+		ObserverPollingIntervalSeconds:    uint64(pollingInterval / time.Second),
+	}, appCallback, test.NewIdentityCertificateSigner, func(error) {})
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	defer cancel()
+
+	h := makeTestDevicesObservationHandler()
+	_, err = c.ObserveDevices(ctx, h, local.WithOfflineAfterMissedPolls(0))
+	require.Error(t, err)
+
+	ID, err := c.ObserveDevices(ctx, h, local.WithPresence(), local.WithOfflineAfterMissedPolls(missedPolls), local.WithDiscoveryConfigration(dev.DiscoveryConfiguration()))
+	require.NoError(t, err)
+
+	presenceCtx, presenceCancel := context.WithTimeout(ctx, pollingInterval/2)
+	defer presenceCancel()
+	waitForDevicesObservationEvent(presenceCtx, t, h.devs, local.DevicesObservationEvent{
+		DeviceID: deviceID,
+		Event:    local.DevicesObservationEvent_ONLINE,
+	})
+	// the device is reported once, even it answers to the presence and to polls
+	waitCtx, waitCancel := context.WithTimeout(ctx, pollingInterval*2)
+	defer waitCancel()
+LOOP:
+	for {
+		select {
+		case ev := <-h.devs:
+			require.NotEqual(t, deviceID, ev.DeviceID)
+		case <-waitCtx.Done():
+			break LOOP
+		}
+	}
+
+	// the device is reported as offline after all missed polls, which run every third interval with the presence
+	dev.Close()
+	closed := time.Now()
+	waitForDevicesObservationEvent(ctx, t, h.devs, local.DevicesObservationEvent{
+		DeviceID: deviceID,
+		Event:    local.DevicesObservationEvent_OFFLINE,
+	})
+	require.GreaterOrEqual(t, int64(time.Since(closed)), int64(pollingInterval*3*(missedPolls-1)))
+
+	err = c.StopObservingDevices(ctx, ID)
+	require.NoError(t, err)
+}
+
+func waitForDevicesObservationUpdatedEvent(ctx context.Context, t *testing.T, chanDevs <-chan local.DevicesObservationEvent, deviceID string) local.DevicesObservationEvent {
+	for {
+		select {
+		case ev := <-chanDevs:
+			if ev.DeviceID == deviceID && ev.Event == local.DevicesObservationEvent_UPDATED {
+				return ev
+			}
+		case <-ctx.Done():
+			require.NoError(t, fmt.Errorf("timeout"))
+			return local.DevicesObservationEvent{}
+		}
+	}
+}
+
+// waitForDevicesObservationOwnedEvent waits for the UPDATED event with the ownership of the device.
+func waitForDevicesObservationOwnedEvent(ctx context.Context, t *testing.T, chanDevs <-chan local.DevicesObservationEvent, deviceID string, owned bool) local.DevicesObservationEvent {
+	for {
+		ev := waitForDevicesObservationUpdatedEvent(ctx, t, chanDevs, deviceID)
+		if ev.Ownership != nil && ev.Ownership.Owned == owned {
+			return ev
+		}
+	}
+}
+
+func TestObserveDevicesUpdated(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	deviceID := dev.ID()
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	h := makeTestDevicesObservationHandler()
+	ID, err := c.ObserveDevices(ctx, h, local.WithOwnershipChanges(), local.WithDiscoveryConfigration(dev.DiscoveryConfiguration()))
+	require.NoError(t, err)
+	defer func() {
+		err := c.StopObservingDevices(ctx, ID)
+		require.NoError(t, err)
+	}()
+
+	waitForDevicesObservationEvent(ctx, t, h.devs, local.DevicesObservationEvent{
+		DeviceID: deviceID,
+		Event:    local.DevicesObservationEvent_ONLINE,
+	})
+
+	deviceID, err = c.OwnDevice(ctx, deviceID)
+	require.NoError(t, err)
+	// a poll during the transfer can report the intermediate ownership
+	ev := waitForDevicesObservationOwnedEvent(ctx, t, h.devs, deviceID, true)
+	require.NotNil(t, ev.OldOwnership)
+	require.False(t, ev.OldOwnership.Owned)
+	require.NotNil(t, ev.Ownership)
+	require.True(t, ev.Ownership.Owned)
+	require.NotEmpty(t, ev.Endpoints)
+
+	err = c.DisownDevice(ctx, deviceID)
+	require.NoError(t, err)
+	ev = waitForDevicesObservationOwnedEvent(ctx, t, h.devs, deviceID, false)
+	require.True(t, ev.OldOwnership.Owned)
+	require.False(t, ev.Ownership.Owned)
+}
+
 func makeTestDevicesObservationHandler() *testDevicesObservationHandler {
 	return &testDevicesObservationHandler{devs: make(chan local.DevicesObservationEvent, 100)}
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/plgd-dev/go-coap/v2/message"
@@ -46,26 +47,114 @@ func (c observerCodec) Decode(m *message.Message, v interface{}) error {
 }
 
 type observationsHandler struct {
-	client *Client
-	device *RefDevice
-	id     string
+	client        *Client
+	id            string
+	deviceID      string
+	href          string
+	contentFormat message.MediaType
+	resilient     *resilientObservation
+	opts          []kitNetCoap.OptionFunc
 
 	sync.Mutex
 
+	stateLock     sync.Mutex
+	device        *RefDevice
 	observationID string
-	lastMessage   atomic.Value
+	closed        bool
+	done          chan struct{}
+	staleTimer    *time.Timer
 
+	lastMessage  atomic.Value
+	freshness    notificationFreshness
 	observations *kitSync.Map
 }
 
+// setObservation stores the registration of the observation, it returns false when the handler was already stopped.
+func (o *observationsHandler) setObservation(d *RefDevice, observationID string) bool {
+	o.stateLock.Lock()
+	defer o.stateLock.Unlock()
+	if o.closed {
+		return false
+	}
+	o.device = d
+	o.observationID = observationID
+	return true
+}
+
+// stop prevents the observation from being registered again and returns the current registration.
+func (o *observationsHandler) stop() (*RefDevice, string) {
+	o.stateLock.Lock()
+	defer o.stateLock.Unlock()
+	o.stopReconnectingLocked()
+	d, observationID := o.device, o.observationID
+	o.device = nil
+	o.observationID = ""
+	return d, observationID
+}
+
+// stopReconnecting prevents the observation from being registered again and stops reporting the stale observation,
+// the current registration is kept.
+func (o *observationsHandler) stopReconnecting() {
+	o.stateLock.Lock()
+	defer o.stateLock.Unlock()
+	o.stopReconnectingLocked()
+}
+
+func (o *observationsHandler) stopReconnectingLocked() {
+	o.stopStaleTimerLocked()
+	if !o.closed {
+		o.closed = true
+		close(o.done)
+	}
+}
+
+// observe registers the observation of the resource at the device.
+func (o *observationsHandler) observe(ctx context.Context) error {
+	d, links, err := o.client.GetRefDevice(ctx, o.deviceID)
+	if err != nil {
+		return err
+	}
+
+	defer d.Release(ctx)
+
+	link, err := core.GetResourceLink(links, o.href)
+	if err != nil {
+		return err
+	}
+
+	observationID, err := d.ObserveResourceWithCodec(ctx, link, observerCodec{contentFormat: o.contentFormat}, o, o.opts...)
+	if err != nil {
+		return err
+	}
+
+	err = o.client.deviceCache.StoreDeviceToPermanentCache(d)
+	if err != nil {
+		return err
+	}
+
+	d.Acquire()
+	if !o.setObservation(d, observationID) {
+		o.client.releaseObservation(ctx, d, observationID)
+	}
+	return nil
+}
+
 type decodeFunc = func(v interface{}, codec kitNetCoap.Codec) error
 
 type observationHandler struct {
 	handler      core.ObservationHandler
 	codec        kitNetCoap.Codec
+	filter       notificationFilter
 	lock         sync.Mutex
 	isClosed     bool
 	firstMessage decodeFunc
+	canceled     int32
+
+	delivered     bool
+	last          filteredNotification
+	lastDelivered time.Time
+	pending       *pendingNotification
+	pendingTimer  *time.Timer
 }
 
 func createDecodeFunc(message *message.Message) decodeFunc {
@@ -94,11 +183,19 @@ func (h *observationHandler) handleMessageLocked(ctx context.Context, decode dec
 	})
 }
 
+func (h *observationHandler) dispatchMessageLocked(ctx context.Context, decode decodeFunc) {
+	if h.filter.isSet() {
+		h.filterMessageLocked(ctx, decode)
+		return
+	}
+	h.handleMessageLocked(ctx, decode)
+}
+
 func (h *observationHandler) HandleMessage(ctx context.Context, decode decodeFunc) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 	h.firstMessage = nil
-	h.handleMessageLocked(ctx, decode)
+	h.dispatchMessageLocked(ctx, decode)
 }
 
 func (h *observationHandler) HandleFirstMessage() {
@@ -109,7 +206,7 @@ func (h *observationHandler) HandleFirstMessage() {
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	h.handleMessageLocked(ctx, h.firstMessage)
+	h.dispatchMessageLocked(ctx, h.firstMessage)
 }
 
 func (h *observationHandler) OnClose() {
@@ -119,6 +216,7 @@ func (h *observationHandler) OnClose() {
 		return
 	}
 	h.isClosed = true
+	h.stopPendingLocked()
 	h.handler.OnClose()
 }
 
@@ -129,6 +227,7 @@ func (h *observationHandler) Error(err error) {
 		return
 	}
 	h.isClosed = true
+	h.stopPendingLocked()
 	h.handler.Error(err)
 }
 
@@ -144,6 +243,7 @@ func parseIDs(ID string) (string, string, error) {
 	return v[0], v[1], nil
 }
 
+// ObserveResource observes the resource of the device, notifications are delivered to the handler.
 func (c *Client) ObserveResource(
 	ctx context.Context,
 	deviceID string,
@@ -162,16 +262,38 @@ func (c *Client) ObserveResource(
 		return "", err
 	}
 
-	key := uuid.NewV5(uuid.NamespaceURL, deviceID+href).String()
+	var queries []string
+	if cfg.conditional {
+		queries = cfg.filter.queries()
+	}
+	coapOpts := make([]kitNetCoap.OptionFunc, 0, len(queries))
+	for _, q := range queries {
+		coapOpts = append(coapOpts, kitNetCoap.WithQuery(q))
+	}
+	if cfg.resilient != nil {
+		queries = append(queries, "resilient")
+	}
+	name := deviceID + href
+	if len(queries) > 0 {
+		// observations with different registrations don't share them
+		name += "?" + strings.Join(queries, "&")
+	}
+	key := uuid.NewV5(uuid.NamespaceURL, name).String()
 	val, loaded := c.observeResourceCache.LoadOrStoreWithFunc(key, func(value interface{}) interface{} {
 		h := value.(*observationsHandler)
 		h.Lock()
 		return h
 	}, func() interface{} {
 		h := observationsHandler{
-			observations: kitSync.NewMap(),
-			client:       c,
-			id:           key,
+			observations:  kitSync.NewMap(),
+			client:        c,
+			id:            key,
+			deviceID:      deviceID,
+			href:          href,
+			contentFormat: cfg.codec.ContentFormat(),
+			resilient:     cfg.resilient,
+			opts:          coapOpts,
+			done:          make(chan struct{}),
 		}
 		h.Lock()
 		return &h
@@ -187,6 +309,7 @@ func (c *Client) ObserveResource(
 	obsHandler := observationHandler{
 		handler:      handler,
 		codec:        cfg.codec,
+		filter:       cfg.filter,
 		firstMessage: firstMessage,
 	}
 	h.observations.Store(resourceObservationID.String(), &obsHandler)
@@ -195,33 +318,12 @@ func (c *Client) ObserveResource(
 		return getObservationID(key, resourceObservationID.String()), nil
 	}
 
-	d, links, err := c.GetRefDevice(ctx, deviceID)
-	if err != nil {
-		return "", err
-	}
-
-	defer d.Release(ctx)
-
-	link, err := core.GetResourceLink(links, href)
-	if err != nil {
-		return "", err
-	}
-
-	observationID, err = d.ObserveResourceWithCodec(ctx, link, observerCodec{contentFormat: cfg.codec.ContentFormat()}, h)
-	if err != nil {
-		return "", err
-	}
-
-	err = c.deviceCache.StoreDeviceToPermanentCache(d)
+	err = h.observe(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	d.Acquire()
-	h.observationID = observationID
-	h.device = d
-
-	return getObservationID(key, resourceObservationID.String()), err
+	return getObservationID(key, resourceObservationID.String()), nil
 }
 
 func (c *Client) StopObservingResource(ctx context.Context, observationID string) error {
@@ -229,31 +331,39 @@ func (c *Client) StopObservingResource(ctx context.Context, observationID string
 	if err != nil {
 		return err
 	}
-	var resourceObservationID string
-	var deleteDevice *RefDevice
+	var deleteHandler *observationsHandler
 	c.observeResourceCache.ReplaceWithFunc(resourceCacheID, func(oldValue interface{}, oldLoaded bool) (newValue interface{}, delete bool) {
 		if !oldLoaded {
 			return nil, true
 		}
 		h := oldValue.(*observationsHandler)
-		resourceObservationID = h.observationID
-		_, ok := h.observations.PullOut(internalResourceObservationID)
+		obsHandler, ok := h.observations.PullOut(internalResourceObservationID)
 		if !ok {
 			return h, false
 		}
+		obsHandler.(*observationHandler).cancel()
 
 		if h.observations.Length() == 0 {
-			deleteDevice = h.device
+			deleteHandler = h
 			return nil, true
 		}
 		return h, false
 	})
+	if deleteHandler == nil {
+		return nil
+	}
+	deleteDevice, resourceObservationID := deleteHandler.stop()
 	if deleteDevice == nil {
 		return nil
 	}
-	defer deleteDevice.Release(ctx)
-	err = deleteDevice.StopObservingResource(ctx, resourceObservationID)
-	c.deviceCache.RemoveDeviceFromPermanentCache(ctx, deleteDevice.DeviceID(), deleteDevice)
+	return c.releaseObservation(ctx, deleteDevice, resourceObservationID)
+}
+
+// releaseObservation stops the observation at the device and releases the device acquired for it.
+func (c *Client) releaseObservation(ctx context.Context, d *RefDevice, observationID string) error {
+	defer d.Release(ctx)
+	err := d.StopObservingResource(ctx, observationID)
+	c.deviceCache.RemoveDeviceFromPermanentCache(ctx, d.DeviceID(), d)
 	return err
 }
 
@@ -262,13 +372,19 @@ func (c *Client) closeObservingResource(ctx context.Context, o *observationsHand
 	if !ok {
 		return
 	}
-	if o.device != nil {
-		defer o.device.Release(ctx)
-		o.device.StopObservingResource(ctx, o.observationID)
-		c.deviceCache.RemoveDeviceFromPermanentCache(ctx, o.device.DeviceID(), o.device)
+	if d, observationID := o.stop(); d != nil {
+		c.releaseObservation(ctx, d, observationID)
 	}
 }
 
+// stopReconnectingObservations prevents resilient observations from being registered again when the client is closed.
+func (c *Client) stopReconnectingObservations() {
+	c.observeResourceCache.Range(func(key, value interface{}) bool {
+		value.(*observationsHandler).stopReconnecting()
+		return true
+	})
+}
+
 func (o *observationsHandler) Handle(ctx context.Context, body kitNetCoap.DecodeFunc) {
 	var message *message.Message
 	err := body(&message)
@@ -276,24 +392,26 @@ func (o *observationsHandler) Handle(ctx context.Context, body kitNetCoap.Decode
 		o.Error(err)
 		return
 	}
+	if !o.freshness.accept(message, time.Now(), o.resilient != nil) {
+		return
+	}
+	o.resetStaleTimer(getMaxAge(message))
 	decode := createDecodeFunc(message)
 	o.lastMessage.Store(decode)
-	observations := make([]*observationHandler, 0, 4)
-	o.observations.Range(func(key, value interface{}) bool {
-		observations = append(observations, value.(*observationHandler))
-		return true
-	})
-	for _, h := range observations {
+	for _, h := range o.getObservations() {
 		h.HandleMessage(ctx, decode)
 	}
 }
 
 func (o *observationsHandler) OnClose() {
+	if o.resilient != nil && o.startReconnecting() {
+		return
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	o.client.closeObservingResource(ctx, o)
 	for _, h := range o.observations.PullOutAll() {
-		h.(*observationHandler).handler.OnClose()
+		h.(*observationHandler).OnClose()
 	}
 }
 
@@ -302,6 +420,6 @@ func (o *observationsHandler) Error(err error) {
 	defer cancel()
 	o.client.closeObservingResource(ctx, o)
 	for _, h := range o.observations.PullOutAll() {
-		h.(*observationHandler).handler.Error(err)
+		h.(*observationHandler).Error(err)
 	}
 }
@@ -0,0 +1,217 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/kit/codec/cbor"
+)
+
+// notificationFilter selects notifications delivered to one observer.
+type notificationFilter struct {
+	minInterval         time.Duration
+	hasThreshold        bool
+	threshold           float64
+	thresholdProperties []string
+	deduplicate         bool
+}
+
+func (f notificationFilter) isSet() bool {
+	return f.minInterval > 0 || f.hasThreshold || f.deduplicate
+}
+
+// queries returns CoRE conditional attributes of the filter.
+func (f notificationFilter) queries() []string {
+	var queries []string
+	if pmin := int64(f.minInterval / time.Second); pmin > 0 {
+		queries = append(queries, "pmin="+strconv.FormatInt(pmin, 10))
+	}
+	if f.hasThreshold && len(f.thresholdProperties) == 0 {
+		queries = append(queries, "st="+strconv.FormatFloat(f.threshold, 'f', -1, 64))
+	}
+	return queries
+}
+
+func (f notificationFilter) comparesProperty(name string) bool {
+	if len(f.thresholdProperties) == 0 {
+		return true
+	}
+	for _, p := range f.thresholdProperties {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case uint64:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func (f notificationFilter) exceedsThreshold(last, current map[string]interface{}) bool {
+	if len(last) != len(current) {
+		return true
+	}
+	for name, v := range current {
+		lastV, ok := last[name]
+		if !ok {
+			return true
+		}
+		if f.comparesProperty(name) {
+			a, aok := toFloat(lastV)
+			b, bok := toFloat(v)
+			if aok && bok {
+				if math.Abs(b-a) >= f.threshold {
+					return true
+				}
+				continue
+			}
+		}
+		if !reflect.DeepEqual(lastV, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// changed reports whether the notification differs from the last delivered one enough to be delivered.
+func (f notificationFilter) changed(last, current filteredNotification) bool {
+	if f.hasThreshold && last.values != nil && current.values != nil {
+		return f.exceedsThreshold(last.values, current.values)
+	}
+	if f.deduplicate || f.hasThreshold {
+		return !samePayload(last.body, current.body)
+	}
+	return true
+}
+
+// payloadCodec propagates the payload to v as *[]byte, it is used by filters of notifications.
+type payloadCodec struct{}
+
+func (c payloadCodec) ContentFormat() message.MediaType { return message.AppOcfCbor }
+
+func (c payloadCodec) Encode(v interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (c payloadCodec) Decode(m *message.Message, v interface{}) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("expected *[]byte instead of %T", v)
+	}
+	if m.Body == nil {
+		*p = nil
+		return nil
+	}
+	data, err := ioutil.ReadAll(m.Body)
+	if err != nil {
+		return err
+	}
+	*p = data
+	return nil
+}
+
+type filteredNotification struct {
+	body []byte
+	// values are set for the change threshold when the payload is the CBOR map.
+	values map[string]interface{}
+}
+
+type pendingNotification struct {
+	decode       decodeFunc
+	notification filteredNotification
+}
+
+func (h *observationHandler) decodeNotification(decode decodeFunc) filteredNotification {
+	var n filteredNotification
+	if err := decode(&n.body, payloadCodec{}); err != nil {
+		return n
+	}
+	if h.filter.hasThreshold {
+		var values map[string]interface{}
+		if err := cbor.Decode(n.body, &values); err == nil {
+			n.values = values
+		}
+	}
+	return n
+}
+
+// filterMessageLocked delivers the notification which passes the filter, the notification received
+// within the minimum interval is delivered at its end unless the newer one replaces it.
+func (h *observationHandler) filterMessageLocked(ctx context.Context, decode decodeFunc) {
+	if decode == nil || h.isClosed {
+		return
+	}
+	n := h.decodeNotification(decode)
+	if h.delivered && !h.filter.changed(h.last, n) {
+		// the representation returned to the delivered one
+		h.pending = nil
+		return
+	}
+	now := time.Now()
+	if h.filter.minInterval > 0 && h.delivered {
+		if wait := h.lastDelivered.Add(h.filter.minInterval).Sub(now); wait > 0 {
+			h.pending = &pendingNotification{decode: decode, notification: n}
+			if h.pendingTimer == nil {
+				h.pendingTimer = time.AfterFunc(wait, h.deliverPending)
+			}
+			return
+		}
+	}
+	h.deliverLocked(ctx, decode, n, now)
+}
+
+func (h *observationHandler) deliverLocked(ctx context.Context, decode decodeFunc, n filteredNotification, now time.Time) {
+	h.delivered = true
+	h.last = n
+	h.lastDelivered = now
+	h.pending = nil
+	h.handleMessageLocked(ctx, decode)
+}
+
+func (h *observationHandler) deliverPending() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.pendingTimer = nil
+	p := h.pending
+	if p == nil || h.isClosed || atomic.LoadInt32(&h.canceled) != 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.deliverLocked(ctx, p.decode, p.notification, time.Now())
+}
+
+func (h *observationHandler) stopPendingLocked() {
+	h.pending = nil
+	if h.pendingTimer != nil {
+		h.pendingTimer.Stop()
+		h.pendingTimer = nil
+	}
+}
+
+// cancel prevents the delivery of the pending notification to the removed observer.
+func (h *observationHandler) cancel() {
+	atomic.StoreInt32(&h.canceled, 1)
+}
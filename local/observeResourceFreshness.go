@@ -0,0 +1,143 @@
+package local
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/net/observation"
+	"github.com/plgd-dev/kit/codec/cbor"
+)
+
+// defaultMaxAge is used for notifications without the Max-Age option, see RFC 7252 5.10.5.
+const defaultMaxAge = time.Second * 60
+
+// StaleObservationHandler is optionally implemented by observation handlers which want to know
+// that the last notification is stale, because no notification arrived within its Max-Age.
+type StaleObservationHandler interface {
+	OnStale()
+}
+
+func getMaxAge(m *message.Message) time.Duration {
+	v, err := m.Options.GetUint32(message.MaxAge)
+	if err != nil {
+		return defaultMaxAge
+	}
+	return time.Duration(v) * time.Second
+}
+
+func readBody(m *message.Message) []byte {
+	if m.Body == nil {
+		return nil
+	}
+	if _, err := m.Body.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	body, err := ioutil.ReadAll(m.Body)
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// samePayload compares payloads by their values, encoders don't keep the order of properties of CBOR maps.
+func samePayload(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if cbor.Decode(a, &va) != nil || cbor.Decode(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// notificationFreshness drops notifications which are not newer than the last delivered one, see RFC 7641 3.4.
+// Sequence numbers of different registrations are not related, so the first notification of the new
+// registration is always fresh, unless it is skipped because the representation didn't change.
+type notificationFreshness struct {
+	lock         sync.Mutex
+	valid        bool
+	hasSeq       bool
+	seq          uint32
+	received     time.Time
+	body         []byte
+	reregistered bool
+}
+
+// reregister marks the start of the new registration.
+func (f *notificationFreshness) reregister() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reregistered = true
+}
+
+// accept reports whether the notification should be delivered, with skipUnchanged the first notification
+// of the new registration is dropped when it has the same representation as the last delivered one.
+func (f *notificationFreshness) accept(m *message.Message, now time.Time, skipUnchanged bool) bool {
+	seq, err := m.Options.Observe()
+	hasSeq := err == nil
+	var body []byte
+	if skipUnchanged {
+		body = readBody(m)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	accepted := true
+	switch {
+	case !f.valid:
+	case f.reregistered:
+		accepted = !skipUnchanged || !samePayload(f.body, body)
+	case hasSeq && f.hasSeq:
+		accepted = observation.ValidSequenceNumber(f.seq, seq, f.received, now)
+	}
+	if !accepted && !f.reregistered {
+		return false
+	}
+	f.valid = true
+	f.hasSeq = hasSeq
+	f.seq = seq
+	f.received = now
+	f.body = body
+	f.reregistered = false
+	return accepted
+}
+
+// resetStaleTimer reports the stale observation after maxAge unless the next notification arrives,
+// zero maxAge disables it.
+func (o *observationsHandler) resetStaleTimer(maxAge time.Duration) {
+	o.stateLock.Lock()
+	defer o.stateLock.Unlock()
+	o.stopStaleTimerLocked()
+	if o.closed || maxAge <= 0 {
+		return
+	}
+	o.staleTimer = time.AfterFunc(maxAge, func() {
+		for _, h := range o.getObservations() {
+			h.OnStale()
+		}
+	})
+}
+
+func (o *observationsHandler) stopStaleTimerLocked() {
+	if o.staleTimer != nil {
+		o.staleTimer.Stop()
+		o.staleTimer = nil
+	}
+}
+
+func (h *observationHandler) OnStale() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.isClosed {
+		return
+	}
+	if s, ok := h.handler.(StaleObservationHandler); ok {
+		s.OnStale()
+	}
+}
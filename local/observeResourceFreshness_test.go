@@ -0,0 +1,126 @@
+package local
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/kit/codec/cbor"
+	"github.com/stretchr/testify/require"
+)
+
+// notification is the notification received after the delay since the previous one.
+type notification struct {
+	seq           uint32
+	noSeq         bool
+	body          map[string]interface{}
+	after         time.Duration
+	reregister    bool
+	skipUnchanged bool
+	want          bool
+}
+
+func newNotification(t *testing.T, n notification) *message.Message {
+	m := &message.Message{}
+	if !n.noSeq {
+		opts, _, err := m.Options.SetObserve(make([]byte, 4), n.seq)
+		require.NoError(t, err)
+		m.Options = opts
+	}
+	if n.body != nil {
+		body, err := cbor.Encode(n.body)
+		require.NoError(t, err)
+		m.Body = bytes.NewReader(body)
+	}
+	return m
+}
+
+func TestNotificationFreshnessAccept(t *testing.T) {
+	on := map[string]interface{}{"state": true}
+	off := map[string]interface{}{"state": false}
+	tests := []struct {
+		name          string
+		notifications []notification
+	}{
+		{
+			name: "newer",
+			notifications: []notification{
+				{seq: 5, want: true},
+				{seq: 6, after: time.Second, want: true},
+			},
+		},
+		{
+			name: "older",
+			notifications: []notification{
+				{seq: 5, want: true},
+				{seq: 4, after: time.Second, want: false},
+				{seq: 5, after: time.Second, want: false},
+				{seq: 6, after: time.Second, want: true},
+			},
+		},
+		{
+			name: "wrap-around",
+			notifications: []notification{
+				{seq: 1<<24 - 1, want: true},
+				{seq: 1, after: time.Second, want: true},
+			},
+		},
+		{
+			name: "older after 128s",
+			notifications: []notification{
+				{seq: 5, want: true},
+				{seq: 4, after: 129 * time.Second, want: true},
+			},
+		},
+		{
+			name: "without seq",
+			notifications: []notification{
+				{noSeq: true, want: true},
+				{noSeq: true, after: time.Second, want: true},
+				{seq: 5, after: time.Second, want: true},
+				{noSeq: true, after: time.Second, want: true},
+				{seq: 1, after: time.Second, want: true},
+			},
+		},
+		{
+			name: "re-registration",
+			notifications: []notification{
+				{seq: 5, want: true},
+				{seq: 1, after: time.Second, reregister: true, want: true},
+				{seq: 2, after: time.Second, want: true},
+			},
+		},
+		{
+			name: "re-registration with unchanged representation",
+			notifications: []notification{
+				{seq: 5, body: on, skipUnchanged: true, want: true},
+				{seq: 1, body: on, after: time.Second, reregister: true, skipUnchanged: true, want: false},
+				// the sequence of the new registration is kept although the notification was dropped
+				{seq: 1, body: off, after: time.Second, skipUnchanged: true, want: false},
+				{seq: 2, body: off, after: time.Second, skipUnchanged: true, want: true},
+			},
+		},
+		{
+			name: "re-registration with changed representation",
+			notifications: []notification{
+				{seq: 5, body: on, skipUnchanged: true, want: true},
+				{seq: 1, body: off, after: time.Second, reregister: true, skipUnchanged: true, want: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f notificationFreshness
+			now := time.Now()
+			for i, n := range tt.notifications {
+				now = now.Add(n.after)
+				if n.reregister {
+					f.reregister()
+				}
+				got := f.accept(newNotification(t, n), now, n.skipUnchanged)
+				require.Equal(t, n.want, got, "notification %v", i)
+			}
+		})
+	}
+}
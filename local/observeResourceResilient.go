@@ -0,0 +1,130 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// reobserveTimeout limits one attempt to register the resilient observation again.
+const reobserveTimeout = time.Second * 10
+
+// ResilientObservationHandler is optionally implemented by handlers of observations created with WithResilientObservation.
+type ResilientObservationHandler interface {
+	// OnDisconnect is called when the connection of the observation is lost, the client starts reconnecting.
+	OnDisconnect()
+	// OnReconnect is called when the observation is registered again.
+	OnReconnect()
+}
+
+type resilientObservation struct {
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// startReconnecting releases the lost registration and registers the observation again in the background,
+// it returns false when the observation was stopped.
+func (o *observationsHandler) startReconnecting() bool {
+	o.stateLock.Lock()
+	if o.closed {
+		o.stateLock.Unlock()
+		return false
+	}
+	d, observationID := o.device, o.observationID
+	o.device = nil
+	o.observationID = ""
+	o.stateLock.Unlock()
+
+	if d != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// the observation was already removed by the closed connection
+		o.client.releaseObservation(ctx, d, observationID)
+	}
+	for _, h := range o.getObservations() {
+		h.OnDisconnect()
+	}
+	go o.reconnect()
+	return true
+}
+
+func (o *observationsHandler) getObservations() []*observationHandler {
+	observations := make([]*observationHandler, 0, 4)
+	o.observations.Range(func(key, value interface{}) bool {
+		observations = append(observations, value.(*observationHandler))
+		return true
+	})
+	return observations
+}
+
+func (o *observationsHandler) isClosed() bool {
+	o.stateLock.Lock()
+	defer o.stateLock.Unlock()
+	return o.closed
+}
+
+func (o *observationsHandler) reconnect() {
+	backoff := o.resilient.minBackoff
+	for {
+		select {
+		case <-o.done:
+			o.closeObservations()
+			return
+		case <-time.After(backoff):
+		}
+		err := o.reobserve()
+		if o.isClosed() {
+			o.closeObservations()
+			return
+		}
+		if err == nil {
+			for _, h := range o.getObservations() {
+				h.OnReconnect()
+			}
+			return
+		}
+		if o.client.errors != nil {
+			o.client.errors(fmt.Errorf("cannot observe resource %v of device %v again: %w", o.href, o.deviceID, err))
+		}
+		backoff *= 2
+		if backoff > o.resilient.maxBackoff {
+			backoff = o.resilient.maxBackoff
+		}
+	}
+}
+
+// closeObservations closes observers which are left when the client is closed, the stopped observation has no observers.
+func (o *observationsHandler) closeObservations() {
+	for _, h := range o.observations.PullOutAll() {
+		h.(*observationHandler).OnClose()
+	}
+}
+
+func (o *observationsHandler) reobserve() error {
+	ctx, cancel := context.WithTimeout(context.Background(), reobserveTimeout)
+	defer cancel()
+	o.freshness.reregister()
+	return o.observe(ctx)
+}
+
+func (h *observationHandler) OnDisconnect() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.isClosed {
+		return
+	}
+	if r, ok := h.handler.(ResilientObservationHandler); ok {
+		r.OnDisconnect()
+	}
+}
+
+func (h *observationHandler) OnReconnect() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.isClosed {
+		return
+	}
+	if r, ok := h.handler.(ResilientObservationHandler); ok {
+		r.OnReconnect()
+	}
+}
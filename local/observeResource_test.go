@@ -3,22 +3,31 @@ package local_test
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/plgd-dev/sdk/app"
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
 	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/server"
 	"github.com/plgd-dev/sdk/test"
+	"github.com/plgd-dev/sdk/test/faultinjection"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestObservingResource(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 	c := NewTestClient()
 	defer func() {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	StoreTestDevices(t, c, dev)
 
 	h := makeObservationHandler()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
@@ -96,3 +105,265 @@ func (h *observationHandler) Handle(ctx context.Context, body kitNetCoap.DecodeF
 func (h *observationHandler) Error(err error) { fmt.Println(err) }
 
 func (h *observationHandler) OnClose() { fmt.Println("Observation was closed") }
+
+type eventObservationHandler struct {
+	events chan string
+}
+
+func (h *eventObservationHandler) Handle(ctx context.Context, body kitNetCoap.DecodeFunc) {
+	var d map[string]interface{}
+	if err := body(&d); err != nil {
+		h.events <- err.Error()
+		return
+	}
+	h.events <- fmt.Sprintf("power %v", d["power"])
+}
+
+func (h *eventObservationHandler) Error(err error) { h.events <- "error" }
+
+func (h *eventObservationHandler) OnClose() { h.events <- "close" }
+
+func (h *eventObservationHandler) OnDisconnect() { h.events <- "disconnect" }
+
+func (h *eventObservationHandler) OnReconnect() { h.events <- "reconnect" }
+
+func (h *eventObservationHandler) OnStale() { h.events <- "stale" }
+
+func (h *eventObservationHandler) waitFor(ctx context.Context, t *testing.T, event string) {
+	select {
+	case e := <-h.events:
+		require.Equal(t, event, e)
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err(), "waiting for %v", event)
+	}
+}
+
+func (h *eventObservationHandler) requireNoEvent(t *testing.T) {
+	select {
+	case e := <-h.events:
+		require.Fail(t, "unexpected event", e)
+	case <-time.After(time.Millisecond * 200):
+	}
+}
+
+type observableLight struct {
+	lock    sync.Mutex
+	power   uint64
+	queries []string
+}
+
+func (l *observableLight) getQueries() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.queries
+}
+
+func newObservableDevice(t *testing.T, maxAge time.Duration) (*test.Device, *observableLight) {
+	l := &observableLight{}
+	dev, err := test.NewDevice(test.WithInsecure(), test.WithResources(server.Resource{
+		Href:          "/light/1",
+		ResourceTypes: []string{"core.light"},
+		Interfaces:    []string{"oic.if.a", "oic.if.baseline"},
+		Observable:    true,
+		MaxAge:        maxAge,
+		Get: func(ctx context.Context, req server.Request) (interface{}, error) {
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			l.queries = req.Queries
+			return map[string]interface{}{"power": l.power, "name": "light"}, nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			var v map[string]interface{}
+			if err := decode(&v); err != nil {
+				return nil, err
+			}
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			l.power = v["power"].(uint64)
+			return nil, nil
+		},
+	}))
+	require.NoError(t, err)
+	return dev, l
+}
+
+func newObservingClient(t *testing.T, dev *test.Device, opts ...core.OptionFunc) *local.Client {
+	appCallback, err := app.NewApp(nil)
+	require.NoError(t, err)
+	c, err := local.NewClient(appCallback, local.NewDeviceOwnershipNone(), time.Hour, time.Second, func(error) {}, opts...)
+	require.NoError(t, err)
+	store := local.NewMemoryDeviceStore()
+	err = store.Store(context.Background(), local.DeviceRecord{ID: dev.ID(), Endpoints: dev.Endpoints()})
+	require.NoError(t, err)
+	c.SetDeviceStore(store)
+	return c
+}
+
+func TestObservingResourceResilient(t *testing.T) {
+	dev, _ := newObservableDevice(t, 0)
+	defer dev.Close()
+	d := faultinjection.NewDialer()
+	defer d.Close()
+	c := newObservingClient(t, dev, core.WithDialUDP(d.DialUDP))
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	h := &eventObservationHandler{events: make(chan string, 16)}
+	id, err := c.ObserveResource(ctx, dev.ID(), "/light/1", h, local.WithResilientObservation(time.Millisecond*10, time.Millisecond*100))
+	require.NoError(t, err)
+	h.waitFor(ctx, t, "power 0")
+
+	// duplicated notifications are delivered once
+	d.SetRule(faultinjection.Always(faultinjection.ToClient, faultinjection.Fault{Action: faultinjection.Duplicate}))
+	err = c.UpdateResource(ctx, dev.ID(), "/light/1", map[string]interface{}{"power": uint64(1)}, nil)
+	require.NoError(t, err)
+	h.waitFor(ctx, t, "power 1")
+	h.requireNoEvent(t)
+
+	// the connection is closed by the next request
+	var closed int32
+	d.SetRule(func(p faultinjection.Packet) faultinjection.Fault {
+		if p.Direction == faultinjection.ToDevice && atomic.CompareAndSwapInt32(&closed, 0, 1) {
+			return faultinjection.Fault{Action: faultinjection.Close}
+		}
+		return faultinjection.Fault{}
+	})
+	var v map[string]interface{}
+	c.GetResource(ctx, dev.ID(), "/light/1", &v)
+	h.waitFor(ctx, t, "disconnect")
+	h.waitFor(ctx, t, "reconnect")
+	// the representation didn't change during the reconnection
+	h.requireNoEvent(t)
+
+	err = c.UpdateResource(ctx, dev.ID(), "/light/1", map[string]interface{}{"power": uint64(3)}, nil)
+	require.NoError(t, err)
+	h.waitFor(ctx, t, "power 3")
+
+	err = c.StopObservingResource(ctx, id)
+	require.NoError(t, err)
+	h.requireNoEvent(t)
+}
+
+func TestObservingResourceStale(t *testing.T) {
+	dev, _ := newObservableDevice(t, time.Second)
+	defer dev.Close()
+	c := newObservingClient(t, dev)
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	h := &eventObservationHandler{events: make(chan string, 16)}
+	id, err := c.ObserveResource(ctx, dev.ID(), "/light/1", h)
+	require.NoError(t, err)
+	h.waitFor(ctx, t, "power 0")
+	h.waitFor(ctx, t, "stale")
+
+	// the next notification restarts the Max-Age
+	err = c.UpdateResource(ctx, dev.ID(), "/light/1", map[string]interface{}{"power": uint64(1)}, nil)
+	require.NoError(t, err)
+	h.waitFor(ctx, t, "power 1")
+	h.waitFor(ctx, t, "stale")
+
+	err = c.StopObservingResource(ctx, id)
+	require.NoError(t, err)
+	select {
+	case e := <-h.events:
+		require.Fail(t, "unexpected event", e)
+	case <-time.After(time.Millisecond * 1500):
+	}
+}
+
+func TestObservingResourceFilter(t *testing.T) {
+	dev, l := newObservableDevice(t, 0)
+	defer dev.Close()
+	c := newObservingClient(t, dev)
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	observe := func(power uint64, opts ...local.ObserveOption) *eventObservationHandler {
+		h := &eventObservationHandler{events: make(chan string, 16)}
+		id, err := c.ObserveResource(ctx, dev.ID(), "/light/1", h, opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			c.StopObservingResource(context.Background(), id)
+		})
+		h.waitFor(ctx, t, fmt.Sprintf("power %v", power))
+		return h
+	}
+	update := func(power uint64) {
+		err := c.UpdateResource(ctx, dev.ID(), "/light/1", map[string]interface{}{"power": power}, nil)
+		require.NoError(t, err)
+	}
+	dedup := observe(0, local.WithDeduplication())
+	threshold := observe(0, local.WithChangeThreshold(10, "power"))
+	interval := observe(0, local.WithMinimumNotificationInterval(time.Second))
+	require.Empty(t, l.getQueries())
+
+	update(0)
+	update(5)
+	dedup.waitFor(ctx, t, "power 5")
+	update(12)
+	dedup.waitFor(ctx, t, "power 12")
+	threshold.waitFor(ctx, t, "power 12")
+	// notifications within the interval are replaced by the latest one
+	for {
+		select {
+		case e := <-interval.events:
+			require.NotEqual(t, "power 5", e)
+			if e != "power 12" {
+				continue
+			}
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+		break
+	}
+	dedup.requireNoEvent(t)
+	threshold.requireNoEvent(t)
+	interval.requireNoEvent(t)
+
+	// conditional attributes are sent to the device by the separate registration
+	observe(12, local.WithConditionalObserve(), local.WithMinimumNotificationInterval(time.Second*2), local.WithChangeThreshold(0.5))
+	require.ElementsMatch(t, []string{"pmin=2", "st=0.5"}, l.getQueries())
+}
+
+func TestObservingResourceFilterClosed(t *testing.T) {
+	dev, _ := newObservableDevice(t, 0)
+	defer dev.Close()
+	d := faultinjection.NewDialer()
+	defer d.Close()
+	c := newObservingClient(t, dev, core.WithDialUDP(d.DialUDP))
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	h := &eventObservationHandler{events: make(chan string, 16)}
+	_, err := c.ObserveResource(ctx, dev.ID(), "/light/1", h, local.WithMinimumNotificationInterval(time.Second))
+	require.NoError(t, err)
+	h.waitFor(ctx, t, "power 0")
+
+	// the notification within the interval is pending
+	err = c.UpdateResource(ctx, dev.ID(), "/light/1", map[string]interface{}{"power": uint64(1)}, nil)
+	require.NoError(t, err)
+
+	// the connection is closed by the next request
+	var closed int32
+	d.SetRule(func(p faultinjection.Packet) faultinjection.Fault {
+		if p.Direction == faultinjection.ToDevice && atomic.CompareAndSwapInt32(&closed, 0, 1) {
+			return faultinjection.Fault{Action: faultinjection.Close}
+		}
+		return faultinjection.Fault{}
+	})
+	var v map[string]interface{}
+	c.GetResource(ctx, dev.ID(), "/light/1", &v)
+	h.waitFor(ctx, t, "close")
+	// the pending notification is not delivered after the close
+	select {
+	case e := <-h.events:
+		require.Fail(t, "unexpected event", e)
+	case <-time.After(time.Millisecond * 1500):
+	}
+}
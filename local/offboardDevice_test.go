@@ -10,7 +10,8 @@ import (
 )
 
 func TestClient_OffboardDevice(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	deviceID := dev.ID()
 	type args struct {
 		deviceID string
 	}
@@ -34,6 +35,7 @@ func TestClient_OffboardDevice(t *testing.T) {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	StoreTestDevices(t, c, dev)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
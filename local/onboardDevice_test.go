@@ -11,7 +11,8 @@ import (
 )
 
 func TestClient_OnboardDevice(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestSecureDeviceName)
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	deviceID := dev.ID()
 	type args struct {
 		deviceID              string
 		authorizationProvider string
@@ -42,6 +43,7 @@ func TestClient_OnboardDevice(t *testing.T) {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	StoreTestDevices(t, c, dev)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
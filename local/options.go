@@ -2,13 +2,14 @@ package local
 
 import (
 	"context"
+	"time"
 
 	"github.com/plgd-dev/sdk/local/core"
 	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
 	"github.com/plgd-dev/sdk/schema"
 )
 
-// WithInterface updates/gets resource with interface directly from a device.
+// WithInterface updates/gets/deletes resource with interface directly from a device.
 func WithInterface(resourceInterface string) ResourceInterfaceOption {
 	return ResourceInterfaceOption{
 		resourceInterface: resourceInterface,
@@ -39,6 +40,98 @@ func WithResourceTypes(resourceTypes ...string) ResourceTypesOption {
 	}
 }
 
+// WithScanConcurrency allows to set how many addresses are probed at once by ScanDevices, by default it is 32.
+func WithScanConcurrency(concurrency int) ScanDevicesOption {
+	return scanConcurrencyOption{
+		concurrency: concurrency,
+	}
+}
+
+// WithScanRate allows to set how many probes per second are started by ScanDevices, by default it is 100.
+func WithScanRate(probesPerSecond int) ScanDevicesOption {
+	return scanRateOption{
+		rate: probesPerSecond,
+	}
+}
+
+// WithScanProbeTimeout allows to set how long ScanDevices waits for the device at one address, by default it is 2 seconds.
+func WithScanProbeTimeout(timeout time.Duration) ScanDevicesOption {
+	return scanProbeTimeoutOption{
+		timeout: timeout,
+	}
+}
+
+// WithPresence allows ObserveDevices to observe /oic/res via the multicast, so devices which support
+// the observation are reported as ONLINE without waiting for the next poll. Polls are only the fallback then,
+// they run every third polling interval, so offline devices are detected later.
+func WithPresence() ObserveDevicesOption {
+	return presenceOption{}
+}
+
+// WithOwnershipChanges allows ObserveDevices to get the ownership of devices by each poll, so changes of the ownership
+// are reported by UPDATED events. It costs an additional multicast request per poll.
+func WithOwnershipChanges() ObserveDevicesOption {
+	return ownershipChangesOption{}
+}
+
+// WithOfflineAfterMissedPolls allows to set how many consecutive polls the device must miss
+// before ObserveDevices reports it as OFFLINE, by default it is 1.
+func WithOfflineAfterMissedPolls(missedPolls int) ObserveDevicesOption {
+	return offlineAfterMissedPollsOption{
+		missedPolls: missedPolls,
+	}
+}
+
+// WithResourceDirectories allows GetDevices to get devices also from resource directories, e.g. "192.168.1.1:5683".
+// Devices published to a resource directory are not contacted, so their details and ownership could be unknown.
+func WithResourceDirectories(addrs ...string) ResourceDirectoriesOption {
+	return ResourceDirectoriesOption{
+		addrs: addrs,
+	}
+}
+
+// WithResilientObservation allows ObserveResource to survive the loss of the connection. The observation is registered
+// again via any endpoint of the device with the exponential backoff from minBackoff to maxBackoff and duplicate
+// notifications are dropped. Handlers which implement ResilientObservationHandler are told about the disconnection
+// and the reconnection.
+func WithResilientObservation(minBackoff, maxBackoff time.Duration) ObserveOption {
+	return resilientObservationOption{
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// WithMinimumNotificationInterval allows to deliver notifications of ObserveResource at most once per interval,
+// the latest notification received during the interval is delivered at its end.
+func WithMinimumNotificationInterval(interval time.Duration) ObserveOption {
+	return minimumNotificationIntervalOption{
+		interval: interval,
+	}
+}
+
+// WithChangeThreshold allows ObserveResource to skip notifications in which numeric properties changed by less
+// than the threshold since the last delivered notification, by default all numeric properties are compared.
+// Changes of other properties are always delivered.
+func WithChangeThreshold(threshold float64, properties ...string) ObserveOption {
+	return changeThresholdOption{
+		threshold:  threshold,
+		properties: properties,
+	}
+}
+
+// WithDeduplication allows ObserveResource to skip notifications with the same payload as the last delivered one.
+func WithDeduplication() ObserveOption {
+	return deduplicationOption{}
+}
+
+// WithConditionalObserve allows ObserveResource to send the minimum interval and the change threshold
+// to the device as CoRE conditional attributes pmin and st, so the device doesn't send filtered notifications.
+// OCF doesn't define how the device advertises their support, so use it only for devices which support them.
+// The threshold of selected properties is not sent.
+func WithConditionalObserve() ObserveOption {
+	return conditionalObserveOption{}
+}
+
 // WithActionDuringOwn allows to set deviceID of owned device and other staffo over owner TLS.
 func WithActionDuringOwn(actionDuringOwn func(ctx context.Context, client *kitNetCoap.ClientCloseHandler) (string, error)) OwnOption {
 	return actionDuringOwnOption{
@@ -46,6 +139,21 @@ func WithActionDuringOwn(actionDuringOwn func(ctx context.Context, client *kitNe
 	}
 }
 
+// WithOwnerACLPolicy allows to set the access control list installed to the device during the ownership transfer,
+// by default core.DefaultOwnerACLPolicy is used.
+func WithOwnerACLPolicy(ownerACLPolicy core.OwnerACLPolicyFunc) OwnOption {
+	return ownerACLPolicyOption{
+		ownerACLPolicy: ownerACLPolicy,
+	}
+}
+
+// WithOwnFailurePolicy allows to set what happens with the device when the ownership transfer fails, by default it is rollback.
+func WithOwnFailurePolicy(policy core.OwnFailurePolicy) OwnOption {
+	return ownFailurePolicyOption{
+		policy: policy,
+	}
+}
+
 // WithOTM allows to set ownership transfer method, by default it is manufacturer.
 func WithOTM(otmType OTMType) OwnOption {
 	return otmOption{
@@ -71,6 +179,13 @@ func (r ResourceInterfaceOption) applyOnUpdate(opts updateOptions) updateOptions
 	return opts
 }
 
+func (r ResourceInterfaceOption) applyOnDelete(opts deleteOptions) deleteOptions {
+	if r.resourceInterface != "" {
+		opts.opts = append(opts.opts, kitNetCoap.WithInterface(r.resourceInterface))
+	}
+	return opts
+}
+
 type DiscoveryConfigrationOption struct {
 	cfg core.DiscoveryConfiguration
 }
@@ -122,6 +237,26 @@ type UpdateOption = interface {
 	applyOnUpdate(opts updateOptions) updateOptions
 }
 
+type deleteOptions struct {
+	opts  []kitNetCoap.OptionFunc
+	codec kitNetCoap.Codec
+}
+
+// DeleteOption option definition.
+type DeleteOption = interface {
+	applyOnDelete(opts deleteOptions) deleteOptions
+}
+
+type createOptions struct {
+	opts  []kitNetCoap.OptionFunc
+	codec kitNetCoap.Codec
+}
+
+// CreateOption option definition.
+type CreateOption = interface {
+	applyOnCreate(opts createOptions) createOptions
+}
+
 // GetDevicesOption option definition.
 type GetDevicesOption = interface {
 	applyOnGetDevices(opts getDevicesOptions) getDevicesOptions
@@ -145,6 +280,14 @@ type ObserveDevicesOption = interface {
 	applyOnObserveDevices(opts observeDevicesOptions) observeDevicesOptions
 }
 
+type DeviceOwnershipSDKOption = interface {
+	applyOnDeviceOwnershipSDK(opts deviceOwnershipSDKOptions) deviceOwnershipSDKOptions
+}
+
+type deviceOwnershipSDKOptions struct {
+	err func(error)
+}
+
 type ErrorOption struct {
 	err func(error)
 }
@@ -154,6 +297,18 @@ func (r ErrorOption) applyOnGetDevices(opts getDevicesOptions) getDevicesOptions
 	return opts
 }
 
+func (r ErrorOption) applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions {
+	opts.err = r.err
+	return opts
+}
+
+func (r ErrorOption) applyOnDeviceOwnershipSDK(opts deviceOwnershipSDKOptions) deviceOwnershipSDKOptions {
+	if r.err != nil {
+		opts.err = r.err
+	}
+	return opts
+}
+
 type GetDetailsFunc = func(context.Context, *core.Device, schema.ResourceLinks) (interface{}, error)
 
 type GetDetailsOption struct {
@@ -175,11 +330,26 @@ func (r GetDetailsOption) applyOnGetDeviceByIP(opts getDevicesOptions) getDevice
 	return opts
 }
 
+func (r GetDetailsOption) applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions {
+	opts.getDetails = r.getDetails
+	return opts
+}
+
 type getDevicesOptions struct {
 	resourceTypes          []string
 	err                    func(error)
 	getDetails             GetDetailsFunc
 	discoveryConfiguration core.DiscoveryConfiguration
+	resourceDirectories    []string
+}
+
+type ResourceDirectoriesOption struct {
+	addrs []string
+}
+
+func (r ResourceDirectoriesOption) applyOnGetDevices(opts getDevicesOptions) getDevicesOptions {
+	opts.resourceDirectories = r.addrs
+	return opts
 }
 
 type getDeviceOptions struct {
@@ -196,7 +366,33 @@ type getDevicesWithHandlerOptions struct {
 }
 
 type observeDevicesOptions struct {
-	discoveryConfiguration core.DiscoveryConfiguration
+	discoveryConfiguration  core.DiscoveryConfiguration
+	presence                bool
+	ownershipChanges        bool
+	offlineAfterMissedPolls int
+}
+
+type presenceOption struct{}
+
+func (r presenceOption) applyOnObserveDevices(opts observeDevicesOptions) observeDevicesOptions {
+	opts.presence = true
+	return opts
+}
+
+type ownershipChangesOption struct{}
+
+func (r ownershipChangesOption) applyOnObserveDevices(opts observeDevicesOptions) observeDevicesOptions {
+	opts.ownershipChanges = true
+	return opts
+}
+
+type offlineAfterMissedPollsOption struct {
+	missedPolls int
+}
+
+func (r offlineAfterMissedPollsOption) applyOnObserveDevices(opts observeDevicesOptions) observeDevicesOptions {
+	opts.offlineAfterMissedPolls = r.missedPolls
+	return opts
 }
 
 type ResourceTypesOption struct {
@@ -208,6 +404,52 @@ func (r ResourceTypesOption) applyOnGetDevices(opts getDevicesOptions) getDevice
 	return opts
 }
 
+func (r ResourceTypesOption) applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions {
+	opts.resourceTypes = r.resourceTypes
+	return opts
+}
+
+// ScanDevicesOption option definition.
+type ScanDevicesOption = interface {
+	applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions
+}
+
+type scanDevicesOptions struct {
+	resourceTypes []string
+	err           func(error)
+	getDetails    GetDetailsFunc
+	concurrency   int
+	rate          int
+	probeTimeout  time.Duration
+}
+
+type scanConcurrencyOption struct {
+	concurrency int
+}
+
+func (r scanConcurrencyOption) applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions {
+	opts.concurrency = r.concurrency
+	return opts
+}
+
+type scanRateOption struct {
+	rate int
+}
+
+func (r scanRateOption) applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions {
+	opts.rate = r.rate
+	return opts
+}
+
+type scanProbeTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (r scanProbeTimeoutOption) applyOnScanDevices(opts scanDevicesOptions) scanDevicesOptions {
+	opts.probeTimeout = r.timeout
+	return opts
+}
+
 type CodecOption struct {
 	codec kitNetCoap.Codec
 }
@@ -222,13 +464,82 @@ func (r CodecOption) applyOnUpdate(opts updateOptions) updateOptions {
 	return opts
 }
 
+func (r CodecOption) applyOnDelete(opts deleteOptions) deleteOptions {
+	opts.codec = r.codec
+	return opts
+}
+
+func (r CodecOption) applyOnCreate(opts createOptions) createOptions {
+	opts.codec = r.codec
+	return opts
+}
+
 func (r CodecOption) applyOnObserve(opts observeOptions) observeOptions {
 	opts.codec = r.codec
 	return opts
 }
 
 type observeOptions struct {
-	codec kitNetCoap.Codec
+	codec       kitNetCoap.Codec
+	resilient   *resilientObservation
+	filter      notificationFilter
+	conditional bool
+}
+
+type minimumNotificationIntervalOption struct {
+	interval time.Duration
+}
+
+func (r minimumNotificationIntervalOption) applyOnObserve(opts observeOptions) observeOptions {
+	opts.filter.minInterval = r.interval
+	return opts
+}
+
+type changeThresholdOption struct {
+	threshold  float64
+	properties []string
+}
+
+func (r changeThresholdOption) applyOnObserve(opts observeOptions) observeOptions {
+	opts.filter.hasThreshold = true
+	opts.filter.threshold = r.threshold
+	opts.filter.thresholdProperties = r.properties
+	return opts
+}
+
+type deduplicationOption struct{}
+
+func (r deduplicationOption) applyOnObserve(opts observeOptions) observeOptions {
+	opts.filter.deduplicate = true
+	return opts
+}
+
+type conditionalObserveOption struct{}
+
+func (r conditionalObserveOption) applyOnObserve(opts observeOptions) observeOptions {
+	opts.conditional = true
+	return opts
+}
+
+type resilientObservationOption struct {
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func (r resilientObservationOption) applyOnObserve(opts observeOptions) observeOptions {
+	minBackoff := r.minBackoff
+	if minBackoff <= 0 {
+		minBackoff = time.Second
+	}
+	maxBackoff := r.maxBackoff
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	opts.resilient = &resilientObservation{
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}
+	return opts
 }
 
 // ObserveOption option definition.
@@ -241,6 +552,11 @@ type OTMType int
 const (
 	OTMType_Manufacturer OTMType = 0
 	OTMType_JustWorks    OTMType = 1
+	// OTMType_RandomPin requires the ApplicationCallback which implements RandomPINApplicationCallback.
+	// Limitation: the DTLS handshake offers only TLS_PSK_* cipher suites instead of TLS_ECDHE_PSK_WITH_AES_128_CBC_SHA256
+	// required by OCF, because pion/dtls doesn't implement ECDHE_PSK. So devices which conform to OCF and offer only
+	// the ECDHE_PSK suite can't be owned by this method, see randompin.CipherSuites.
+	OTMType_RandomPin OTMType = 2
 )
 
 type ownOptions struct {
@@ -262,6 +578,24 @@ func (r actionDuringOwnOption) applyOnOwn(opts ownOptions) ownOptions {
 	return opts
 }
 
+type ownerACLPolicyOption struct {
+	ownerACLPolicy core.OwnerACLPolicyFunc
+}
+
+func (r ownerACLPolicyOption) applyOnOwn(opts ownOptions) ownOptions {
+	opts.opts = append(opts.opts, core.WithOwnerACLPolicy(r.ownerACLPolicy))
+	return opts
+}
+
+type ownFailurePolicyOption struct {
+	policy core.OwnFailurePolicy
+}
+
+func (r ownFailurePolicyOption) applyOnOwn(opts ownOptions) ownOptions {
+	opts.opts = append(opts.opts, core.WithOwnFailurePolicy(r.policy))
+	return opts
+}
+
 type otmOption struct {
 	otmType OTMType
 }
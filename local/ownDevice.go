@@ -6,6 +6,9 @@ import (
 	"github.com/plgd-dev/sdk/local/core"
 )
 
+// OwnDevice transfers the ownership of the device to the client and returns the ID of the owned device. When the transfer
+// fails after the device ID was changed by the action during own, the new ID is returned with the error, so the transfer
+// can be resumed by it.
 func (c *Client) OwnDevice(ctx context.Context, deviceID string, opts ...OwnOption) (string, error) {
 	cfg := ownOptions{
 		otmType: OTMType_Manufacturer,
@@ -51,23 +54,48 @@ func (c *Client) ownDeviceWithSigners(ctx context.Context, deviceID string, otmC
 		links = patchResourceLinksEndpoints(links, false)
 	}
 
+	journal := c.getOwnJournal(deviceID)
+	opts = append(opts, core.WithOwnJournal(journal))
 	err = d.Own(ctx, links, otmClient, opts...)
-	if err != nil {
-		return "", err
-	}
-
 	if d.DeviceID() != deviceID {
+		// the device is known by the new ID even when a later step failed, so the transfer can be resumed by it
+		c.ownJournals.Delete(deviceID)
+		c.ownJournals.Store(d.DeviceID(), journal)
+		c.moveDeviceRecord(ctx, deviceID, d.DeviceID())
 		c.deviceCache.RemoveDevice(ctx, deviceID, d)
-		tmp, stored, err := c.deviceCache.TryStoreDeviceToTemporaryCache(d)
-		if err != nil {
-			return d.DeviceID(), nil
+		tmp, stored, errCache := c.deviceCache.TryStoreDeviceToTemporaryCache(d)
+		if errCache == nil {
+			if stored {
+				d.Acquire()
+			} else {
+				tmp.Release(ctx)
+			}
 		}
-		if stored {
-			d.Acquire()
-		} else {
-			tmp.Release(ctx)
+	}
+	if err != nil {
+		if d.DeviceID() != deviceID {
+			return d.DeviceID(), err
 		}
+		return "", err
 	}
 
+	ownerID, _ := c.client.GetSdkOwnerID()
+	c.storeOwnershipStatus(ctx, d.DeviceID(), OwnershipStatus_Owned, ownerID)
 	return d.DeviceID(), nil
 }
+
+func (c *Client) getOwnJournal(deviceID string) *core.OwnJournal {
+	v, _ := c.ownJournals.LoadOrStoreWithFunc(deviceID, nil, func() interface{} {
+		return core.NewOwnJournal()
+	})
+	return v.(*core.OwnJournal)
+}
+
+// GetOwnJournal returns the journal of the last ownership transfer of the device made by the client.
+func (c *Client) GetOwnJournal(deviceID string) (*core.OwnJournal, bool) {
+	v, ok := c.ownJournals.Load(deviceID)
+	if !ok {
+		return nil, false
+	}
+	return v.(*core.OwnJournal), true
+}
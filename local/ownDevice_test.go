@@ -2,61 +2,60 @@ package local_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/gofrs/uuid"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
 	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
 	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/schema/acl"
+	"github.com/plgd-dev/sdk/server"
 	"github.com/plgd-dev/sdk/test"
 	"github.com/stretchr/testify/require"
 )
 
 func TestClient_OwnDevice(t *testing.T) {
-	type args struct {
-		deviceName string
-	}
 	tests := []struct {
 		name    string
-		args    args
 		wantErr bool
 	}{
 		{
 			name: "valid",
-			args: args{
-				deviceName: test.TestSecureDeviceName,
-			},
 		},
 	}
 
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
 	c, err := NewTestSecureClient()
 	require.NoError(t, err)
 	defer func() {
 		err := c.Close(context.Background())
 		require.NoError(t, err)
 	}()
+	StoreTestDevices(t, c, dev)
+	discoveryConfiguration := local.WithDiscoveryConfigration(dev.DiscoveryConfiguration())
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 			defer cancel()
-			deviceID, err := test.FindDeviceByName(ctx, tt.args.deviceName)
-			require.NoError(t, err)
-			deviceID, err = c.OwnDevice(ctx, deviceID)
+			deviceID, err := c.OwnDevice(ctx, dev.ID())
 			if tt.wantErr {
 				require.Error(t, err)
 				return
 			}
 			require.NoError(t, err)
-			device1, err := c.GetDeviceByMulticast(ctx, deviceID)
+			device1, err := c.GetDeviceByMulticast(ctx, deviceID, discoveryConfiguration)
 			require.NoError(t, err)
 			require.Equal(t, device1.OwnershipStatus, local.OwnershipStatus_Owned)
 			err = c.DisownDevice(ctx, deviceID)
 			require.NoError(t, err)
-			deviceID, err = test.FindDeviceByName(ctx, tt.args.deviceName)
-			require.NoError(t, err)
-			deviceID, err = c.OwnDevice(ctx, deviceID)
+			deviceID, err = c.OwnDevice(ctx, dev.ID())
 			require.NoError(t, err)
-			device2, err := c.GetDeviceByMulticast(ctx, deviceID)
+			device2, err := c.GetDeviceByMulticast(ctx, deviceID, discoveryConfiguration)
 			require.NoError(t, err)
 			require.Equal(t, device1.Details.(*schema.Device).ProtocolIndependentID, device2.Details.(*schema.Device).ProtocolIndependentID)
 			require.Equal(t, device1.OwnershipStatus, local.OwnershipStatus_Owned)
@@ -66,3 +65,162 @@ func TestClient_OwnDevice(t *testing.T) {
 	}
 
 }
+
+func TestClient_GetOwnJournal(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName), test.WithFailure(func(method codes.Code, href string) error {
+		if method == codes.DELETE && href == "/oic/sec/acl2" {
+			return server.NewError(codes.ServiceUnavailable, fmt.Errorf("injected failure"))
+		}
+		return nil
+	}))
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	_, ok := c.GetOwnJournal(dev.ID())
+	require.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err = c.OwnDevice(ctx, dev.ID(), local.WithOwnFailurePolicy(core.OwnFailurePolicy_Resume))
+	require.Error(t, err)
+	journal, ok := c.GetOwnJournal(dev.ID())
+	require.True(t, ok)
+	require.True(t, journal.IsResumable())
+	entries := journal.Entries()
+	require.Equal(t, core.OwnStep_SetAccessControl, entries[len(entries)-1].Step)
+	require.Error(t, entries[len(entries)-1].Err)
+
+	dev.SetFailure(nil)
+	deviceID, err := c.OwnDevice(ctx, dev.ID(), local.WithOwnFailurePolicy(core.OwnFailurePolicy_Resume))
+	require.NoError(t, err)
+	journal, ok = c.GetOwnJournal(deviceID)
+	require.True(t, ok)
+	require.True(t, journal.Completed())
+	err = c.DisownDevice(ctx, deviceID)
+	require.NoError(t, err)
+}
+
+func TestClient_OwnDeviceResumeWithChangedID(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName), test.WithFailure(func(method codes.Code, href string) error {
+		if method == codes.DELETE && href == "/oic/sec/acl2" {
+			return server.NewError(codes.ServiceUnavailable, fmt.Errorf("injected failure"))
+		}
+		return nil
+	}))
+	deviceID := dev.ID()
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	store := StoreTestDevices(t, c, dev)
+
+	id, err := uuid.NewV4()
+	require.NoError(t, err)
+	newDeviceID := id.String()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	gotID, err := c.OwnDevice(ctx, deviceID, local.WithOwnFailurePolicy(core.OwnFailurePolicy_Resume),
+		local.WithActionDuringOwn(func(ctx context.Context, client *kitNetCoap.ClientCloseHandler) (string, error) {
+			err := client.UpdateResource(ctx, schema.DoxmHref, schema.DoxmUpdate{DeviceID: newDeviceID}, nil)
+			return newDeviceID, err
+		}))
+	require.Error(t, err)
+	require.Equal(t, newDeviceID, gotID)
+	require.Equal(t, newDeviceID, dev.ID())
+
+	// the journal is moved to the new ID by the failed transfer
+	_, ok := c.GetOwnJournal(deviceID)
+	require.False(t, ok)
+	journal, ok := c.GetOwnJournal(newDeviceID)
+	require.True(t, ok)
+	require.True(t, journal.IsResumable())
+
+	// the stored record is moved to the new ID
+	_, ok, err = store.Load(ctx, deviceID)
+	require.NoError(t, err)
+	require.False(t, ok)
+	r, ok, err := store.Load(ctx, newDeviceID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, dev.Endpoints(), r.Endpoints)
+
+	dev.SetFailure(nil)
+	gotID, err = c.OwnDevice(ctx, newDeviceID, local.WithOwnFailurePolicy(core.OwnFailurePolicy_Resume))
+	require.NoError(t, err)
+	require.Equal(t, newDeviceID, gotID)
+	resumed, ok := c.GetOwnJournal(newDeviceID)
+	require.True(t, ok)
+	require.Same(t, journal, resumed)
+	require.True(t, journal.Completed())
+	var selected int
+	for _, e := range journal.Entries() {
+		if e.Step == core.OwnStep_SelectOTM {
+			selected++
+		}
+	}
+	require.Equal(t, 1, selected, "the transfer was started again: %+v", journal.Entries())
+
+	err = c.DisownDevice(ctx, newDeviceID)
+	require.NoError(t, err)
+}
+
+func TestClient_OwnDeviceWithOwnerACLPolicy(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	var policy acl.UpdateRequest
+	ownerACLPolicy := func(links schema.ResourceLinks, ownerID string) acl.UpdateRequest {
+		policy = acl.UpdateRequest{
+			AccessControlList: []acl.AccessControl{
+				{
+					Permission: acl.AllPermissions,
+					Subject: acl.Subject{
+						Subject_Device: &acl.Subject_Device{
+							DeviceID: ownerID,
+						},
+					},
+					Resources: acl.AllResources,
+				},
+				{
+					Permission: acl.Permission_READ,
+					Subject:    acl.TLSConnection,
+					Resources: []acl.Resource{
+						{
+							Href:       "/light/1",
+							Interfaces: []string{"*"},
+						},
+					},
+				},
+			},
+		}
+		return policy
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	deviceID, err := c.OwnDevice(ctx, dev.ID(), local.WithOwnerACLPolicy(ownerACLPolicy))
+	require.NoError(t, err)
+	require.NotEmpty(t, policy.AccessControlList)
+
+	got := dev.AccessControlList().AccessControlList
+	require.Len(t, got, len(policy.AccessControlList))
+	for i, ace := range policy.AccessControlList {
+		require.True(t, ace.Equal(got[i]), "%+v != %+v", ace, got[i])
+	}
+
+	err = c.DisownDevice(ctx, deviceID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,38 @@
+package local
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// provisionDevice switches the device to the provisioning state and calls update with the link of the resource identified by href.
+func (c *Client) provisionDevice(
+	ctx context.Context,
+	deviceID string,
+	href string,
+	update func(ctx context.Context, p *core.ProvisioningClient, link schema.ResourceLink) error,
+) error {
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetSecureResourceLink(links, href)
+	if err != nil {
+		return err
+	}
+	p, err := d.Provision(ctx, links)
+	if err != nil {
+		return err
+	}
+	err = update(ctx, p, link)
+	errClose := p.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot update resource %v of device %v: %w", href, deviceID, err)
+	}
+	return errClose
+}
@@ -2,6 +2,8 @@ package local
 
 import (
 	"context"
+	"crypto/x509"
+	"time"
 
 	"github.com/plgd-dev/kit/sync"
 	"github.com/plgd-dev/sdk/local/core"
@@ -102,6 +104,46 @@ func (d *RefDevice) UpdateResourceWithCodec(
 	return d.Device().UpdateResourceWithCodec(ctx, link, codec, request, response, options...)
 }
 
+func (d *RefDevice) DeleteResource(
+	ctx context.Context,
+	link schema.ResourceLink,
+	response interface{},
+	options ...coap.OptionFunc,
+) error {
+	return d.Device().DeleteResource(ctx, link, response, options...)
+}
+
+func (d *RefDevice) DeleteResourceWithCodec(
+	ctx context.Context,
+	link schema.ResourceLink,
+	codec coap.Codec,
+	response interface{},
+	options ...coap.OptionFunc,
+) error {
+	return d.Device().DeleteResourceWithCodec(ctx, link, codec, response, options...)
+}
+
+func (d *RefDevice) CreateResource(
+	ctx context.Context,
+	link schema.ResourceLink,
+	request interface{},
+	response interface{},
+	options ...coap.OptionFunc,
+) error {
+	return d.Device().CreateResource(ctx, link, request, response, options...)
+}
+
+func (d *RefDevice) CreateResourceWithCodec(
+	ctx context.Context,
+	link schema.ResourceLink,
+	codec coap.Codec,
+	request interface{},
+	response interface{},
+	options ...coap.OptionFunc,
+) error {
+	return d.Device().CreateResourceWithCodec(ctx, link, codec, request, response, options...)
+}
+
 func (d *RefDevice) Own(
 	ctx context.Context,
 	links schema.ResourceLinks,
@@ -130,6 +172,18 @@ func (d *RefDevice) GetResourceLinks(ctx context.Context, endpoints []schema.End
 	return d.Device().GetResourceLinks(ctx, endpoints, options...)
 }
 
+func (d *RefDevice) GetResourceLinksTree(ctx context.Context, links schema.ResourceLinks, options ...coap.OptionFunc) ([]*core.ResourceLinkNode, error) {
+	return d.Device().GetResourceLinksTree(ctx, links, options...)
+}
+
+func (d *RefDevice) GetIdentityCertificate(ctx context.Context, links schema.ResourceLinks) (*x509.Certificate, error) {
+	return d.Device().GetIdentityCertificate(ctx, links)
+}
+
+func (d *RefDevice) RenewIdentityCertificate(ctx context.Context, links schema.ResourceLinks, signer core.CertificateSigner) (time.Time, error) {
+	return d.Device().RenewIdentityCertificate(ctx, links, signer)
+}
+
 func (d *RefDevice) FactoryReset(ctx context.Context, links schema.ResourceLinks) error {
 	return d.Device().FactoryReset(ctx, links)
 }
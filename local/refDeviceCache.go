@@ -194,6 +194,42 @@ func (c *refDeviceCache) getPermanentCacheDevices() []*refCacheDevice {
 	return devices
 }
 
+func (c *refDeviceCache) getTemporaryCacheDevices() []*RefDevice {
+	c.temporaryCacheLock.Lock()
+	defer c.temporaryCacheLock.Unlock()
+	items := c.temporaryCache.Items()
+	devices := make([]*RefDevice, 0, len(items))
+	for _, val := range items {
+		d := val.Object.(*RefDevice)
+		d.Acquire()
+		devices = append(devices, d)
+	}
+	return devices
+}
+
+// CloseConnections closes pooled connections of all cached devices, requests in progress on them fail.
+func (c *refDeviceCache) CloseConnections(ctx context.Context) error {
+	var errors []error
+	for _, d := range c.getTemporaryCacheDevices() {
+		err := d.Device().CloseConnections()
+		if err != nil {
+			errors = append(errors, err)
+		}
+		d.Release(ctx)
+	}
+	for _, d := range c.getPermanentCacheDevices() {
+		err := d.device().Device().CloseConnections()
+		if err != nil {
+			errors = append(errors, err)
+		}
+		d.Release(ctx)
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("%v", errors)
+	}
+	return nil
+}
+
 func (c *refDeviceCache) Close(ctx context.Context) error {
 	var errors []error
 	for _, val := range c.popTemporaryCache() {
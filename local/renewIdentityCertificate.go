@@ -0,0 +1,193 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"go.uber.org/atomic"
+)
+
+// GetDeviceIdentityCertificateExpiry returns the expiration of the identity certificate of the owned device.
+func (c *Client) GetDeviceIdentityCertificateExpiry(ctx context.Context, deviceID string) (time.Time, error) {
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer d.Release(ctx)
+
+	cert, err := d.GetIdentityCertificate(ctx, links)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// RenewDeviceIdentityCertificate replaces the identity certificate of the owned device by a new one signed
+// by the device owner. It returns the expiration of the new certificate.
+func (c *Client) RenewDeviceIdentityCertificate(ctx context.Context, deviceID string) (time.Time, error) {
+	signer, err := c.deviceOwner.GetIdentityCertificateSigner(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot get identity certificate signer: %w", err)
+	}
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer d.Release(ctx)
+
+	return d.RenewIdentityCertificate(ctx, links, signer)
+}
+
+type IdentityCertificateRenewalHandler = interface {
+	// Handle is called when the identity certificate of the device was renewed.
+	Handle(ctx context.Context, deviceID string, notAfter time.Time)
+	OnClose()
+	// Error reports failures of devices, the renewal continues.
+	Error(err error)
+}
+
+type identityCertificateRenewer struct {
+	c                *Client
+	handler          IdentityCertificateRenewalHandler
+	renewBefore      time.Duration
+	discoveryTimeout time.Duration
+	opts             []GetDevicesOption
+
+	handlerMutex sync.Mutex
+	isClosed     atomic.Bool
+	cancel       context.CancelFunc
+	wait         func()
+}
+
+func newIdentityCertificateRenewer(ctx context.Context, c *Client, interval, renewBefore time.Duration, handler IdentityCertificateRenewalHandler, opts []GetDevicesOption) *identityCertificateRenewer {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	r := &identityCertificateRenewer{
+		c:                c,
+		handler:          handler,
+		renewBefore:      renewBefore,
+		discoveryTimeout: c.observerPollingInterval,
+		opts:             opts,
+
+		cancel: cancel,
+		wait:   wg.Wait,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			r.renew(ctx)
+			select {
+			case <-ctx.Done():
+				r.onClose()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return r
+}
+
+func (r *identityCertificateRenewer) getDevices(ctx context.Context) (map[string]DeviceDetails, error) {
+	// the discovery lasts until the context is done, so each tick has its own window
+	discoveryCtx, cancel := context.WithTimeout(ctx, r.discoveryTimeout)
+	defer cancel()
+	return r.c.GetDevices(discoveryCtx, r.opts...)
+}
+
+func (r *identityCertificateRenewer) renew(ctx context.Context) {
+	devices, err := r.getDevices(ctx)
+	if err != nil {
+		r.error(fmt.Errorf("cannot get devices for identity certificate renewal: %w", err))
+		return
+	}
+	for deviceID, d := range devices {
+		if ctx.Err() != nil {
+			return
+		}
+		if d.OwnershipStatus != OwnershipStatus_Owned {
+			continue
+		}
+		notAfter, err := r.c.GetDeviceIdentityCertificateExpiry(ctx, deviceID)
+		if err != nil {
+			r.error(fmt.Errorf("cannot get identity certificate of device %v: %w", deviceID, err))
+			continue
+		}
+		if time.Until(notAfter) > r.renewBefore {
+			continue
+		}
+		notAfter, err = r.c.RenewDeviceIdentityCertificate(ctx, deviceID)
+		if err != nil {
+			r.error(fmt.Errorf("cannot renew identity certificate of device %v: %w", deviceID, err))
+			continue
+		}
+		r.handle(ctx, deviceID, notAfter)
+	}
+}
+
+func (r *identityCertificateRenewer) handle(ctx context.Context, deviceID string, notAfter time.Time) {
+	r.handlerMutex.Lock()
+	defer r.handlerMutex.Unlock()
+	if r.isClosed.Load() {
+		return
+	}
+	r.handler.Handle(ctx, deviceID, notAfter)
+}
+
+func (r *identityCertificateRenewer) error(err error) {
+	r.handlerMutex.Lock()
+	defer r.handlerMutex.Unlock()
+	if r.isClosed.Load() {
+		return
+	}
+	r.handler.Error(err)
+}
+
+func (r *identityCertificateRenewer) onClose() {
+	r.handlerMutex.Lock()
+	defer r.handlerMutex.Unlock()
+	if r.isClosed.Load() {
+		return
+	}
+	r.isClosed.Store(true)
+	r.handler.OnClose()
+}
+
+func (r *identityCertificateRenewer) Cancel() {
+	r.cancel()
+}
+
+func (r *identityCertificateRenewer) Wait() {
+	r.wait()
+}
+
+// StartIdentityCertificateRenewal checks identity certificates of owned devices every interval and renews
+// certificates which expire within renewBefore. Devices are discovered by GetDevices with opts, the discovery
+// lasts the polling interval of the client. The renewal stops when ctx is done or by StopIdentityCertificateRenewal.
+func (c *Client) StartIdentityCertificateRenewal(ctx context.Context, interval, renewBefore time.Duration, handler IdentityCertificateRenewalHandler, opts ...GetDevicesOption) (string, error) {
+	if interval <= 0 {
+		return "", fmt.Errorf("invalid interval %v", interval)
+	}
+	ID, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	c.insertSubscription(ID.String(), newIdentityCertificateRenewer(ctx, c, interval, renewBefore, handler, opts))
+	return ID.String(), nil
+}
+
+// StopIdentityCertificateRenewal stops the renewal started by StartIdentityCertificateRenewal.
+func (c *Client) StopIdentityCertificateRenewal(ctx context.Context, renewalID string) error {
+	sub, err := c.popSubscription(renewalID)
+	if err != nil {
+		return err
+	}
+	sub.Cancel()
+	sub.Wait()
+	return nil
+}
@@ -0,0 +1,117 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RenewDeviceIdentityCertificate(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	deviceID := dev.ID()
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	deviceID, err = c.OwnDevice(ctx, deviceID)
+	require.NoError(t, err)
+	defer func() {
+		err := c.DisownDevice(ctx, deviceID)
+		require.NoError(t, err)
+	}()
+
+	oldNotAfter, err := c.GetDeviceIdentityCertificateExpiry(ctx, deviceID)
+	require.NoError(t, err)
+
+	notAfter, err := c.RenewDeviceIdentityCertificate(ctx, deviceID)
+	require.NoError(t, err)
+	require.False(t, notAfter.Before(oldNotAfter))
+
+	got, err := c.GetDeviceIdentityCertificateExpiry(ctx, deviceID)
+	require.NoError(t, err)
+	require.True(t, notAfter.Equal(got))
+
+	// the device is still accessible with the renewed certificate
+	_, err = c.GetCredentials(ctx, deviceID)
+	require.NoError(t, err)
+}
+
+type testRenewalHandler struct {
+	renewed chan time.Time
+	errors  chan error
+	closed  chan struct{}
+}
+
+func (h *testRenewalHandler) Handle(ctx context.Context, deviceID string, notAfter time.Time) {
+	h.renewed <- notAfter
+}
+
+func (h *testRenewalHandler) OnClose() {
+	close(h.closed)
+}
+
+func (h *testRenewalHandler) Error(err error) {
+	select {
+	case h.errors <- err:
+	default:
+	}
+}
+
+func TestClient_StartIdentityCertificateRenewal(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName))
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+	StoreTestDevices(t, c, dev)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	deviceID, err := c.OwnDevice(ctx, dev.ID())
+	require.NoError(t, err)
+	defer func() {
+		err := c.DisownDevice(ctx, deviceID)
+		require.NoError(t, err)
+	}()
+	oldNotAfter, err := c.GetDeviceIdentityCertificateExpiry(ctx, deviceID)
+	require.NoError(t, err)
+
+	h := &testRenewalHandler{
+		renewed: make(chan time.Time, 8),
+		errors:  make(chan error, 8),
+		closed:  make(chan struct{}),
+	}
+	// every certificate expires within renewBefore, so it is renewed by the first tick
+	renewalID, err := c.StartIdentityCertificateRenewal(context.Background(), time.Hour, time.Until(oldNotAfter)+time.Hour, h,
+		local.WithDiscoveryConfigration(dev.DiscoveryConfiguration()))
+	require.NoError(t, err)
+
+	var notAfter time.Time
+	select {
+	case notAfter = <-h.renewed:
+	case err := <-h.errors:
+		require.NoError(t, err)
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+	err = c.StopIdentityCertificateRenewal(ctx, renewalID)
+	require.NoError(t, err)
+	<-h.closed
+
+	require.False(t, notAfter.Before(oldNotAfter))
+	got, err := c.GetDeviceIdentityCertificateExpiry(ctx, deviceID)
+	require.NoError(t, err)
+	require.True(t, notAfter.Equal(got))
+}
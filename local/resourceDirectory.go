@@ -0,0 +1,95 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	kitStrings "github.com/plgd-dev/kit/strings"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// GetResourceDirectoryLinks gets links which devices published to the resource directory at addr, e.g. "192.168.1.1:5683".
+func (c *Client) GetResourceDirectoryLinks(ctx context.Context, addr string) (schema.ResourceLinks, error) {
+	links, err := c.client.GetResourceDirectoryLinks(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return patchResourceLinksEndpoints(links, c.disableUDPEndpoints), nil
+}
+
+// resourceDirectoryDevices groups links by devices. The device is not contacted, because it could sleep,
+// so Details is nil and the ownership is unknown.
+func resourceDirectoryDevices(links schema.ResourceLinks, typeFilter []string) []DeviceDetails {
+	byDevice := make(map[string]schema.ResourceLinks)
+	for _, link := range links {
+		deviceID := link.GetDeviceID()
+		if deviceID == "" {
+			continue
+		}
+		byDevice[deviceID] = append(byDevice[deviceID], link)
+	}
+	devices := make([]DeviceDetails, 0, len(byDevice))
+	for deviceID, deviceLinks := range byDevice {
+		link, ok := deviceLinks.GetResourceLink("/oic/d")
+		if !ok {
+			continue
+		}
+		deviceTypes := make(kitStrings.Set, len(link.ResourceTypes))
+		deviceTypes.Add(link.ResourceTypes...)
+		if !deviceTypes.HasOneOf(typeFilter...) {
+			continue
+		}
+		eps := link.GetEndpoints()
+		devices = append(devices, DeviceDetails{
+			ID:              deviceID,
+			IsSecured:       len(eps.FilterSecureEndpoints()) > 0,
+			Resources:       deviceLinks,
+			Endpoints:       eps,
+			OwnershipStatus: OwnershipStatus_Unknown,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+	return devices
+}
+
+// cacheResourceDirectoryDevice stores the device to the cache, so it is reachable by the device ID.
+func (c *Client) cacheResourceDirectoryDevice(ctx context.Context, d DeviceDetails) {
+	link, _ := schema.ResourceLinks(d.Resources).GetResourceLink("/oic/d")
+	newRefDev := NewRefDevice(c.client.NewDevice(d.ID, link.ResourceTypes, d.Endpoints))
+	refDev, stored, err := c.deviceCache.TryStoreDeviceToTemporaryCache(newRefDev)
+	if err != nil {
+		newRefDev.Release(ctx)
+		return
+	}
+	if !stored {
+		newRefDev.Release(ctx)
+	}
+	refDev.Release(ctx)
+}
+
+// getResourceDirectoriesDevices queries resource directories in parallel and reports their devices.
+func (c *Client) getResourceDirectoriesDevices(ctx context.Context, addrs []string, typeFilter []string, errors func(error), devices func(DeviceDetails)) {
+	var wg sync.WaitGroup
+	wg.Add(len(addrs))
+	for _, addr := range addrs {
+		go func(addr string) {
+			defer wg.Done()
+			links, err := c.GetResourceDirectoryLinks(ctx, addr)
+			if err != nil {
+				if errors != nil {
+					errors(fmt.Errorf("cannot get devices from resource directory: %w", err))
+				}
+				return
+			}
+			for _, d := range resourceDirectoryDevices(links, typeFilter) {
+				c.cacheResourceDirectoryDevice(ctx, d)
+				devices(d)
+			}
+		}(addr)
+	}
+	wg.Wait()
+}
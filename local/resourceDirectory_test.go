@@ -0,0 +1,125 @@
+package local_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/udp"
+	"github.com/plgd-dev/kit/codec/cbor"
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRDSecureDeviceID = "00000000-0000-0000-0000-000000000011"
+	testRDDeviceID       = "00000000-0000-0000-0000-000000000012"
+)
+
+func testRDLinks() schema.ResourceLinks {
+	secureEps := schema.Endpoints{{URI: "coaps://127.0.0.1:40001"}, {URI: "coap://127.0.0.1:40000"}}
+	eps := schema.Endpoints{{URI: "coap://127.0.0.1:40002"}}
+	return schema.ResourceLinks{
+		{Href: "/oic/d", ResourceTypes: []string{"oic.wk.d", "oic.d.light"}, Interfaces: []string{"oic.if.r", "oic.if.baseline"}, Anchor: "ocf://" + testRDSecureDeviceID, Endpoints: secureEps},
+		{Href: "/light/1", ResourceTypes: []string{"core.light"}, Interfaces: []string{"oic.if.rw", "oic.if.baseline"}, Anchor: "ocf://" + testRDSecureDeviceID, Endpoints: secureEps},
+		{Href: "/oic/d", ResourceTypes: []string{"oic.wk.d", "oic.d.sensor"}, Interfaces: []string{"oic.if.r", "oic.if.baseline"}, Anchor: "ocf://" + testRDDeviceID, Endpoints: eps},
+	}
+}
+
+// newTestResourceDirectory starts the resource directory stub which serves links at /oic/res.
+func newTestResourceDirectory(t *testing.T, links schema.ResourceLinks) (addr string, shutdown func()) {
+	data, err := cbor.Encode(links)
+	require.NoError(t, err)
+	router := mux.NewRouter()
+	err = router.Handle("/oic/res", mux.HandlerFunc(func(w mux.ResponseWriter, r *mux.Message) {
+		err := w.SetResponse(codes.Content, message.AppOcfCbor, bytes.NewReader(data))
+		require.NoError(t, err)
+	}))
+	require.NoError(t, err)
+
+	l, err := coapNet.NewListenUDP("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := udp.NewServer(udp.WithMux(router))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Serve(l)
+	}()
+	return l.LocalAddr().String(), func() {
+		s.Stop()
+		l.Close()
+		<-done
+	}
+}
+
+func TestClient_GetResourceDirectoryLinks(t *testing.T) {
+	addr, shutdown := newTestResourceDirectory(t, testRDLinks())
+	defer shutdown()
+	c := NewTestClient()
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	links, err := c.GetResourceDirectoryLinks(ctx, addr)
+	require.NoError(t, err)
+	require.Len(t, links, 3)
+	for _, l := range links {
+		require.NotEmpty(t, l.DeviceID)
+	}
+}
+
+func TestClient_GetDevicesFromResourceDirectory(t *testing.T) {
+	addr, shutdown := newTestResourceDirectory(t, testRDLinks())
+	defer shutdown()
+	c := NewTestClient()
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+
+	tests := []struct {
+		name string
+		opts []local.GetDevicesOption
+		want []string
+	}{
+		{
+			name: "all",
+			want: []string{testRDSecureDeviceID, testRDDeviceID},
+		},
+		{
+			name: "filter",
+			opts: []local.GetDevicesOption{local.WithResourceTypes("oic.d.sensor")},
+			want: []string{testRDDeviceID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			opts := append([]local.GetDevicesOption{
+				local.WithDiscoveryConfigration(core.DiscoveryConfiguration{}),
+				local.WithResourceDirectories(addr),
+			}, tt.opts...)
+			devices, err := c.GetDevices(ctx, opts...)
+			require.NoError(t, err)
+			require.Len(t, devices, len(tt.want))
+			for _, deviceID := range tt.want {
+				d, ok := devices[deviceID]
+				require.True(t, ok)
+				require.Equal(t, deviceID == testRDSecureDeviceID, d.IsSecured)
+				require.Equal(t, local.OwnershipStatus_Unknown, d.OwnershipStatus)
+				require.NotEmpty(t, d.Endpoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// maxScanHostBits limits the size of one scanned network to 65536 addresses.
+const maxScanHostBits = 16
+
+// parseScanNetworks parses networks in the CIDR notation, a single IP address is accepted as well.
+func parseScanNetworks(cidrs []string) ([]*net.IPNet, error) {
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("empty networks")
+	}
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if ip := net.ParseIP(cidr); ip != nil {
+			bits := net.IPv6len * 8
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+				bits = net.IPv4len * 8
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %v: %w", cidr, err)
+		}
+		ones, bits := network.Mask.Size()
+		if bits-ones > maxScanHostBits {
+			return nil, fmt.Errorf("network %v is too large: at most %v host bits are supported", cidr, maxScanHostBits)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// forEachScanAddress calls fn for every host address of networks until fn returns false.
+// The network and the broadcast addresses of IPv4 networks are skipped.
+func forEachScanAddress(networks []*net.IPNet, fn func(ip string) bool) {
+	for _, network := range networks {
+		ones, bits := network.Mask.Size()
+		skipEdges := bits == net.IPv4len*8 && bits-ones >= 2
+		ip := network.IP.Mask(network.Mask)
+		for first := true; network.Contains(ip); ip, first = nextIP(ip), false {
+			if skipEdges && (first || !network.Contains(nextIP(ip))) {
+				continue
+			}
+			if !fn(ip.String()) {
+				return
+			}
+		}
+	}
+}
+
+// ScanDevices discovers devices in networks which are not reachable by the multicast, e.g. behind a router.
+// Every address of the networks is probed by the unicast request to /oic/res on port 5683 and devices are sent
+// to the returned channel as they answer, each device is sent once. The channel is closed when the scan is done
+// or the ctx is canceled. Addresses which don't answer within the probe timeout are skipped.
+func (c *Client) ScanDevices(
+	ctx context.Context,
+	cidrs []string,
+	opts ...ScanDevicesOption,
+) (<-chan DeviceDetails, error) {
+	cfg := scanDevicesOptions{
+		err:          c.errors,
+		getDetails:   getDetails,
+		concurrency:  32,
+		rate:         100,
+		probeTimeout: time.Second * 2,
+	}
+	for _, o := range opts {
+		cfg = o.applyOnScanDevices(cfg)
+	}
+	if cfg.err == nil {
+		cfg.err = func(error) {}
+	}
+	if cfg.concurrency <= 0 {
+		return nil, core.MakeInvalidArgument(fmt.Errorf("invalid concurrency %v", cfg.concurrency))
+	}
+	if cfg.rate <= 0 || cfg.rate > int(time.Second) {
+		return nil, core.MakeInvalidArgument(fmt.Errorf("invalid rate %v", cfg.rate))
+	}
+	if cfg.probeTimeout <= 0 {
+		return nil, core.MakeInvalidArgument(fmt.Errorf("invalid probe timeout %v", cfg.probeTimeout))
+	}
+	networks, err := parseScanNetworks(cidrs)
+	if err != nil {
+		return nil, core.MakeInvalidArgument(fmt.Errorf("cannot scan devices: %w", err))
+	}
+
+	devices := make(chan DeviceDetails)
+	go func() {
+		defer close(devices)
+		c.scanDevices(ctx, networks, cfg, devices)
+	}()
+	return devices, nil
+}
+
+func (c *Client) scanDevices(ctx context.Context, networks []*net.IPNet, cfg scanDevicesOptions, devices chan<- DeviceDetails) {
+	var m sync.Mutex
+	sent := make(map[string]bool)
+	send := func(d DeviceDetails) {
+		m.Lock()
+		if sent[d.ID] {
+			m.Unlock()
+			return
+		}
+		sent[d.ID] = true
+		m.Unlock()
+		c.storeDeviceDetails(ctx, d)
+		select {
+		case devices <- d:
+		case <-ctx.Done():
+		}
+	}
+
+	ips := make(chan string)
+	var wg sync.WaitGroup
+	wg.Add(cfg.concurrency)
+	for i := 0; i < cfg.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for ip := range ips {
+				c.probeDevice(ctx, ip, cfg, send)
+			}
+		}()
+	}
+
+	t := time.NewTicker(time.Second / time.Duration(cfg.rate))
+	defer t.Stop()
+	forEachScanAddress(networks, func(ip string) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case ips <- ip:
+			return true
+		}
+	})
+	close(ips)
+	wg.Wait()
+}
+
+func (c *Client) probeDevice(ctx context.Context, ip string, cfg scanDevicesOptions, send func(DeviceDetails)) {
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.probeTimeout)
+	defer cancel()
+	dev, err := c.client.GetDeviceByIP(probeCtx, ip)
+	if err != nil {
+		// most of addresses don't answer
+		return
+	}
+	getDetails := func(ctx context.Context, d *core.Device, links schema.ResourceLinks) (interface{}, error) {
+		return cfg.getDetails(ctx, d, patchResourceLinksEndpoints(links, c.disableUDPEndpoints))
+	}
+	var res []DeviceDetails
+	handler := newDiscoveryHandler(probeCtx, cfg.resourceTypes, cfg.err, func(d DeviceDetails) {
+		res = append(res, d)
+	}, getDetails, c.deviceCache, c.disableUDPEndpoints)
+	handler.Handle(probeCtx, dev)
+	if len(res) == 0 {
+		return
+	}
+
+	ownerID, _ := c.client.GetSdkOwnerID()
+	devs := mergeDevices(res)
+	owns := make(map[string]schema.Doxm, len(devs))
+	for _, d := range devs {
+		if !d.IsSecured {
+			continue
+		}
+		refDev, ok := c.deviceCache.GetDevice(probeCtx, d.ID)
+		if !ok {
+			continue
+		}
+		doxm, err := refDev.GetOwnership(probeCtx, d.Resources)
+		refDev.Release(ctx)
+		if err != nil {
+			cfg.err(fmt.Errorf("cannot get ownership of device %v at %v: %w", d.ID, ip, err))
+			continue
+		}
+		owns[doxm.DeviceID] = doxm
+	}
+	for _, d := range setOwnership(ownerID, devs, owns) {
+		send(d)
+	}
+}
@@ -0,0 +1,94 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ScanDevices(t *testing.T) {
+	dev := NewTestDevice(t, test.WithDeviceName(TestSecureDeviceName), test.WithAddress(TestDeviceAddress))
+	secureDeviceID := dev.ID()
+	ip4 := "127.0.0.2"
+	c, err := NewTestSecureClient()
+	require.NoError(t, err)
+	defer func() {
+		err := c.Close(context.Background())
+		require.NoError(t, err)
+	}()
+
+	type args struct {
+		cidrs []string
+		opts  []local.ScanDevicesOption
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "ip",
+			args: args{
+				cidrs: []string{ip4},
+			},
+			want: []string{secureDeviceID},
+		},
+		{
+			name: "cidr",
+			args: args{
+				cidrs: []string{ip4 + "/24"},
+				opts:  []local.ScanDevicesOption{local.WithScanConcurrency(64), local.WithScanRate(500), local.WithScanProbeTimeout(time.Second)},
+			},
+			want: []string{secureDeviceID},
+		},
+		{
+			name: "invalid-cidr",
+			args: args{
+				cidrs: []string{"invalid"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too-large-network",
+			args: args{
+				cidrs: []string{"10.0.0.0/8"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid-rate",
+			args: args{
+				cidrs: []string{ip4},
+				opts:  []local.ScanDevicesOption{local.WithScanRate(0)},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+			devices, err := c.ScanDevices(ctx, tt.args.cidrs, tt.args.opts...)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var got []string
+			for d := range devices {
+				if d.ID == secureDeviceID {
+					require.NotNil(t, d.Ownership)
+					require.Equal(t, local.OwnershipStatus_ReadyToBeOwned, d.OwnershipStatus)
+				}
+				got = append(got, d.ID)
+			}
+			require.Subset(t, got, tt.want)
+		})
+	}
+}
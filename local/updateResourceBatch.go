@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plgd-dev/kit/codec/cbor"
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/local/core"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+)
+
+// UpdateResourceBatch updates resources of the collection in one request via the batch interface.
+// The request contains the representations indexed by href of the resources.
+func (c *Client) UpdateResourceBatch(
+	ctx context.Context,
+	deviceID string,
+	href string,
+	request map[string]interface{},
+) (BatchResponse, error) {
+	items := make([]batchItem, 0, len(request))
+	for h, rep := range request {
+		items = append(items, batchItem{
+			Href:           h,
+			Representation: rep,
+		})
+	}
+	body, err := cbor.Encode(items)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode batch request: %w", err)
+	}
+
+	d, links, err := c.GetRefDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Release(ctx)
+
+	link, err := core.GetResourceLink(links, href)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = d.UpdateResourceWithCodec(ctx, link, codecOcf.RawVNDOCFCBORCodec{}, body, &data, kitNetCoap.WithInterface(BatchInterface))
+	if err != nil {
+		return nil, err
+	}
+	return decodeBatchItems(data)
+}
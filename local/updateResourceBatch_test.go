@@ -0,0 +1,97 @@
+package local_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_UpdateResourceBatch(t *testing.T) {
+	dev := NewTestDevice(t, test.WithInsecure(), test.WithCollection("/lights", "/light/1", "/light/2"))
+	deviceID := dev.ID()
+	type args struct {
+		deviceID string
+		href     string
+		request  map[string]interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]test.Light
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			args: args{
+				deviceID: deviceID,
+				href:     "/lights",
+				request: map[string]interface{}{
+					"/light/1": map[string]interface{}{"power": uint64(7)},
+					"/light/2": map[string]interface{}{"state": true},
+				},
+			},
+			want: map[string]test.Light{
+				"/light/1": {Power: 7, Name: "Light"},
+				"/light/2": {State: true, Name: "Light"},
+			},
+		},
+		{
+			name: "not a child",
+			args: args{
+				deviceID: deviceID,
+				href:     "/lights",
+				request: map[string]interface{}{
+					"/oc/con": map[string]interface{}{"n": "renamed"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid href",
+			args: args{
+				deviceID: deviceID,
+				href:     "/invalid/href",
+				request: map[string]interface{}{
+					"/light/1": map[string]interface{}{"power": uint64(1)},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TestTimeout)
+	defer cancel()
+
+	c := NewTestClient()
+	defer c.Close(context.Background())
+	StoreTestDevices(t, c, dev)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			got, err := c.UpdateResourceBatch(ctx, tt.args.deviceID, tt.args.href, tt.args.request)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for href, want := range tt.want {
+				require.Contains(t, got, href)
+				var rep test.Light
+				err = got[href](&rep)
+				require.NoError(t, err)
+				require.Equal(t, want, rep)
+
+				// the update is applied to the child
+				var current test.Light
+				err = c.GetResource(ctx, tt.args.deviceID, href, &current)
+				require.NoError(t, err)
+				require.Equal(t, want, current)
+			}
+		})
+	}
+}
@@ -11,7 +11,8 @@ import (
 )
 
 func TestClient_UpdateResource(t *testing.T) {
-	deviceID := test.MustFindDeviceByName(test.TestDeviceName)
+	dev := NewTestDevice(t, test.WithInsecure())
+	deviceID := dev.ID()
 	type args struct {
 		deviceID string
 		href     string
@@ -57,11 +58,11 @@ func TestClient_UpdateResource(t *testing.T) {
 				deviceID: deviceID,
 				href:     "/oc/con",
 				data: map[string]interface{}{
-					"n": test.TestDeviceName,
+					"n": TestDeviceName,
 				},
 			},
 			want: map[interface{}]interface{}{
-				"n": test.TestDeviceName,
+				"n": TestDeviceName,
 			},
 		},
 		{
@@ -81,6 +82,7 @@ func TestClient_UpdateResource(t *testing.T) {
 
 	c := NewTestClient()
 	defer c.Close(context.Background())
+	StoreTestDevices(t, c, dev)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -187,6 +187,33 @@ func WithCredentialSubject(in string) OptionFunc {
 	}
 }
 
+func WithAccessControlId(in int) OptionFunc {
+	return func(opts message.Options) message.Options {
+		v := "aceid=" + strconv.Itoa(in)
+		buf := make([]byte, len(v))
+		opts, _, _ = opts.AddString(buf, message.URIQuery, v)
+		return opts
+	}
+}
+
+// WithQuery adds the query to the request, e.g. "pmin=10".
+func WithQuery(query string) OptionFunc {
+	return func(opts message.Options) message.Options {
+		buf := make([]byte, len(query))
+		opts, _, _ = opts.AddString(buf, message.URIQuery, query)
+		return opts
+	}
+}
+
+// WithObserve registers the observation, it is used for the multicast observation of discovery resources.
+func WithObserve() OptionFunc {
+	return func(opts message.Options) message.Options {
+		buf := make([]byte, 4)
+		opts, _, _ = opts.SetUint32(buf, message.Observe, 0)
+		return opts
+	}
+}
+
 func WithAccept(contentFormat message.MediaType) OptionFunc {
 	return func(opts message.Options) message.Options {
 		buf := make([]byte, 4)
@@ -238,6 +265,46 @@ func (c *Client) UpdateResourceWithCodec(
 	return nil
 }
 
+func (c *Client) CreateResource(
+	ctx context.Context,
+	href string,
+	request interface{},
+	response interface{},
+	options ...OptionFunc,
+) error {
+	return c.CreateResourceWithCodec(ctx, href, codecOcf.VNDOCFCBORCodec{}, request, response, options...)
+}
+
+func (c *Client) CreateResourceWithCodec(
+	ctx context.Context,
+	href string,
+	codec Codec,
+	request interface{},
+	response interface{},
+	options ...OptionFunc,
+) error {
+	body, err := codec.Encode(request)
+	if err != nil {
+		return fmt.Errorf("could not encode the query %s: %w", href, err)
+	}
+	opts := make(message.Options, 0, 4)
+	for _, o := range options {
+		opts = o(opts)
+	}
+
+	resp, err := c.conn.Post(ctx, href, codec.ContentFormat(), bytes.NewReader(body), opts...)
+	if err != nil {
+		return fmt.Errorf("could not query %s: %w", href, err)
+	}
+	if resp.Code != codes.Created && resp.Code != codes.Changed {
+		return status.Error(resp, fmt.Errorf("request failed: %s", codecOcf.Dump(resp)))
+	}
+	if err := codec.Decode(resp, response); err != nil {
+		return status.Error(resp, fmt.Errorf("could not decode the query %s: %w", href, err))
+	}
+	return nil
+}
+
 func (c *Client) GetResource(
 	ctx context.Context,
 	href string,
@@ -289,6 +356,10 @@ func (c *Client) DeleteResourceWithCodec(
 	if resp.Code != codes.Deleted {
 		return status.Error(resp, fmt.Errorf("request failed: %s", codecOcf.Dump(resp)))
 	}
+	if resp.Body == nil {
+		// the response to the delete usually doesn't contain any representation, so the response is left unchanged
+		return nil
+	}
 	if err := codec.Decode(resp, response); err != nil {
 		return status.Error(resp, fmt.Errorf("could not decode the query %s: %w", href, err))
 	}
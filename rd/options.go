@@ -0,0 +1,47 @@
+package rd
+
+import "time"
+
+type config struct {
+	errors            func(error)
+	selection         int
+	defaultTimeToLive time.Duration
+	maxTimeToLive     time.Duration
+}
+
+type OptionFunc func(config) config
+
+// WithErrors allows to set the handler of errors, by default errors are dropped.
+func WithErrors(errors func(error)) OptionFunc {
+	return func(cfg config) config {
+		if errors != nil {
+			cfg.errors = errors
+		}
+		return cfg
+	}
+}
+
+// WithSelection allows to set the bias factor 0-100 reported by /oic/rd, devices prefer the directory with the higher value.
+// By default it is 50.
+func WithSelection(selection int) OptionFunc {
+	return func(cfg config) config {
+		cfg.selection = selection
+		return cfg
+	}
+}
+
+// WithDefaultTimeToLive allows to set the time to live of links published without ttl, by default they don't expire.
+func WithDefaultTimeToLive(ttl time.Duration) OptionFunc {
+	return func(cfg config) config {
+		cfg.defaultTimeToLive = ttl
+		return cfg
+	}
+}
+
+// WithMaxTimeToLive allows to limit the time to live requested by devices, by default it is not limited.
+func WithMaxTimeToLive(ttl time.Duration) OptionFunc {
+	return func(cfg config) config {
+		cfg.maxTimeToLive = ttl
+		return cfg
+	}
+}
@@ -0,0 +1,394 @@
+// Package rd provides a minimal OCF resource directory. Devices publish their links to /oic/rd
+// and clients discover them by /oic/res of the directory.
+// https://openconnectivity.org/specs/OCF_Resource_Directory_Specification_v2.0.0.pdf
+package rd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/udp"
+	"github.com/plgd-dev/kit/codec/cbor"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+type publishedDevice struct {
+	name  string
+	links schema.ResourceLinks
+	// expires is zero when links don't expire.
+	expires time.Time
+}
+
+// Server is the resource directory, it keeps published links in the memory.
+type Server struct {
+	deviceID string
+	cfg      config
+	router   *mux.Router
+
+	lock    sync.Mutex
+	devices map[string]publishedDevice
+	lastIns int64
+	server  *udp.Server
+}
+
+// New creates the resource directory, deviceID is the anchor of its own links.
+func New(deviceID string, opts ...OptionFunc) *Server {
+	cfg := config{
+		errors:    func(error) {},
+		selection: 50,
+	}
+	for _, o := range opts {
+		cfg = o(cfg)
+	}
+	s := &Server{
+		deviceID: deviceID,
+		cfg:      cfg,
+		router:   mux.NewRouter(),
+		devices:  make(map[string]publishedDevice),
+	}
+	s.router.HandleFunc("/oic/res", s.handleDiscovery)
+	s.router.HandleFunc(schema.ResourceDirectoryHref, s.handleResourceDirectory)
+	return s
+}
+
+// Serve serves CoAP requests on l until Stop is called. To answer the multicast discovery,
+// l must join the OCF multicast group, e.g. l.JoinGroup(nil, &net.UDPAddr{IP: net.ParseIP("224.0.1.187")}).
+func (s *Server) Serve(l *coapNet.UDPConn) error {
+	server := udp.NewServer(udp.WithMux(s.router), udp.WithErrors(s.cfg.errors))
+	s.lock.Lock()
+	if s.server != nil {
+		s.lock.Unlock()
+		return fmt.Errorf("resource directory is already serving")
+	}
+	s.server = server
+	s.lock.Unlock()
+	return server.Serve(l)
+}
+
+// Stop stops serving, published links are kept.
+func (s *Server) Stop() {
+	s.lock.Lock()
+	server := s.server
+	s.server = nil
+	s.lock.Unlock()
+	if server != nil {
+		server.Stop()
+	}
+}
+
+func (s *Server) ownLinks() schema.ResourceLinks {
+	anchor := "ocf://" + s.deviceID
+	return schema.ResourceLinks{
+		{
+			Href:          "/oic/res",
+			ResourceTypes: []string{"oic.wk.res"},
+			Interfaces:    []string{"oic.if.ll", "oic.if.baseline"},
+			Anchor:        anchor,
+			DeviceID:      s.deviceID,
+			Policy:        &schema.Policy{BitMask: schema.Discoverable},
+		},
+		{
+			Href:          schema.ResourceDirectoryHref,
+			ResourceTypes: []string{schema.ResourceDirectoryResourceType},
+			Interfaces:    []string{"oic.if.baseline"},
+			Anchor:        anchor,
+			DeviceID:      s.deviceID,
+			Policy:        &schema.Policy{BitMask: schema.Discoverable},
+		},
+	}
+}
+
+func (s *Server) removeExpiredLocked(now time.Time) {
+	for deviceID, d := range s.devices {
+		if !d.expires.IsZero() && !now.Before(d.expires) {
+			delete(s.devices, deviceID)
+		}
+	}
+}
+
+func hasOneOf(values []string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		for _, v := range values {
+			if v == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Links returns links of the directory and published links which are not expired. Links are filtered
+// by resource types and interfaces, an empty filter matches all links.
+func (s *Server) Links(resourceTypes, interfaces []string) schema.ResourceLinks {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeExpiredLocked(time.Now())
+	links := make(schema.ResourceLinks, 0, 16)
+	add := func(link schema.ResourceLink) {
+		if hasOneOf(link.ResourceTypes, resourceTypes) && hasOneOf(link.Interfaces, interfaces) {
+			links = append(links, link)
+		}
+	}
+	for _, link := range s.ownLinks() {
+		add(link)
+	}
+	for _, d := range s.devices {
+		for _, link := range d.links {
+			add(link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Anchor != links[j].Anchor {
+			return links[i].Anchor < links[j].Anchor
+		}
+		return links[i].Href < links[j].Href
+	})
+	return links
+}
+
+func (s *Server) timeToLive(ttl int64) time.Duration {
+	v := time.Duration(ttl) * time.Second
+	if v == 0 {
+		v = s.cfg.defaultTimeToLive
+	}
+	if s.cfg.maxTimeToLive > 0 && (v == 0 || v > s.cfg.maxTimeToLive) {
+		v = s.cfg.maxTimeToLive
+	}
+	return v
+}
+
+// findLink returns the index of the published link with the href of the link, so each href is published once.
+// The link without the published href replaces the link with its instance ID, e.g. when the resource was moved.
+func findLink(links schema.ResourceLinks, link schema.ResourceLink) int {
+	byInstanceID := -1
+	for i, l := range links {
+		if l.Href == link.Href {
+			return i
+		}
+		if link.InstanceID != 0 && l.InstanceID == link.InstanceID {
+			byInstanceID = i
+		}
+	}
+	return byInstanceID
+}
+
+// Publish adds links of the device or replaces links with the same href or instance ID. The time to live of all
+// links of the device is reset. Links without endpoints get the endpoint of the publisher, e.g. "coap://[fe80::1]:5683".
+// It returns all links of the device with assigned instance IDs.
+func (s *Server) Publish(req schema.ResourceDirectoryPublish, publisherEndpoint string) (schema.ResourceDirectoryPublish, error) {
+	if req.DeviceID == "" {
+		return schema.ResourceDirectoryPublish{}, fmt.Errorf("invalid device id")
+	}
+	if req.TimeToLive < 0 {
+		return schema.ResourceDirectoryPublish{}, fmt.Errorf("invalid ttl %v", req.TimeToLive)
+	}
+	if len(req.Links) == 0 {
+		return schema.ResourceDirectoryPublish{}, fmt.Errorf("empty links")
+	}
+	for _, link := range req.Links {
+		if link.Href == "" {
+			return schema.ResourceDirectoryPublish{}, fmt.Errorf("invalid link: empty href")
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	s.removeExpiredLocked(now)
+	d := s.devices[req.DeviceID]
+	links := append(schema.ResourceLinks(nil), d.links...)
+	for _, link := range req.Links {
+		link.DeviceID = req.DeviceID
+		if link.Anchor == "" {
+			link.Anchor = "ocf://" + req.DeviceID
+		}
+		if len(link.Endpoints) == 0 && publisherEndpoint != "" {
+			link.Endpoints = schema.Endpoints{{URI: publisherEndpoint}}
+		}
+		idx := findLink(links, link)
+		if idx < 0 {
+			if link.InstanceID == 0 {
+				s.lastIns++
+				link.InstanceID = s.lastIns
+			} else if link.InstanceID > s.lastIns {
+				s.lastIns = link.InstanceID
+			}
+			links = append(links, link)
+			continue
+		}
+		link.InstanceID = links[idx].InstanceID
+		links[idx] = link
+	}
+	if req.Name != "" {
+		d.name = req.Name
+	}
+	d.links = links
+	d.expires = time.Time{}
+	if ttl := s.timeToLive(req.TimeToLive); ttl > 0 {
+		d.expires = now.Add(ttl)
+	}
+	s.devices[req.DeviceID] = d
+	return schema.ResourceDirectoryPublish{
+		DeviceID:   req.DeviceID,
+		Name:       d.name,
+		TimeToLive: req.TimeToLive,
+		Links:      append(schema.ResourceLinks(nil), links...),
+	}, nil
+}
+
+// Delete removes links of the device with the instance IDs, without instance IDs it removes all links of the device.
+// It returns false when there was nothing to remove.
+func (s *Server) Delete(deviceID string, instanceIDs ...int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeExpiredLocked(time.Now())
+	d, ok := s.devices[deviceID]
+	if !ok {
+		return false
+	}
+	if len(instanceIDs) == 0 {
+		delete(s.devices, deviceID)
+		return true
+	}
+	links := make(schema.ResourceLinks, 0, len(d.links))
+	for _, link := range d.links {
+		remove := false
+		for _, ins := range instanceIDs {
+			if link.InstanceID == ins {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			links = append(links, link)
+		}
+	}
+	if len(links) == len(d.links) {
+		return false
+	}
+	if len(links) == 0 {
+		delete(s.devices, deviceID)
+		return true
+	}
+	d.links = links
+	s.devices[deviceID] = d
+	return true
+}
+
+func getQueries(r *mux.Message) map[string][]string {
+	res := make(map[string][]string)
+	queries, _ := r.Options.Queries()
+	for _, q := range queries {
+		kv := strings.SplitN(q, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		res[kv[0]] = append(res[kv[0]], kv[1])
+	}
+	return res
+}
+
+func (s *Server) setError(w mux.ResponseWriter, code codes.Code, err error) {
+	s.cfg.errors(fmt.Errorf("resource directory: %w", err))
+	if errResp := w.SetResponse(code, message.TextPlain, bytes.NewReader([]byte(err.Error()))); errResp != nil {
+		s.cfg.errors(fmt.Errorf("resource directory: cannot set response: %w", errResp))
+	}
+}
+
+func (s *Server) setResponse(w mux.ResponseWriter, code codes.Code, v interface{}) {
+	data, err := cbor.Encode(v)
+	if err != nil {
+		s.setError(w, codes.InternalServerError, fmt.Errorf("cannot encode response: %w", err))
+		return
+	}
+	if err := w.SetResponse(code, message.AppOcfCbor, bytes.NewReader(data)); err != nil {
+		s.cfg.errors(fmt.Errorf("resource directory: cannot set response: %w", err))
+	}
+}
+
+func (s *Server) handleDiscovery(w mux.ResponseWriter, r *mux.Message) {
+	if r.Code != codes.GET {
+		s.setError(w, codes.MethodNotAllowed, fmt.Errorf("invalid method %v for /oic/res", r.Code))
+		return
+	}
+	queries := getQueries(r)
+	s.setResponse(w, codes.Content, s.Links(queries["rt"], queries["if"]))
+}
+
+func (s *Server) handleResourceDirectory(w mux.ResponseWriter, r *mux.Message) {
+	switch r.Code {
+	case codes.GET:
+		s.setResponse(w, codes.Content, schema.ResourceDirectory{
+			ResourceTypes: []string{schema.ResourceDirectoryResourceType},
+			Interfaces:    []string{"oic.if.baseline"},
+			Selection:     s.cfg.selection,
+		})
+	case codes.POST:
+		s.handlePublish(w, r)
+	case codes.DELETE:
+		s.handleDelete(w, r)
+	default:
+		s.setError(w, codes.MethodNotAllowed, fmt.Errorf("invalid method %v for %v", r.Code, schema.ResourceDirectoryHref))
+	}
+}
+
+func (s *Server) handlePublish(w mux.ResponseWriter, r *mux.Message) {
+	if r.Body == nil {
+		s.setError(w, codes.BadRequest, fmt.Errorf("empty publish request"))
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.setError(w, codes.BadRequest, fmt.Errorf("cannot read publish request: %w", err))
+		return
+	}
+	var req schema.ResourceDirectoryPublish
+	if err := cbor.Decode(data, &req); err != nil {
+		s.setError(w, codes.BadRequest, fmt.Errorf("cannot decode publish request: %w", err))
+		return
+	}
+	resp, err := s.Publish(req, "coap://"+w.Client().RemoteAddr().String())
+	if err != nil {
+		s.setError(w, codes.BadRequest, fmt.Errorf("cannot publish links: %w", err))
+		return
+	}
+	s.setResponse(w, codes.Changed, resp)
+}
+
+func (s *Server) handleDelete(w mux.ResponseWriter, r *mux.Message) {
+	queries := getQueries(r)
+	deviceIDs := queries["di"]
+	if len(deviceIDs) != 1 || deviceIDs[0] == "" {
+		s.setError(w, codes.BadRequest, fmt.Errorf("invalid delete request: exactly one di is required"))
+		return
+	}
+	instanceIDs := make([]int64, 0, len(queries["ins"]))
+	for _, v := range queries["ins"] {
+		ins, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			s.setError(w, codes.BadRequest, fmt.Errorf("invalid delete request: invalid ins %v: %w", v, err))
+			return
+		}
+		instanceIDs = append(instanceIDs, ins)
+	}
+	if !s.Delete(deviceIDs[0], instanceIDs...) {
+		s.setError(w, codes.NotFound, fmt.Errorf("cannot delete links of device %v: not found", deviceIDs[0]))
+		return
+	}
+	if err := w.SetResponse(codes.Deleted, message.TextPlain, nil); err != nil {
+		s.cfg.errors(fmt.Errorf("resource directory: cannot set response: %w", err))
+	}
+}
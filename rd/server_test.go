@@ -0,0 +1,167 @@
+package rd_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/rd"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRDID     = "00000000-0000-0000-0000-000000000020"
+	testDeviceID = "00000000-0000-0000-0000-000000000021"
+)
+
+func newTestServer(t *testing.T, opts ...rd.OptionFunc) (*rd.Server, string, func()) {
+	s := rd.New(testRDID, opts...)
+	l, err := coapNet.NewListenUDP("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Serve(l)
+	}()
+	return s, l.LocalAddr().String(), func() {
+		s.Stop()
+		l.Close()
+		<-done
+	}
+}
+
+func getHrefs(links schema.ResourceLinks) []string {
+	hrefs := make([]string, 0, len(links))
+	for _, l := range links {
+		hrefs = append(hrefs, l.Href)
+	}
+	return hrefs
+}
+
+func TestServer(t *testing.T) {
+	_, addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	c, err := coap.DialUDP(ctx, addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var rdResource schema.ResourceDirectory
+	err = c.GetResource(ctx, schema.ResourceDirectoryHref, &rdResource)
+	require.NoError(t, err)
+	require.Equal(t, 50, rdResource.Selection)
+
+	var published schema.ResourceDirectoryPublish
+	err = c.UpdateResource(ctx, schema.ResourceDirectoryHref, schema.ResourceDirectoryPublish{
+		DeviceID:   testDeviceID,
+		TimeToLive: 3600,
+		Links: schema.ResourceLinks{
+			{Href: "/oic/d", ResourceTypes: []string{"oic.wk.d", "oic.d.sensor"}, Interfaces: []string{"oic.if.r", "oic.if.baseline"}},
+			{Href: "/temperature", ResourceTypes: []string{"oic.r.temperature"}, Interfaces: []string{"oic.if.s", "oic.if.baseline"}},
+		},
+	}, &published, coap.WithResourceType(schema.ResourceDirectoryPublishResourceType))
+	require.NoError(t, err)
+	require.Len(t, published.Links, 2)
+	for _, l := range published.Links {
+		require.NotZero(t, l.InstanceID)
+		require.Equal(t, "ocf://"+testDeviceID, l.Anchor)
+		// links without endpoints get the endpoint of the publisher
+		require.Len(t, l.Endpoints, 1)
+	}
+
+	var links schema.ResourceLinks
+	err = c.GetResource(ctx, "/oic/res", &links)
+	require.NoError(t, err)
+	require.Equal(t, []string{schema.ResourceDirectoryHref, "/oic/res", "/oic/d", "/temperature"}, getHrefs(links))
+
+	err = c.GetResource(ctx, "/oic/res", &links, coap.WithResourceType("oic.r.temperature"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/temperature"}, getHrefs(links))
+
+	err = c.GetResource(ctx, "/oic/res", &links, coap.WithInterface("oic.if.s"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/temperature"}, getHrefs(links))
+
+	// update of the link with the same href keeps the instance ID
+	var updated schema.ResourceDirectoryPublish
+	err = c.UpdateResource(ctx, schema.ResourceDirectoryHref, schema.ResourceDirectoryPublish{
+		DeviceID: testDeviceID,
+		Links: schema.ResourceLinks{
+			{Href: "/temperature", ResourceTypes: []string{"oic.r.temperature", "x.sensor"}, Interfaces: []string{"oic.if.s"}},
+		},
+	}, &updated)
+	require.NoError(t, err)
+	require.Len(t, updated.Links, 2)
+	require.Equal(t, published.Links[1].InstanceID, updated.Links[1].InstanceID)
+	require.Equal(t, []string{"oic.r.temperature", "x.sensor"}, updated.Links[1].ResourceTypes)
+
+	// the link is matched by href, even the instance ID of the request differs
+	err = c.UpdateResource(ctx, schema.ResourceDirectoryHref, schema.ResourceDirectoryPublish{
+		DeviceID: testDeviceID,
+		Links: schema.ResourceLinks{
+			{Href: "/temperature", InstanceID: published.Links[1].InstanceID + 100, ResourceTypes: []string{"oic.r.temperature"}, Interfaces: []string{"oic.if.s"}},
+		},
+	}, &updated)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/oic/d", "/temperature"}, getHrefs(updated.Links))
+	require.Equal(t, published.Links[1].InstanceID, updated.Links[1].InstanceID)
+
+	// the link with the instance ID and a new href is moved
+	err = c.UpdateResource(ctx, schema.ResourceDirectoryHref, schema.ResourceDirectoryPublish{
+		DeviceID: testDeviceID,
+		Links: schema.ResourceLinks{
+			{Href: "/temperature/1", InstanceID: published.Links[1].InstanceID, ResourceTypes: []string{"oic.r.temperature"}, Interfaces: []string{"oic.if.s"}},
+		},
+	}, &updated)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/oic/d", "/temperature/1"}, getHrefs(updated.Links))
+	require.Equal(t, published.Links[1].InstanceID, updated.Links[1].InstanceID)
+
+	err = c.DeleteResource(ctx, schema.ResourceDirectoryHref, nil, coap.WithDeviceID(testDeviceID), coap.WithInstanceID(int(updated.Links[1].InstanceID)))
+	require.NoError(t, err)
+	err = c.GetResource(ctx, "/oic/res", &links, coap.WithResourceType("oic.r.temperature"))
+	require.NoError(t, err)
+	require.Empty(t, links)
+
+	err = c.DeleteResource(ctx, schema.ResourceDirectoryHref, nil, coap.WithDeviceID(testDeviceID))
+	require.NoError(t, err)
+	err = c.DeleteResource(ctx, schema.ResourceDirectoryHref, nil, coap.WithDeviceID(testDeviceID))
+	require.Error(t, err)
+	err = c.GetResource(ctx, "/oic/res", &links)
+	require.NoError(t, err)
+	require.Equal(t, []string{schema.ResourceDirectoryHref, "/oic/res"}, getHrefs(links))
+
+	// invalid requests
+	err = c.UpdateResource(ctx, schema.ResourceDirectoryHref, schema.ResourceDirectoryPublish{
+		Links: schema.ResourceLinks{{Href: "/oic/d"}},
+	}, nil)
+	require.Error(t, err)
+	err = c.DeleteResource(ctx, schema.ResourceDirectoryHref, nil)
+	require.Error(t, err)
+}
+
+func TestServerTimeToLive(t *testing.T) {
+	s := rd.New(testRDID, rd.WithMaxTimeToLive(time.Millisecond*500))
+	publish := func(deviceID string, ttl int64) {
+		_, err := s.Publish(schema.ResourceDirectoryPublish{
+			DeviceID:   deviceID,
+			TimeToLive: ttl,
+			Links:      schema.ResourceLinks{{Href: "/oic/d", ResourceTypes: []string{"oic.wk.d"}}},
+		}, "coap://127.0.0.1:5683")
+		require.NoError(t, err)
+	}
+	publish(testDeviceID, 3600)
+	publish("00000000-0000-0000-0000-000000000022", 0)
+	require.Len(t, s.Links([]string{"oic.wk.d"}, nil), 2)
+
+	time.Sleep(time.Second)
+	require.Empty(t, s.Links([]string{"oic.wk.d"}, nil))
+
+	_, err := s.Publish(schema.ResourceDirectoryPublish{DeviceID: testDeviceID, TimeToLive: -1, Links: schema.ResourceLinks{{Href: "/oic/d"}}}, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package acl
+
+// Equal compares access controls, the ID is ignored.
+func (a AccessControl) Equal(b AccessControl) bool {
+	if a.Permission != b.Permission || !a.Subject.Equal(b.Subject) {
+		return false
+	}
+	if len(a.Resources) != len(b.Resources) || len(a.Validity) != len(b.Validity) {
+		return false
+	}
+	for i := range a.Resources {
+		if !a.Resources[i].Equal(b.Resources[i]) {
+			return false
+		}
+	}
+	for i := range a.Validity {
+		if a.Validity[i] != b.Validity[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal compares subjects.
+func (s Subject) Equal(b Subject) bool {
+	switch {
+	case (s.Subject_Device == nil) != (b.Subject_Device == nil),
+		(s.Subject_Role == nil) != (b.Subject_Role == nil),
+		(s.Subject_Connection == nil) != (b.Subject_Connection == nil):
+		return false
+	case s.Subject_Device != nil && *s.Subject_Device != *b.Subject_Device,
+		s.Subject_Role != nil && *s.Subject_Role != *b.Subject_Role,
+		s.Subject_Connection != nil && *s.Subject_Connection != *b.Subject_Connection:
+		return false
+	}
+	return true
+}
+
+// Equal compares resources.
+func (r Resource) Equal(b Resource) bool {
+	return r.Href == b.Href &&
+		r.Wildcard == b.Wildcard &&
+		stringsEqual(r.Interfaces, b.Interfaces) &&
+		stringsEqual(r.ResourceTypes, b.ResourceTypes)
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff returns access controls of current which are missing in desired and
+// access controls of desired which are missing in current.
+func Diff(current, desired []AccessControl) (remove []AccessControl, add []AccessControl) {
+	matched := make([]bool, len(current))
+	for _, d := range desired {
+		found := false
+		for i, c := range current {
+			if !matched[i] && c.Equal(d) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			add = append(add, d)
+		}
+	}
+	for i, c := range current {
+		if !matched[i] {
+			remove = append(remove, c)
+		}
+	}
+	return remove, add
+}
@@ -0,0 +1,170 @@
+package acl_test
+
+import (
+	"testing"
+
+	"github.com/plgd-dev/sdk/schema/acl"
+	"github.com/stretchr/testify/require"
+)
+
+func deviceACE(id int, deviceID string, permission acl.Permission, hrefs ...string) acl.AccessControl {
+	resources := make([]acl.Resource, 0, len(hrefs))
+	for _, href := range hrefs {
+		resources = append(resources, acl.Resource{
+			Href:       href,
+			Interfaces: []string{"*"},
+		})
+	}
+	return acl.AccessControl{
+		ID:         id,
+		Permission: permission,
+		Subject: acl.Subject{
+			Subject_Device: &acl.Subject_Device{
+				DeviceID: deviceID,
+			},
+		},
+		Resources: resources,
+	}
+}
+
+const (
+	ownerID = "00000000-0000-0000-0000-000000000001"
+	otherID = "00000000-0000-0000-0000-000000000002"
+)
+
+func TestAccessControl_Equal(t *testing.T) {
+	ace := deviceACE(1, ownerID, acl.AllPermissions, "/light/1", "/light/2")
+	withValidity := ace
+	withValidity.Validity = []acl.TimePattern{{Period: "20200101T000000/PT1H", Recurrence: "RRULE:FREQ=DAILY"}}
+	tests := []struct {
+		name string
+		a    acl.AccessControl
+		b    acl.AccessControl
+		want bool
+	}{
+		{
+			name: "equal",
+			a:    ace,
+			b:    deviceACE(1, ownerID, acl.AllPermissions, "/light/1", "/light/2"),
+			want: true,
+		},
+		{
+			name: "id is ignored",
+			a:    ace,
+			b:    deviceACE(2, ownerID, acl.AllPermissions, "/light/1", "/light/2"),
+			want: true,
+		},
+		{
+			name: "changed permission",
+			a:    ace,
+			b:    deviceACE(1, ownerID, acl.Permission_READ, "/light/1", "/light/2"),
+		},
+		{
+			name: "changed subject",
+			a:    ace,
+			b:    deviceACE(1, otherID, acl.AllPermissions, "/light/1", "/light/2"),
+		},
+		{
+			name: "changed subject type",
+			a:    ace,
+			b: acl.AccessControl{
+				ID:         1,
+				Permission: acl.AllPermissions,
+				Subject:    acl.TLSConnection,
+				Resources:  ace.Resources,
+			},
+		},
+		{
+			name: "changed resource",
+			a:    ace,
+			b:    deviceACE(1, ownerID, acl.AllPermissions, "/light/1", "/light/3"),
+		},
+		{
+			name: "removed resource",
+			a:    ace,
+			b:    deviceACE(1, ownerID, acl.AllPermissions, "/light/1"),
+		},
+		{
+			name: "changed wildcard",
+			a:    deviceACE(1, ownerID, acl.AllPermissions),
+			b: acl.AccessControl{
+				ID:         1,
+				Permission: acl.AllPermissions,
+				Subject:    deviceACE(1, ownerID, acl.AllPermissions).Subject,
+				Resources:  acl.AllResources,
+			},
+		},
+		{
+			name: "added validity",
+			a:    ace,
+			b:    withValidity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.a.Equal(tt.b))
+			require.Equal(t, tt.want, tt.b.Equal(tt.a))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	owner := deviceACE(1, ownerID, acl.AllPermissions, "/light/1")
+	other := deviceACE(2, otherID, acl.Permission_READ, "/light/1")
+	changedOther := deviceACE(0, otherID, acl.Permission_READ|acl.Permission_WRITE, "/light/1")
+	tests := []struct {
+		name       string
+		current    []acl.AccessControl
+		desired    []acl.AccessControl
+		wantRemove []acl.AccessControl
+		wantAdd    []acl.AccessControl
+	}{
+		{
+			name:    "equal",
+			current: []acl.AccessControl{owner, other},
+			desired: []acl.AccessControl{deviceACE(0, otherID, acl.Permission_READ, "/light/1"), deviceACE(0, ownerID, acl.AllPermissions, "/light/1")},
+		},
+		{
+			name:    "added",
+			current: []acl.AccessControl{owner},
+			desired: []acl.AccessControl{owner, other},
+			wantAdd: []acl.AccessControl{other},
+		},
+		{
+			name:       "removed",
+			current:    []acl.AccessControl{owner, other},
+			desired:    []acl.AccessControl{owner},
+			wantRemove: []acl.AccessControl{other},
+		},
+		{
+			name:       "changed",
+			current:    []acl.AccessControl{owner, other},
+			desired:    []acl.AccessControl{owner, changedOther},
+			wantRemove: []acl.AccessControl{other},
+			wantAdd:    []acl.AccessControl{changedOther},
+		},
+		{
+			name:       "duplicate",
+			current:    []acl.AccessControl{owner, owner},
+			desired:    []acl.AccessControl{owner},
+			wantRemove: []acl.AccessControl{owner},
+		},
+		{
+			name:    "empty current",
+			desired: []acl.AccessControl{owner},
+			wantAdd: []acl.AccessControl{owner},
+		},
+		{
+			name:       "empty desired",
+			current:    []acl.AccessControl{owner},
+			wantRemove: []acl.AccessControl{owner},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remove, add := acl.Diff(tt.current, tt.desired)
+			require.Equal(t, tt.wantRemove, remove)
+			require.Equal(t, tt.wantAdd, add)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package schema
 
+import "encoding/pem"
+
 type CertificateSigningRequestResponse struct {
 	Interfaces                []string            `json:"if"`
 	ResourceTypes             []string            `json:"rt"`
@@ -22,6 +24,14 @@ func (c CertificateSigningRequestResponse) CSR() []byte {
 	return nil
 }
 
+// PEM returns the CSR encoded by PEM, which is expected by certificate signers.
+func (c CertificateSigningRequestResponse) PEM() []byte {
+	if c.Encoding == CertificateEncoding_DER {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: c.CSR()})
+	}
+	return c.CSR()
+}
+
 type CertificateEncoding string
 
 const (
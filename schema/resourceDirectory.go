@@ -0,0 +1,26 @@
+package schema
+
+const (
+	ResourceDirectoryHref         = "/oic/rd"
+	ResourceDirectoryResourceType = "oic.wk.rd"
+	// ResourceDirectoryPublishResourceType is used by devices as the query of the publish request.
+	ResourceDirectoryPublishResourceType = "oic.wk.rdpub"
+)
+
+// ResourceDirectory describes how the resource directory is preferred by devices.
+// https://github.com/openconnectivityfoundation/core/blob/master/swagger2.0/oic.wk.rd.swagger.json
+type ResourceDirectory struct {
+	ResourceTypes []string `json:"rt"`
+	Interfaces    []string `json:"if"`
+	// Selection is the bias factor in the range 0-100, the higher value is preferred.
+	Selection int `json:"sel"`
+}
+
+// ResourceDirectoryPublish is the payload of the publish request, the device publishes links or updates them.
+// TimeToLive is in seconds, 0 means links don't expire.
+type ResourceDirectoryPublish struct {
+	DeviceID   string        `json:"di"`
+	Name       string        `json:"n,omitempty"`
+	TimeToLive int64         `json:"ttl,omitempty"`
+	Links      ResourceLinks `json:"links"`
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/dtls/v2"
+	"github.com/pion/udp"
+	coapDtls "github.com/plgd-dev/go-coap/v2/dtls"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/udp/client"
+)
+
+// handshakeTimeout limits the DTLS handshake of an accepted connection.
+const handshakeTimeout = time.Second * 10
+
+// GetDTLSConfigFunc returns the configuration of the accepted DTLS connection.
+type GetDTLSConfigFunc = func() (*dtls.Config, error)
+
+// DTLSListener accepts DTLS connections. The configuration is created for every connection,
+// so certificates and cipher suites can follow the current security state of the device.
+type DTLSListener struct {
+	listener  net.Listener
+	getConfig GetDTLSConfigFunc
+	errors    func(error)
+
+	conns chan *dtls.Conn
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewDTLSListener listens on the UDP address, e.g. "127.0.0.1:0". Errors of handshakes are reported by errors.
+func NewDTLSListener(network, addr string, getConfig GetDTLSConfigFunc, errors func(error)) (*DTLSListener, error) {
+	a, err := net.ResolveUDPAddr(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve address: %w", err)
+	}
+	listener, err := udp.Listen(network, a)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen: %w", err)
+	}
+	if errors == nil {
+		errors = func(error) {}
+	}
+	l := &DTLSListener{
+		listener:  listener,
+		getConfig: getConfig,
+		errors:    errors,
+		conns:     make(chan *dtls.Conn),
+		done:      make(chan struct{}),
+	}
+	l.wg.Add(1)
+	go l.acceptLoop()
+	return l, nil
+}
+
+func (l *DTLSListener) acceptLoop() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			l.handshake(conn)
+		}()
+	}
+}
+
+func (l *DTLSListener) handshake(conn net.Conn) {
+	cfg, err := l.getConfig()
+	if err != nil {
+		conn.Close()
+		l.errors(fmt.Errorf("cannot get dtls config for %v: %w", conn.RemoteAddr(), err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	dtlsConn, err := dtls.ServerWithContext(ctx, conn, cfg)
+	if err != nil {
+		conn.Close()
+		l.errors(fmt.Errorf("dtls handshake with %v failed: %w", conn.RemoteAddr(), err))
+		return
+	}
+	select {
+	case l.conns <- dtlsConn:
+	case <-l.done:
+		dtlsConn.Close()
+	}
+}
+
+// AcceptWithContext waits for the next connection with the finished handshake.
+func (l *DTLSListener) AcceptWithContext(ctx context.Context) (net.Conn, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, coapNet.ErrListenerIsClosed
+	case conn := <-l.conns:
+		return conn, nil
+	}
+}
+
+// Addr returns the address of the listener.
+func (l *DTLSListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Close stops accepting connections, accepted connections are closed by the server.
+func (l *DTLSListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.listener.Close()
+		l.wg.Wait()
+	})
+	return err
+}
+
+type connectionInfoKey struct{}
+
+type connectionInfo struct {
+	peerCertificates []*x509.Certificate
+}
+
+func getConnectionInfo(ctx context.Context) (connectionInfo, bool) {
+	info, ok := ctx.Value(connectionInfoKey{}).(connectionInfo)
+	return info, ok
+}
+
+func onNewSecureConnection(cc *client.ClientConn, conn *dtls.Conn) {
+	var info connectionInfo
+	for _, raw := range conn.ConnectionState().PeerCertificates {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			break
+		}
+		info.peerCertificates = append(info.peerCertificates, cert)
+	}
+	cc.SetContextValue(connectionInfoKey{}, info)
+}
+
+// ServeDTLS serves CoAP requests secured by DTLS on l until Stop is called. Requests of resources
+// with Secured are accepted only by this listener.
+func (s *Server) ServeDTLS(l *DTLSListener) error {
+	server := coapDtls.NewServer(
+		coapDtls.WithMux(s.router),
+		coapDtls.WithErrors(s.cfg.errors),
+		coapDtls.WithOnNewClientConn(onNewSecureConnection),
+	)
+	addr, _ := l.Addr().(*net.UDPAddr)
+	s.lock.Lock()
+	if s.stopped {
+		s.lock.Unlock()
+		return nil
+	}
+	if s.secureServer != nil {
+		s.lock.Unlock()
+		return fmt.Errorf("server is already serving dtls")
+	}
+	s.secureServer = server
+	s.secureAddr = addr
+	s.lock.Unlock()
+	return server.Serve(l)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	"github.com/plgd-dev/go-coap/v2/udp/client"
+	"github.com/plgd-dev/kit/codec/cbor"
+)
+
+// notificationTimeout limits the delivery of one notification including retransmissions.
+const notificationTimeout = time.Second * 10
+
+type observer struct {
+	client mux.Client
+	token  message.Token
+	req    Request
+	seq    uint32
+}
+
+func observeOption(seq uint32) message.Option {
+	buf := make([]byte, 4)
+	n, _ := message.EncodeUint32(buf, seq)
+	return message.Option{ID: message.Observe, Value: buf[:n]}
+}
+
+// appendMaxAge appends the Max-Age option when the resource overrides the default.
+func appendMaxAge(opts []message.Option, res Resource) []message.Option {
+	if res.MaxAge <= 0 {
+		return opts
+	}
+	buf := make([]byte, 4)
+	n, _ := message.EncodeUint32(buf, uint32(res.MaxAge/time.Second))
+	return append(opts, message.Option{ID: message.MaxAge, Value: buf[:n]})
+}
+
+// addObserver registers the observation, it returns the sequence number of the first notification.
+func (s *Server) addObserver(c mux.Client, token message.Token, href string, req Request) uint32 {
+	key := token.String()
+	s.lock.Lock()
+	observers, ok := s.observers[href]
+	if !ok {
+		observers = make(map[string]*observer)
+		s.observers[href] = observers
+	}
+	obs := &observer{
+		client: c,
+		token:  token,
+		req:    req,
+		seq:    2,
+	}
+	observers[key] = obs
+	s.lock.Unlock()
+	if cc, ok := c.ClientConn().(*client.ClientConn); ok {
+		cc.AddOnClose(func() {
+			s.removeObserverIf(href, key, obs)
+		})
+	}
+	return obs.seq
+}
+
+func (s *Server) removeObserver(href string, token message.Token) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if observers, ok := s.observers[href]; ok {
+		delete(observers, token.String())
+	}
+}
+
+// removeObserverIf removes the observer unless it was replaced by a new registration with the same token.
+func (s *Server) removeObserverIf(href, key string, obs *observer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if observers, ok := s.observers[href]; ok && observers[key] == obs {
+		delete(observers, key)
+	}
+}
+
+type notification struct {
+	key string
+	obs *observer
+	seq uint32
+}
+
+func (s *Server) getNotifications(href string) []notification {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	observers := s.observers[href]
+	res := make([]notification, 0, len(observers))
+	for key, obs := range observers {
+		// sequence numbers are 24 bits long
+		obs.seq = (obs.seq + 1) & 0xffffff
+		res = append(res, notification{key: key, obs: obs, seq: obs.seq})
+	}
+	return res
+}
+
+// NotifyResourceChanged sends the current representation of the resource to its observers.
+// Observers which don't acknowledge the notification or whose Get fails are removed.
+func (s *Server) NotifyResourceChanged(href string) {
+	res, ok := s.getResource(href)
+	if !ok || !res.Observable {
+		return
+	}
+	for _, n := range s.getNotifications(href) {
+		go s.notify(res, n)
+	}
+}
+
+func (s *Server) notify(res Resource, n notification) {
+	ctx, cancel := context.WithTimeout(n.obs.client.Context(), notificationTimeout)
+	defer cancel()
+	msg := message.Message{
+		Context: ctx,
+		Token:   n.obs.token,
+		Code:    codes.Content,
+	}
+	opts := appendMaxAge([]message.Option{observeOption(n.seq)}, res)
+	v, err := res.Get(ctx, n.obs.req)
+	if err != nil {
+		// a notification with an error code ends the observation
+		s.removeObserverIf(res.Href, n.key, n.obs)
+		s.cfg.errors(fmt.Errorf("server: cannot get resource %v for observer: %w", res.Href, err))
+		msg.Code = errorCode(err)
+		opts = nil
+	} else if v != nil {
+		data, err := cbor.Encode(v)
+		if err != nil {
+			s.removeObserverIf(res.Href, n.key, n.obs)
+			s.cfg.errors(fmt.Errorf("server: cannot encode notification of %v: %w", res.Href, err))
+			return
+		}
+		opts = append(opts, message.Option{ID: message.ContentFormat, Value: encodeContentFormat(message.AppOcfCbor)})
+		msg.Body = bytes.NewReader(data)
+	}
+	msg.Options = opts
+	if err := n.obs.client.WriteMessage(&msg); err != nil {
+		s.removeObserverIf(res.Href, n.key, n.obs)
+		s.cfg.errors(fmt.Errorf("server: cannot send notification of %v: %w", res.Href, err))
+	}
+}
+
+func encodeContentFormat(cf message.MediaType) []byte {
+	buf := make([]byte, 4)
+	n, _ := message.EncodeUint32(buf, uint32(cf))
+	return buf[:n]
+}
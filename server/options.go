@@ -0,0 +1,17 @@
+package server
+
+type config struct {
+	errors func(error)
+}
+
+type OptionFunc func(config) config
+
+// WithErrors allows to set the handler of errors, by default errors are dropped.
+func WithErrors(errors func(error)) OptionFunc {
+	return func(cfg config) config {
+		if errors != nil {
+			cfg.errors = errors
+		}
+		return cfg
+	}
+}
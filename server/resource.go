@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+// Request is the request to the resource.
+type Request struct {
+	// Queries of the request, e.g. "if=oic.if.baseline".
+	Queries []string
+	// Interface is the value of the "if" query, it is empty when the query is not set.
+	Interface string
+	// Secured is true when the request was received by DTLS.
+	Secured bool
+	// PeerCertificates of the DTLS connection, the first one is the certificate of the client.
+	PeerCertificates []*x509.Certificate
+}
+
+// GetHandlerFunc returns the representation of the resource which is encoded by CBOR.
+type GetHandlerFunc = func(ctx context.Context, req Request) (interface{}, error)
+
+// PostHandlerFunc updates the resource, decode decodes the body of the request to v.
+// It returns the representation of the resource sent in the response, nil means the response without the body.
+type PostHandlerFunc = func(ctx context.Context, req Request, decode func(v interface{}) error) (interface{}, error)
+
+// DeleteHandlerFunc deletes the resource or its part selected by queries of the request.
+type DeleteHandlerFunc = func(ctx context.Context, req Request) error
+
+// Resource is the resource hosted by the Server.
+type Resource struct {
+	Href          string
+	ResourceTypes []string
+	Interfaces    []string
+	// Observable allows clients to observe the resource, changes are announced by Server.NotifyResourceChanged.
+	Observable bool
+	// MaxAge is sent in responses and notifications of the observable resource, zero means the default 60 seconds.
+	MaxAge time.Duration
+	// Secured allows requests only via DTLS, the link of the resource contains only secure endpoints.
+	Secured bool
+	// Get is required.
+	Get GetHandlerFunc
+	// Post is optional, the resource without it is read only.
+	Post PostHandlerFunc
+	// Delete is optional.
+	Delete DeleteHandlerFunc
+}
+
+func (r Resource) validate() error {
+	if r.Href == "" || r.Href[0] != '/' {
+		return fmt.Errorf("invalid href %v", r.Href)
+	}
+	if len(r.ResourceTypes) == 0 {
+		return fmt.Errorf("invalid resource %v: empty resource types", r.Href)
+	}
+	if len(r.Interfaces) == 0 {
+		return fmt.Errorf("invalid resource %v: empty interfaces", r.Href)
+	}
+	if r.Get == nil {
+		return fmt.Errorf("invalid resource %v: get handler is not set", r.Href)
+	}
+	return nil
+}
+
+func udpEndpoint(scheme string, addr *net.UDPAddr) schema.Endpoint {
+	return schema.Endpoint{URI: scheme + "://" + addr.String()}
+}
+
+// link returns the link of the resource. When the server listens on the unspecified address, endpoints
+// are set by the client from the address of the response according to the policy.
+func (r Resource) link(deviceID string, addr, secureAddr *net.UDPAddr) schema.ResourceLink {
+	bm := schema.Discoverable
+	if r.Observable {
+		bm |= schema.Observable
+	}
+	link := schema.ResourceLink{
+		Href:          r.Href,
+		ResourceTypes: r.ResourceTypes,
+		Interfaces:    r.Interfaces,
+		Anchor:        "ocf://" + deviceID,
+		DeviceID:      deviceID,
+		Policy: &schema.Policy{
+			BitMask: bm,
+		},
+	}
+	if r.Secured {
+		addr = nil
+	}
+	if (addr != nil && addr.IP.IsUnspecified()) || (secureAddr != nil && secureAddr.IP.IsUnspecified()) {
+		if secureAddr != nil && (r.Secured || addr == nil) {
+			link.Policy.UDPPort = uint16(secureAddr.Port)
+			link.Policy.Secured = true
+		} else if addr != nil {
+			link.Policy.UDPPort = uint16(addr.Port)
+		}
+		return link
+	}
+	if addr != nil {
+		link.Endpoints = append(link.Endpoints, udpEndpoint(string(schema.UDPScheme), addr))
+	}
+	if secureAddr != nil {
+		link.Endpoints = append(link.Endpoints, udpEndpoint(string(schema.UDPSecureScheme), secureAddr))
+	}
+	return link
+}
+
+// Error is the error of the handler with the CoAP response code, other errors are reported by InternalServerError.
+type Error struct {
+	Code codes.Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Code, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError creates the error with the CoAP response code, e.g. codes.BadRequest or codes.Forbidden.
+func NewError(code codes.Code, err error) error {
+	return &Error{Code: code, Err: err}
+}
+
+func errorCode(err error) codes.Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return codes.InternalServerError
+}
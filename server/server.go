@@ -0,0 +1,401 @@
+// Package server hosts OCF resources, so a pure-Go device can be discovered and driven by local.Client.
+// The server exposes /oic/res, /oic/d, /oic/p and user-defined resources over UDP and optionally DTLS.
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/plgd-dev/go-coap/v2/dtls"
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/udp"
+	"github.com/plgd-dev/kit/codec/cbor"
+	"github.com/plgd-dev/sdk/schema"
+)
+
+const (
+	discoveryHref = "/oic/res"
+	deviceHref    = "/oic/d"
+	platformHref  = "/oic/p"
+)
+
+// Server hosts resources of one device.
+type Server struct {
+	cfg    config
+	router *mux.Router
+
+	lock         sync.Mutex
+	device       schema.Device
+	platform     schema.Platform
+	resources    map[string]Resource
+	observers    map[string]map[string]*observer
+	addr         *net.UDPAddr
+	secureAddr   *net.UDPAddr
+	server       *udp.Server
+	secureServer *dtls.Server
+	// stopped prevents serving which starts after Stop, the closed listener would block it forever.
+	stopped bool
+}
+
+func hasValue(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// New creates the server of the device, device.ID is required. Missing resource types and interfaces
+// of /oic/d and /oic/p are set to defaults, e.g. oic.wk.d and oic.if.r, oic.if.baseline.
+func New(device schema.Device, platform schema.Platform, opts ...OptionFunc) (*Server, error) {
+	if device.ID == "" {
+		return nil, fmt.Errorf("invalid device id")
+	}
+	cfg := config{
+		errors: func(error) {},
+	}
+	for _, o := range opts {
+		cfg = o(cfg)
+	}
+	if !hasValue(device.ResourceTypes, "oic.wk.d") {
+		device.ResourceTypes = append([]string{"oic.wk.d"}, device.ResourceTypes...)
+	}
+	if len(device.Interfaces) == 0 {
+		device.Interfaces = []string{"oic.if.r", "oic.if.baseline"}
+	}
+	if !hasValue(platform.ResourceTypes, "oic.wk.p") {
+		platform.ResourceTypes = append([]string{"oic.wk.p"}, platform.ResourceTypes...)
+	}
+	if len(platform.Interfaces) == 0 {
+		platform.Interfaces = []string{"oic.if.r", "oic.if.baseline"}
+	}
+
+	s := &Server{
+		cfg:       cfg,
+		router:    mux.NewRouter(),
+		device:    device,
+		platform:  platform,
+		resources: make(map[string]Resource),
+		observers: make(map[string]map[string]*observer),
+	}
+	s.resources[deviceHref] = Resource{
+		Href:          deviceHref,
+		ResourceTypes: device.ResourceTypes,
+		Interfaces:    device.Interfaces,
+		Observable:    true,
+		Get: func(context.Context, Request) (interface{}, error) {
+			return s.Device(), nil
+		},
+	}
+	s.resources[platformHref] = Resource{
+		Href:          platformHref,
+		ResourceTypes: platform.ResourceTypes,
+		Interfaces:    platform.Interfaces,
+		Get: func(context.Context, Request) (interface{}, error) {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			return s.platform, nil
+		},
+	}
+	s.router.HandleFunc(discoveryHref, s.handleDiscovery)
+	s.router.DefaultHandleFunc(s.handleResource)
+	return s, nil
+}
+
+// Device returns the content of /oic/d.
+func (s *Server) Device() schema.Device {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.device
+}
+
+// SetDeviceID changes the ID of the device, e.g. when the device is reset or owned.
+func (s *Server) SetDeviceID(deviceID string) {
+	s.lock.Lock()
+	s.device.ID = deviceID
+	s.lock.Unlock()
+	s.NotifyResourceChanged(deviceHref)
+}
+
+// SetDeviceName changes the name of the device and notifies observers of /oic/d.
+func (s *Server) SetDeviceName(name string) {
+	s.lock.Lock()
+	s.device.Name = name
+	s.lock.Unlock()
+	s.NotifyResourceChanged(deviceHref)
+}
+
+// AddResource adds the user-defined resource, the href must be unique.
+func (s *Server) AddResource(r Resource) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	if r.Href == discoveryHref {
+		return fmt.Errorf("invalid resource %v: reserved href", r.Href)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.resources[r.Href]; ok {
+		return fmt.Errorf("invalid resource %v: already exists", r.Href)
+	}
+	s.resources[r.Href] = r
+	return nil
+}
+
+// RemoveResource removes the user-defined resource, observations of the resource are dropped.
+// It returns false when the resource doesn't exist.
+func (s *Server) RemoveResource(href string) bool {
+	if href == deviceHref || href == platformHref {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.resources[href]; !ok {
+		return false
+	}
+	delete(s.resources, href)
+	delete(s.observers, href)
+	return true
+}
+
+func (s *Server) getResource(href string) (Resource, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.resources[href]
+	return r, ok
+}
+
+// Serve serves CoAP requests on l until Stop is called. To answer the multicast discovery,
+// l must join the OCF multicast group, e.g. l.JoinGroup(nil, &net.UDPAddr{IP: net.ParseIP("224.0.1.187")}).
+// It returns immediately when the server was stopped.
+func (s *Server) Serve(l *coapNet.UDPConn) error {
+	addr, _ := l.LocalAddr().(*net.UDPAddr)
+	server := udp.NewServer(udp.WithMux(s.router), udp.WithErrors(s.cfg.errors))
+	s.lock.Lock()
+	if s.stopped {
+		s.lock.Unlock()
+		return nil
+	}
+	if s.server != nil {
+		s.lock.Unlock()
+		return fmt.Errorf("server is already serving")
+	}
+	s.server = server
+	s.addr = addr
+	s.lock.Unlock()
+	return server.Serve(l)
+}
+
+// Stop stops serving and drops all observations.
+func (s *Server) Stop() {
+	s.lock.Lock()
+	server := s.server
+	secureServer := s.secureServer
+	s.server = nil
+	s.secureServer = nil
+	s.stopped = true
+	s.observers = make(map[string]map[string]*observer)
+	s.lock.Unlock()
+	if server != nil {
+		server.Stop()
+	}
+	if secureServer != nil {
+		secureServer.Stop()
+	}
+}
+
+func hasOneOf(values []string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if hasValue(values, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Links returns links of hosted resources filtered by resource types and interfaces, an empty filter matches all links.
+func (s *Server) Links(resourceTypes, interfaces []string) schema.ResourceLinks {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	discovery := Resource{
+		Href:          discoveryHref,
+		ResourceTypes: []string{"oic.wk.res"},
+		Interfaces:    []string{"oic.if.ll", "oic.if.baseline"},
+	}
+	links := make(schema.ResourceLinks, 0, len(s.resources)+1)
+	add := func(r Resource) {
+		if hasOneOf(r.ResourceTypes, resourceTypes) && hasOneOf(r.Interfaces, interfaces) {
+			links = append(links, r.link(s.device.ID, s.addr, s.secureAddr))
+		}
+	}
+	add(discovery)
+	for _, r := range s.resources {
+		add(r)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Href < links[j].Href
+	})
+	return links
+}
+
+func getQueries(r *mux.Message) ([]string, map[string][]string) {
+	res := make(map[string][]string)
+	queries, _ := r.Options.Queries()
+	for _, q := range queries {
+		kv := strings.SplitN(q, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		res[kv[0]] = append(res[kv[0]], kv[1])
+	}
+	return queries, res
+}
+
+func getPath(r *mux.Message) string {
+	path, _ := r.Options.Path()
+	return "/" + strings.TrimPrefix(path, "/")
+}
+
+func (s *Server) setError(w mux.ResponseWriter, code codes.Code, err error) {
+	s.cfg.errors(fmt.Errorf("server: %w", err))
+	if errResp := w.SetResponse(code, message.TextPlain, bytes.NewReader([]byte(err.Error()))); errResp != nil {
+		s.cfg.errors(fmt.Errorf("server: cannot set response: %w", errResp))
+	}
+}
+
+func (s *Server) setResponse(w mux.ResponseWriter, code codes.Code, v interface{}, opts ...message.Option) {
+	if v == nil {
+		if err := w.SetResponse(code, message.TextPlain, nil, opts...); err != nil {
+			s.cfg.errors(fmt.Errorf("server: cannot set response: %w", err))
+		}
+		return
+	}
+	data, err := cbor.Encode(v)
+	if err != nil {
+		s.setError(w, codes.InternalServerError, fmt.Errorf("cannot encode response: %w", err))
+		return
+	}
+	if err := w.SetResponse(code, message.AppOcfCbor, bytes.NewReader(data), opts...); err != nil {
+		s.cfg.errors(fmt.Errorf("server: cannot set response: %w", err))
+	}
+}
+
+func (s *Server) handleDiscovery(w mux.ResponseWriter, r *mux.Message) {
+	if r.Code != codes.GET {
+		s.setError(w, codes.MethodNotAllowed, fmt.Errorf("invalid method %v for %v", r.Code, discoveryHref))
+		return
+	}
+	_, queries := getQueries(r)
+	s.setResponse(w, codes.Content, s.Links(queries["rt"], queries["if"]))
+}
+
+func (s *Server) handleResource(w mux.ResponseWriter, r *mux.Message) {
+	href := getPath(r)
+	res, ok := s.getResource(href)
+	if !ok {
+		s.setError(w, codes.NotFound, fmt.Errorf("resource %v not found", href))
+		return
+	}
+	queries, values := getQueries(r)
+	req := Request{
+		Queries: queries,
+	}
+	if ifs := values["if"]; len(ifs) > 0 {
+		req.Interface = ifs[0]
+	}
+	if info, ok := getConnectionInfo(w.Client().Context()); ok {
+		req.Secured = true
+		req.PeerCertificates = info.peerCertificates
+	}
+	if res.Secured && !req.Secured {
+		s.setError(w, codes.Unauthorized, fmt.Errorf("resource %v requires secure connection", href))
+		return
+	}
+	if req.Interface != "" && !hasValue(res.Interfaces, req.Interface) {
+		s.setError(w, codes.BadRequest, fmt.Errorf("resource %v doesn't support interface %v", href, req.Interface))
+		return
+	}
+	switch r.Code {
+	case codes.GET:
+		s.handleGet(w, r, res, req)
+	case codes.POST:
+		s.handlePost(w, r, res, req)
+	case codes.DELETE:
+		s.handleDelete(w, r, res, req)
+	default:
+		s.setError(w, codes.MethodNotAllowed, fmt.Errorf("invalid method %v for %v", r.Code, href))
+	}
+}
+
+func (s *Server) handleGet(w mux.ResponseWriter, r *mux.Message, res Resource, req Request) {
+	var opts []message.Option
+	obs, err := r.Options.Observe()
+	if err == nil && res.Observable {
+		switch obs {
+		case 0:
+			seq := s.addObserver(w.Client(), r.Token, res.Href, req)
+			opts = append(opts, observeOption(seq))
+			opts = appendMaxAge(opts, res)
+		case 1:
+			s.removeObserver(res.Href, r.Token)
+		}
+	}
+	v, err := res.Get(r.Context, req)
+	if err != nil {
+		s.removeObserver(res.Href, r.Token)
+		s.setError(w, errorCode(err), fmt.Errorf("cannot get resource %v: %w", res.Href, err))
+		return
+	}
+	s.setResponse(w, codes.Content, v, opts...)
+}
+
+func (s *Server) handlePost(w mux.ResponseWriter, r *mux.Message, res Resource, req Request) {
+	if res.Post == nil {
+		s.setError(w, codes.MethodNotAllowed, fmt.Errorf("resource %v is read only", res.Href))
+		return
+	}
+	decode := func(v interface{}) error {
+		if r.Body == nil {
+			return NewError(codes.BadRequest, fmt.Errorf("empty request"))
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return NewError(codes.BadRequest, fmt.Errorf("cannot read request: %w", err))
+		}
+		if err := cbor.Decode(data, v); err != nil {
+			return NewError(codes.BadRequest, fmt.Errorf("cannot decode request: %w", err))
+		}
+		return nil
+	}
+	v, err := res.Post(r.Context, req, decode)
+	if err != nil {
+		s.setError(w, errorCode(err), fmt.Errorf("cannot update resource %v: %w", res.Href, err))
+		return
+	}
+	s.setResponse(w, codes.Changed, v)
+}
+
+func (s *Server) handleDelete(w mux.ResponseWriter, r *mux.Message, res Resource, req Request) {
+	if res.Delete == nil {
+		s.setError(w, codes.MethodNotAllowed, fmt.Errorf("resource %v cannot be deleted", res.Href))
+		return
+	}
+	if err := res.Delete(r.Context, req); err != nil {
+		s.setError(w, errorCode(err), fmt.Errorf("cannot delete resource %v: %w", res.Href, err))
+		return
+	}
+	s.setResponse(w, codes.Deleted, nil)
+}
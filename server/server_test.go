@@ -0,0 +1,219 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	codecOcf "github.com/plgd-dev/kit/codec/ocf"
+	"github.com/plgd-dev/sdk/app"
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/server"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+const testDeviceID = "00000000-0000-0000-0000-000000000030"
+
+type light struct {
+	Power bool `json:"power"`
+}
+
+type lightResource struct {
+	server *server.Server
+	lock   sync.Mutex
+	state  light
+}
+
+func (l *lightResource) get() light {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.state
+}
+
+func (l *lightResource) resource() server.Resource {
+	return server.Resource{
+		Href:          "/light",
+		ResourceTypes: []string{"core.light"},
+		Interfaces:    []string{"oic.if.a", "oic.if.r", "oic.if.baseline"},
+		Observable:    true,
+		Get: func(context.Context, server.Request) (interface{}, error) {
+			return l.get(), nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			var v light
+			if err := decode(&v); err != nil {
+				return nil, err
+			}
+			if req.Interface == "oic.if.r" {
+				return nil, server.NewError(codes.Forbidden, fmt.Errorf("read only interface"))
+			}
+			l.lock.Lock()
+			l.state = v
+			l.lock.Unlock()
+			l.server.NotifyResourceChanged("/light")
+			return v, nil
+		},
+	}
+}
+
+func newTestServer(t *testing.T) (*server.Server, *lightResource, string, func()) {
+	s, err := server.New(schema.Device{
+		ID:            testDeviceID,
+		ResourceTypes: []string{"oic.d.light"},
+		Name:          "light",
+	}, schema.Platform{
+		PlatformIdentifier: "00000000-0000-0000-0000-000000000031",
+		ManufacturerName:   "plgd",
+	})
+	require.NoError(t, err)
+	l := &lightResource{server: s}
+	err = s.AddResource(l.resource())
+	require.NoError(t, err)
+
+	listener, err := coapNet.NewListenUDP("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Serve(listener)
+	}()
+	return s, l, listener.LocalAddr().String(), func() {
+		s.Stop()
+		listener.Close()
+		<-done
+	}
+}
+
+func getHrefs(links schema.ResourceLinks) []string {
+	hrefs := make([]string, 0, len(links))
+	for _, l := range links {
+		hrefs = append(hrefs, l.Href)
+	}
+	return hrefs
+}
+
+type observationHandler struct {
+	res chan light
+}
+
+func (h *observationHandler) Handle(client *coap.Client, body coap.DecodeFunc) {
+	var v light
+	if err := body(&v); err != nil {
+		return
+	}
+	h.res <- v
+}
+
+func (h *observationHandler) Error(err error) {}
+
+func TestServer(t *testing.T) {
+	s, l, addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	c, err := coap.DialUDP(ctx, addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var links schema.ResourceLinks
+	err = c.GetResource(ctx, "/oic/res", &links)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/light", "/oic/d", "/oic/p", "/oic/res"}, getHrefs(links))
+	for _, link := range links {
+		require.Equal(t, testDeviceID, link.DeviceID)
+		require.Equal(t, schema.Endpoints{{URI: "coap://" + addr}}, link.Endpoints)
+	}
+	err = c.GetResource(ctx, "/oic/res", &links, coap.WithResourceType("oic.wk.d"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/oic/d"}, getHrefs(links))
+	require.Equal(t, []string{"oic.wk.d", "oic.d.light"}, links[0].ResourceTypes)
+
+	var device schema.Device
+	err = c.GetResource(ctx, "/oic/d", &device)
+	require.NoError(t, err)
+	require.Equal(t, testDeviceID, device.ID)
+	require.Equal(t, "light", device.Name)
+
+	var platform schema.Platform
+	err = c.GetResource(ctx, "/oic/p", &platform)
+	require.NoError(t, err)
+	require.Equal(t, "plgd", platform.ManufacturerName)
+
+	h := &observationHandler{res: make(chan light, 4)}
+	obs, err := c.Observe(ctx, "/light", codecOcf.VNDOCFCBORCodec{}, h)
+	require.NoError(t, err)
+	require.Equal(t, light{}, <-h.res)
+
+	var updated light
+	err = c.UpdateResource(ctx, "/light", light{Power: true}, &updated)
+	require.NoError(t, err)
+	require.Equal(t, light{Power: true}, updated)
+	require.Equal(t, light{Power: true}, l.get())
+	select {
+	case v := <-h.res:
+		require.Equal(t, light{Power: true}, v)
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+	err = obs.Cancel(ctx)
+	require.NoError(t, err)
+
+	// errors of handlers
+	err = c.UpdateResource(ctx, "/light", light{}, nil, coap.WithInterface("oic.if.r"))
+	require.Error(t, err)
+	err = c.UpdateResource(ctx, "/oic/d", schema.Device{Name: "x"}, nil)
+	require.Error(t, err)
+	err = c.GetResource(ctx, "/unknown", &device)
+	require.Error(t, err)
+
+	require.True(t, s.RemoveResource("/light"))
+	require.False(t, s.RemoveResource("/oic/d"))
+	err = c.GetResource(ctx, "/light", &updated)
+	require.Error(t, err)
+}
+
+func TestServerLocalClient(t *testing.T) {
+	_, _, addr, shutdown := newTestServer(t)
+	defer shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	appCallback, err := app.NewApp(nil)
+	require.NoError(t, err)
+	c, err := local.NewClientFromConfig(&local.Config{
+		KeepAliveConnectionTimeoutSeconds: 1,
+		ObserverPollingIntervalSeconds:    1,
+	}, appCallback, test.NewIdentityCertificateSigner, func(error) {})
+	require.NoError(t, err)
+	defer c.Close(context.Background())
+
+	// the server doesn't join the multicast group, so the client reaches it via the stored endpoint
+	store := local.NewMemoryDeviceStore()
+	err = store.Store(ctx, local.DeviceRecord{
+		ID:        testDeviceID,
+		Endpoints: schema.Endpoints{{URI: "coap://" + addr}},
+	})
+	require.NoError(t, err)
+	c.SetDeviceStore(store)
+
+	var device schema.Device
+	err = c.GetResource(ctx, testDeviceID, "/oic/d", &device)
+	require.NoError(t, err)
+	require.Equal(t, "light", device.Name)
+
+	err = c.UpdateResource(ctx, testDeviceID, "/light", light{Power: true}, nil)
+	require.NoError(t, err)
+	var v light
+	err = c.GetResource(ctx, testDeviceID, "/light", &v)
+	require.NoError(t, err)
+	require.Equal(t, light{Power: true}, v)
+}
@@ -0,0 +1,316 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/schema/acl"
+	"github.com/plgd-dev/sdk/schema/cloud"
+	"github.com/plgd-dev/sdk/server"
+)
+
+// Device is the in-process fake OCF device. It hosts the security resources doxm, pstat, acl2, cred
+// and csr, so the device can be owned, provisioned and disowned by local.Client without devsim.
+type Device struct {
+	cfg          deviceConfig
+	key          *ecdsa.PrivateKey
+	server       *server.Server
+	listener     *coapNet.UDPConn
+	dtlsListener *server.DTLSListener
+	wg           sync.WaitGroup
+
+	lock      sync.Mutex
+	failure   FailureFunc
+	resources map[string]server.Resource
+	securityState
+}
+
+// NewDevice creates the device and starts serving on 127.0.0.1. The device is secured by default,
+// use Endpoints to reach it, e.g. by local.DeviceRecord stored to the device store of the client.
+func NewDevice(opts ...DeviceOptionFunc) (*Device, error) {
+	cfg := deviceConfig{
+		name:                      "go-devsim",
+		address:                   "127.0.0.1:0",
+		errors:                    func(error) {},
+		supportedOperationalModes: schema.OperationalMode_CLIENT_DIRECTED,
+		operationalMode:           schema.OperationalMode_CLIENT_DIRECTED,
+	}
+	for _, o := range opts {
+		cfg = o(cfg)
+	}
+	if cfg.id == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, fmt.Errorf("cannot generate device id: %w", err)
+		}
+		cfg.id = id.String()
+	}
+	if len(cfg.ownerTransferMethods) == 0 {
+		cfg.ownerTransferMethods = []schema.OwnerTransferMethod{schema.JustWorks}
+		if cfg.manufacturerCert != nil {
+			cfg.ownerTransferMethods = append(cfg.ownerTransferMethods, schema.ManufacturerCertificate)
+		}
+		if cfg.displayPIN != nil {
+			cfg.ownerTransferMethods = append(cfg.ownerTransferMethods, schema.SharedPin)
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate device key: %w", err)
+	}
+	piid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate protocol independent id: %w", err)
+	}
+	s, err := server.New(schema.Device{
+		ID:                    cfg.id,
+		ResourceTypes:         []string{"oic.d.cloudDevice"},
+		Name:                  cfg.name,
+		ProtocolIndependentID: piid.String(),
+	}, schema.Platform{
+		PlatformIdentifier: piid.String(),
+		ManufacturerName:   "plgd",
+	}, server.WithErrors(cfg.errors))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create server: %w", err)
+	}
+	d := &Device{
+		cfg:     cfg,
+		key:     key,
+		server:  s,
+		failure: cfg.failure,
+	}
+	d.initSecurity()
+
+	resources := []server.Resource{d.cloudConfigurationResource(), d.configurationResource(), d.maintenanceResource()}
+	if !cfg.insecure {
+		resources = append(resources, d.securityResources()...)
+	}
+	for _, r := range cfg.resources {
+		if !cfg.insecure {
+			r.Secured = true
+		}
+		resources = append(resources, d.notifyOnPost(r))
+	}
+	for _, c := range cfg.collections {
+		c := collection{href: c.href, children: append([]string(nil), c.children...)}
+		resources = append(resources, d.collectionResource(&c))
+	}
+	d.resources = make(map[string]server.Resource, len(resources))
+	for _, r := range resources {
+		r = d.injectFailures(r)
+		if err := s.AddResource(r); err != nil {
+			return nil, err
+		}
+		d.resources[r.Href] = r
+	}
+	if err := d.serve(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// getNetwork returns udp6 for IPv6 addresses, otherwise udp4.
+func getNetwork(address string) (string, string, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid address %v: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", "", fmt.Errorf("invalid address %v: invalid ip", address)
+	}
+	if ip.To4() == nil {
+		return "udp6", host, nil
+	}
+	return "udp4", host, nil
+}
+
+func (d *Device) serve() error {
+	network, host, err := getNetwork(d.cfg.address)
+	if err != nil {
+		return err
+	}
+	listener, err := coapNet.NewListenUDP(network, d.cfg.address)
+	if err != nil {
+		return fmt.Errorf("cannot listen udp: %w", err)
+	}
+	d.listener = listener
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.server.Serve(listener); err != nil {
+			d.cfg.errors(fmt.Errorf("device %v: cannot serve udp: %w", d.ID(), err))
+		}
+	}()
+	if d.cfg.insecure {
+		return nil
+	}
+	dtlsListener, err := server.NewDTLSListener(network, net.JoinHostPort(host, "0"), d.getDTLSConfig, d.cfg.errors)
+	if err != nil {
+		return fmt.Errorf("cannot listen dtls: %w", err)
+	}
+	d.dtlsListener = dtlsListener
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.server.ServeDTLS(dtlsListener); err != nil {
+			d.cfg.errors(fmt.Errorf("device %v: cannot serve dtls: %w", d.ID(), err))
+		}
+	}()
+	return nil
+}
+
+// ID returns the current ID of the device, the ID can be changed during the ownership transfer.
+func (d *Device) ID() string {
+	return d.server.Device().ID
+}
+
+// Endpoints returns the coap and, for the secured device, coaps endpoints of the device.
+func (d *Device) Endpoints() schema.Endpoints {
+	eps := schema.Endpoints{{URI: string(schema.UDPScheme) + "://" + d.listener.LocalAddr().String()}}
+	if d.dtlsListener != nil {
+		eps = append(eps, schema.Endpoint{URI: string(schema.UDPSecureScheme) + "://" + d.dtlsListener.Addr().String()})
+	}
+	return eps
+}
+
+// DiscoveryConfiguration returns the configuration which discovers the device by the unicast request, because
+// the device doesn't join the multicast group.
+func (d *Device) DiscoveryConfiguration() core.DiscoveryConfiguration {
+	addr := d.listener.LocalAddr().String()
+	if network, _, _ := getNetwork(addr); network == "udp6" {
+		return core.DiscoveryConfiguration{MulticastAddressUDP6: []string{addr}}
+	}
+	return core.DiscoveryConfiguration{MulticastAddressUDP4: []string{addr}}
+}
+
+// Server returns the server of the device, e.g. to announce changes of resources by NotifyResourceChanged.
+func (d *Device) Server() *server.Server {
+	return d.server
+}
+
+// SetFailure replaces the failure injection, nil disables it.
+func (d *Device) SetFailure(failure FailureFunc) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.failure = failure
+}
+
+// Close stops the device.
+func (d *Device) Close() {
+	d.server.Stop()
+	if d.listener != nil {
+		d.listener.Close()
+	}
+	if d.dtlsListener != nil {
+		d.dtlsListener.Close()
+	}
+	d.wg.Wait()
+}
+
+// Doxm returns the content of the ownership resource.
+func (d *Device) Doxm() schema.Doxm {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.doxm
+}
+
+// ProvisionStatus returns the content of the provisioning status resource.
+func (d *Device) ProvisionStatus() schema.ProvisionStatusResponse {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.pstat
+}
+
+// AccessControlList returns the content of the access control list resource.
+func (d *Device) AccessControlList() acl.Response {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	v := d.acl
+	v.AccessControlList = append([]acl.AccessControl(nil), d.acl.AccessControlList...)
+	return v
+}
+
+// Credentials returns the content of the credential resource.
+func (d *Device) Credentials() schema.CredentialResponse {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	v := d.creds
+	v.Credentials = append([]schema.Credential(nil), d.creds.Credentials...)
+	return v
+}
+
+// CloudConfiguration returns the content of the cloud configuration resource.
+func (d *Device) CloudConfiguration() cloud.Configuration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.cloud
+}
+
+func (d *Device) injectFailure(method codes.Code, href string) error {
+	d.lock.Lock()
+	failure := d.failure
+	d.lock.Unlock()
+	if failure == nil {
+		return nil
+	}
+	return failure(method, href)
+}
+
+// injectFailures wraps handlers of the resource by the failure injection.
+func (d *Device) injectFailures(r server.Resource) server.Resource {
+	href := r.Href
+	get := r.Get
+	r.Get = func(ctx context.Context, req server.Request) (interface{}, error) {
+		if err := d.injectFailure(codes.GET, href); err != nil {
+			return nil, err
+		}
+		return get(ctx, req)
+	}
+	if post := r.Post; post != nil {
+		r.Post = func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			if err := d.injectFailure(codes.POST, href); err != nil {
+				return nil, err
+			}
+			return post(ctx, req, decode)
+		}
+	}
+	if del := r.Delete; del != nil {
+		r.Delete = func(ctx context.Context, req server.Request) error {
+			if err := d.injectFailure(codes.DELETE, href); err != nil {
+				return err
+			}
+			return del(ctx, req)
+		}
+	}
+	return r
+}
+
+// notifyOnPost notifies observers of the user-defined resource after the successful update.
+func (d *Device) notifyOnPost(r server.Resource) server.Resource {
+	post := r.Post
+	if post == nil || !r.Observable {
+		return r
+	}
+	href := r.Href
+	r.Post = func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+		v, err := post(ctx, req, decode)
+		if err == nil {
+			d.server.NotifyResourceChanged(href)
+		}
+		return v, err
+	}
+	return r
+}
@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/kit/codec/cbor"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/server"
+)
+
+const (
+	collectionResourceType = "oic.wk.col"
+	linkListInterface      = "oic.if.ll"
+	batchInterface         = "oic.if.b"
+	createInterface        = "oic.if.create"
+)
+
+var collectionInterfaces = []string{linkListInterface, batchInterface, createInterface, "oic.if.baseline"}
+
+// collection is shared by handlers of the collection resource, children are guarded by the lock of the device.
+type collection struct {
+	href     string
+	children []string
+	created  int
+}
+
+// createRequest is the request of the create interface, the representation is the initial state of the child.
+type createRequest struct {
+	ResourceTypes  []string    `json:"rt"`
+	Interfaces     []string    `json:"if"`
+	Representation interface{} `json:"rep"`
+}
+
+type collectionBaseline struct {
+	ResourceTypes []string             `json:"rt"`
+	Interfaces    []string             `json:"if"`
+	Links         schema.ResourceLinks `json:"links"`
+}
+
+type batchItem struct {
+	Href           string      `json:"href"`
+	Representation interface{} `json:"rep"`
+}
+
+func (d *Device) getResource(href string) (server.Resource, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	r, ok := d.resources[href]
+	return r, ok
+}
+
+func (d *Device) collectionChildren(c *collection) []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]string(nil), c.children...)
+}
+
+// collectionLinks returns links of children which are hosted by the device.
+func (d *Device) collectionLinks(c *collection) schema.ResourceLinks {
+	all := d.server.Links(nil, nil)
+	children := d.collectionChildren(c)
+	links := make(schema.ResourceLinks, 0, len(children))
+	for _, href := range children {
+		if l, ok := all.GetResourceLink(href); ok {
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// collectionResource creates the collection, the link list interface is the default one. Children can be
+// collections, even the collection which contains the parent. Children created by the create interface can be deleted.
+func (d *Device) collectionResource(c *collection) server.Resource {
+	return server.Resource{
+		Href:          c.href,
+		ResourceTypes: []string{collectionResourceType},
+		Interfaces:    collectionInterfaces,
+		Secured:       !d.cfg.insecure,
+		Get: func(ctx context.Context, req server.Request) (interface{}, error) {
+			switch req.Interface {
+			case batchInterface:
+				return d.getBatch(ctx, c)
+			case "oic.if.baseline":
+				return collectionBaseline{
+					ResourceTypes: []string{collectionResourceType},
+					Interfaces:    collectionInterfaces,
+					Links:         d.collectionLinks(c),
+				}, nil
+			}
+			return d.collectionLinks(c), nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			switch req.Interface {
+			case batchInterface:
+				return d.updateBatch(ctx, req, c, decode)
+			case createInterface:
+				return d.createChild(c, decode)
+			}
+			return nil, server.NewError(codes.MethodNotAllowed, fmt.Errorf("collection %v can be updated only by the batch or create interface", c.href))
+		},
+	}
+}
+
+// createChild hosts the new child of the collection, it returns the link of the child.
+func (d *Device) createChild(c *collection, decode func(v interface{}) error) (interface{}, error) {
+	var create createRequest
+	if err := decode(&create); err != nil {
+		return nil, err
+	}
+	if len(create.ResourceTypes) == 0 {
+		return nil, server.NewError(codes.BadRequest, fmt.Errorf("resource types of the child of the collection %v are not set", c.href))
+	}
+	if len(create.Interfaces) == 0 {
+		create.Interfaces = []string{"oic.if.rw", "oic.if.baseline"}
+	}
+	d.lock.Lock()
+	c.created++
+	href := fmt.Sprintf("%v/%v", c.href, c.created)
+	d.lock.Unlock()
+
+	var lock sync.Mutex
+	rep := create.Representation
+	r := d.injectFailures(server.Resource{
+		Href:          href,
+		ResourceTypes: create.ResourceTypes,
+		Interfaces:    create.Interfaces,
+		Secured:       !d.cfg.insecure,
+		Get: func(context.Context, server.Request) (interface{}, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return rep, nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			var v interface{}
+			if err := decode(&v); err != nil {
+				return nil, err
+			}
+			rep = v
+			return v, nil
+		},
+		Delete: func(context.Context, server.Request) error {
+			d.deleteChild(c, href)
+			return nil
+		},
+	})
+	if err := d.server.AddResource(r); err != nil {
+		return nil, server.NewError(codes.BadRequest, err)
+	}
+	d.lock.Lock()
+	d.resources[href] = r
+	c.children = append(c.children, href)
+	d.lock.Unlock()
+	link, _ := d.server.Links(nil, nil).GetResourceLink(href)
+	return link, nil
+}
+
+func (d *Device) deleteChild(c *collection, href string) {
+	d.server.RemoveResource(href)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.resources, href)
+	for i, child := range c.children {
+		if child == href {
+			c.children = append(c.children[:i], c.children[i+1:]...)
+			break
+		}
+	}
+}
+
+func (d *Device) getBatch(ctx context.Context, c *collection) ([]batchItem, error) {
+	children := d.collectionChildren(c)
+	items := make([]batchItem, 0, len(children))
+	for _, href := range children {
+		r, ok := d.getResource(href)
+		if !ok {
+			continue
+		}
+		rep, err := r.Get(ctx, server.Request{Secured: !d.cfg.insecure})
+		if err != nil {
+			return nil, fmt.Errorf("cannot get %v: %w", href, err)
+		}
+		items = append(items, batchItem{Href: href, Representation: rep})
+	}
+	return items, nil
+}
+
+// updateBatch updates children by the representations of the request, it returns representations
+// of the updated children.
+func (d *Device) updateBatch(ctx context.Context, req server.Request, c *collection, decode func(v interface{}) error) ([]batchItem, error) {
+	var items []batchItem
+	if err := decode(&items); err != nil {
+		return nil, err
+	}
+	children := d.collectionChildren(c)
+	for _, item := range items {
+		if !hasValue(children, item.Href) {
+			return nil, server.NewError(codes.BadRequest, fmt.Errorf("%v is not a child of the collection %v", item.Href, c.href))
+		}
+		r, ok := d.getResource(item.Href)
+		if !ok || r.Post == nil {
+			return nil, server.NewError(codes.BadRequest, fmt.Errorf("%v cannot be updated", item.Href))
+		}
+	}
+	resp := make([]batchItem, 0, len(items))
+	for _, item := range items {
+		r, _ := d.getResource(item.Href)
+		rep := item.Representation
+		v, err := r.Post(ctx, server.Request{Secured: req.Secured, PeerCertificates: req.PeerCertificates}, func(v interface{}) error {
+			data, err := cbor.Encode(rep)
+			if err != nil {
+				return server.NewError(codes.BadRequest, err)
+			}
+			return cbor.Decode(data, v)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot update %v: %w", item.Href, err)
+		}
+		resp = append(resp, batchItem{Href: item.Href, Representation: v})
+	}
+	return resp, nil
+}
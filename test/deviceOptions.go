@@ -0,0 +1,140 @@
+package test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/server"
+)
+
+// FailureFunc injects failures to requests of the fake device, it returns nil to handle the request normally.
+// The error is sent to the client, the response code is set by server.NewError, e.g. codes.ServiceUnavailable.
+type FailureFunc = func(method codes.Code, href string) error
+
+type deviceConfig struct {
+	id                   string
+	name                 string
+	address              string
+	resources            []server.Resource
+	collections          []collection
+	insecure             bool
+	ownerTransferMethods []schema.OwnerTransferMethod
+	manufacturerCert     *tls.Certificate
+	manufacturerCAs      []*x509.Certificate
+	displayPIN           func(pin string)
+	failure              FailureFunc
+	errors               func(error)
+
+	supportedOperationalModes schema.OperationalMode
+	operationalMode           schema.OperationalMode
+}
+
+type DeviceOptionFunc func(deviceConfig) deviceConfig
+
+// WithDeviceID sets the ID of the device, by default a random UUID is used.
+func WithDeviceID(id string) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.id = id
+		return cfg
+	}
+}
+
+// WithDeviceName sets the name of the device, by default it is "go-devsim".
+func WithDeviceName(name string) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.name = name
+		return cfg
+	}
+}
+
+// WithAddress sets the UDP address of the coap endpoint, by default 127.0.0.1:0 is used. The DTLS endpoint
+// listens on the same IP. Use port 5683 to reach the device by GetDeviceByIP, e.g. 127.0.0.2:5683 or [::1]:5683.
+func WithAddress(address string) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.address = address
+		return cfg
+	}
+}
+
+// WithResources adds user-defined resources to the device. Resources of the secured device are accessible only via DTLS.
+func WithResources(resources ...server.Resource) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.resources = append(cfg.resources, resources...)
+		return cfg
+	}
+}
+
+// WithCollection adds the collection oic.wk.col which links resources of the device, it supports
+// the link list, batch, create and baseline interfaces.
+func WithCollection(href string, children ...string) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.collections = append(cfg.collections, collection{href: href, children: children})
+		return cfg
+	}
+}
+
+// WithInsecure creates the device without DTLS and security resources, like devsim without security.
+func WithInsecure() DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.insecure = true
+		return cfg
+	}
+}
+
+// WithOwnerTransferMethods sets the supported ownership transfer methods, by default just works is supported,
+// manufacturer certificate when WithManufacturerCertificate is set and random PIN when WithRandomPIN is set.
+func WithOwnerTransferMethods(methods ...schema.OwnerTransferMethod) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.ownerTransferMethods = methods
+		return cfg
+	}
+}
+
+// WithManufacturerCertificate sets the certificate of the device and authorities which verify clients
+// during the manufacturer certificate ownership transfer.
+func WithManufacturerCertificate(cert tls.Certificate, cas []*x509.Certificate) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.manufacturerCert = &cert
+		cfg.manufacturerCAs = cas
+		return cfg
+	}
+}
+
+// WithRandomPIN enables the random PIN ownership transfer method, the PIN is generated when the method is selected
+// and it is passed to display.
+func WithRandomPIN(display func(pin string)) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.displayPIN = display
+		return cfg
+	}
+}
+
+// WithOperationalModes sets the supported and the current operational mode of the device, by default only
+// the client directed mode is supported.
+func WithOperationalModes(supported, current schema.OperationalMode) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.supportedOperationalModes = supported
+		cfg.operationalMode = current
+		return cfg
+	}
+}
+
+// WithFailure injects failures to requests, it can be changed later by Device.SetFailure.
+func WithFailure(failure FailureFunc) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		cfg.failure = failure
+		return cfg
+	}
+}
+
+// WithDeviceErrors sets the handler of errors of the device, by default errors are dropped.
+func WithDeviceErrors(errors func(error)) DeviceOptionFunc {
+	return func(cfg deviceConfig) deviceConfig {
+		if errors != nil {
+			cfg.errors = errors
+		}
+		return cfg
+	}
+}
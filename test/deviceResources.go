@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"sync"
+
+	"github.com/plgd-dev/sdk/schema/maintenance"
+	"github.com/plgd-dev/sdk/server"
+)
+
+const (
+	configurationHref = "/oc/con"
+	maintenanceHref   = "/oic/mnt"
+)
+
+var (
+	configurationResourceTypes = []string{"oic.wk.con"}
+	maintenanceResourceTypes   = []string{maintenance.MaintenanceResourceType}
+	readWriteInterfaces        = []string{"oic.if.rw", "oic.if.baseline"}
+)
+
+type configuration struct {
+	ResourceTypes []string `json:"rt,omitempty"`
+	Interfaces    []string `json:"if,omitempty"`
+	Name          string   `json:"n"`
+}
+
+// configurationResource renames the device like /oc/con of devsim, the baseline interface returns also rt and if.
+func (d *Device) configurationResource() server.Resource {
+	get := func(req server.Request) configuration {
+		v := configuration{Name: d.server.Device().Name}
+		if req.Interface == "oic.if.baseline" {
+			v.ResourceTypes = configurationResourceTypes
+			v.Interfaces = readWriteInterfaces
+		}
+		return v
+	}
+	return server.Resource{
+		Href:          configurationHref,
+		ResourceTypes: configurationResourceTypes,
+		Interfaces:    readWriteInterfaces,
+		Secured:       !d.cfg.insecure,
+		Get: func(ctx context.Context, req server.Request) (interface{}, error) {
+			return get(req), nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			var upd configuration
+			if err := decode(&upd); err != nil {
+				return nil, err
+			}
+			if upd.Name != "" {
+				d.server.SetDeviceName(upd.Name)
+			}
+			return configuration{Name: d.server.Device().Name}, nil
+		},
+	}
+}
+
+// maintenanceResource supports the factory reset, which returns the device to the ready for ownership transfer
+// state, and the reboot, which is only acknowledged.
+func (d *Device) maintenanceResource() server.Resource {
+	return server.Resource{
+		Href:          maintenanceHref,
+		ResourceTypes: maintenanceResourceTypes,
+		Interfaces:    readWriteInterfaces,
+		Secured:       !d.cfg.insecure,
+		Get: func(ctx context.Context, req server.Request) (interface{}, error) {
+			return maintenance.Maintenance{
+				ResourceTypes: maintenanceResourceTypes,
+				Interfaces:    readWriteInterfaces,
+			}, nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			var upd maintenance.MaintenanceUpdateRequest
+			if err := decode(&upd); err != nil {
+				return nil, err
+			}
+			if upd.FactoryReset {
+				d.lock.Lock()
+				d.resetLocked()
+				d.lock.Unlock()
+			}
+			return maintenance.Maintenance{
+				ResourceTypes: maintenanceResourceTypes,
+				Interfaces:    readWriteInterfaces,
+			}, nil
+		},
+	}
+}
+
+// Light is the representation of the light resource of devsim.
+type Light struct {
+	State bool   `json:"state"`
+	Power uint64 `json:"power"`
+	Name  string `json:"name"`
+}
+
+// NewLightResource creates the observable light resource of devsim, the update changes only properties
+// which are set by the request.
+func NewLightResource(href string) server.Resource {
+	var lock sync.Mutex
+	state := Light{Name: "Light"}
+	return server.Resource{
+		Href:          href,
+		ResourceTypes: []string{"core.light"},
+		Interfaces:    []string{"oic.if.rw", "oic.if.baseline"},
+		Observable:    true,
+		Get: func(context.Context, server.Request) (interface{}, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return state, nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			v := state
+			if err := decode(&v); err != nil {
+				return nil, err
+			}
+			state = v
+			return v, nil
+		},
+	}
+}
@@ -0,0 +1,553 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pion/dtls/v2"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	kitSecurity "github.com/plgd-dev/kit/security"
+	"github.com/plgd-dev/sdk/local/core/otm/just-works/cipher"
+	randompin "github.com/plgd-dev/sdk/local/core/otm/random-pin"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/schema/acl"
+	"github.com/plgd-dev/sdk/schema/cloud"
+	"github.com/plgd-dev/sdk/server"
+)
+
+const (
+	provisionStatusHref   = "/oic/sec/pstat"
+	accessControlListHref = "/oic/sec/acl2"
+	credentialHref        = "/oic/sec/cred"
+	csrHref               = "/oic/sec/csr"
+	// unownedID is the owner of the device which is ready for the ownership transfer, like devsim reports it.
+	unownedID = "00000000-0000-0000-0000-000000000000"
+)
+
+var securityInterfaces = []string{"oic.if.rw", "oic.if.baseline"}
+
+// certificateCipherSuites are cipher suites of OCF devices, they are not enabled by pion by default.
+var certificateCipherSuites = []dtls.CipherSuiteID{dtls.TLS_ECDHE_ECDSA_WITH_AES_128_CCM_8, dtls.TLS_ECDHE_ECDSA_WITH_AES_128_CCM}
+
+// securityState is the security state of the device, it is protected by Device.lock.
+type securityState struct {
+	doxm  schema.Doxm
+	pstat schema.ProvisionStatusResponse
+	acl   acl.Response
+	creds schema.CredentialResponse
+	cloud cloud.Configuration
+
+	nextAccessControlID int
+	nextCredentialID    int
+	// identityCertificate is set by the credential of the device with the certificate usage.
+	identityCertificate *tls.Certificate
+	trustCAs            []*x509.Certificate
+	// pin is generated when the random PIN method is selected.
+	pin string
+}
+
+func (d *Device) initSecurity() {
+	d.doxm = schema.Doxm{
+		SupportedOwnerTransferMethods: d.cfg.ownerTransferMethods,
+		DeviceID:                      d.cfg.id,
+		SupportedCredentialTypes:      schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE,
+		SelectedOwnerTransferMethod:   d.defaultOwnerTransferMethod(),
+		OwnerID:                       unownedID,
+		ResourceOwner:                 unownedID,
+		Interfaces:                    securityInterfaces,
+		ResourceTypes:                 []string{"oic.r.doxm"},
+	}
+	d.pstat = schema.ProvisionStatusResponse{
+		Interfaces:                securityInterfaces,
+		ResourceTypes:             []string{"oic.r.pstat"},
+		CurrentOperationalMode:    d.cfg.operationalMode,
+		SupportedOperationalModes: d.cfg.supportedOperationalModes,
+		DeviceOnboardingState: schema.DeviceOnboardingState{
+			CurrentOrPendingOperationalState: schema.OperationalState_RFOTM,
+		},
+	}
+	d.acl = acl.Response{
+		Interfaces:    securityInterfaces,
+		ResourceTypes: []string{"oic.r.acl2"},
+	}
+	d.creds = schema.CredentialResponse{
+		Interfaces:    securityInterfaces,
+		ResourceTypes: []string{"oic.r.cred"},
+	}
+	d.cloud = cloud.Configuration{
+		ResourceTypes:      cloud.ConfigurationResourceTypes,
+		Interfaces:         securityInterfaces,
+		ProvisioningStatus: cloud.ProvisioningStatus_UNINITIALIZED,
+	}
+	d.nextAccessControlID = 1
+	d.nextCredentialID = 1
+}
+
+// resetLocked returns the device to the ready for ownership transfer state, it is called when pstat is set to RESET.
+func (d *Device) resetLocked() {
+	d.doxm.Owned = false
+	d.doxm.OwnerID = unownedID
+	d.doxm.ResourceOwner = unownedID
+	d.doxm.SelectedOwnerTransferMethod = d.defaultOwnerTransferMethod()
+	d.pstat.ResourceOwner = ""
+	d.pstat.DeviceIsOperational = false
+	d.pstat.DeviceOnboardingState = schema.DeviceOnboardingState{
+		CurrentOrPendingOperationalState: schema.OperationalState_RFOTM,
+	}
+	d.acl.ResourceOwner = ""
+	d.acl.AccessControlList = nil
+	d.creds.ResourceOwner = ""
+	d.creds.Credentials = nil
+	d.identityCertificate = nil
+	d.trustCAs = nil
+	d.pin = ""
+	d.cloud = cloud.Configuration{
+		ResourceTypes:      cloud.ConfigurationResourceTypes,
+		Interfaces:         securityInterfaces,
+		ProvisioningStatus: cloud.ProvisioningStatus_UNINITIALIZED,
+	}
+}
+
+// defaultOwnerTransferMethod returns the method selected by the unowned device, just works when it is supported.
+func (d *Device) defaultOwnerTransferMethod() schema.OwnerTransferMethod {
+	if len(d.cfg.ownerTransferMethods) == 0 || hasOwnerTransferMethod(d.cfg.ownerTransferMethods, schema.JustWorks) {
+		return schema.JustWorks
+	}
+	return d.cfg.ownerTransferMethods[0]
+}
+
+func getQueryValues(req server.Request, key string) []string {
+	var res []string
+	for _, q := range req.Queries {
+		if v := strings.TrimPrefix(q, key+"="); v != q {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (d *Device) securityResources() []server.Resource {
+	return []server.Resource{
+		{
+			Href:          schema.DoxmHref,
+			ResourceTypes: []string{"oic.r.doxm"},
+			Interfaces:    securityInterfaces,
+			Get:           d.getDoxm,
+			Post:          d.updateDoxm,
+		},
+		{
+			Href:          provisionStatusHref,
+			ResourceTypes: []string{"oic.r.pstat"},
+			Interfaces:    securityInterfaces,
+			Secured:       true,
+			Get: func(context.Context, server.Request) (interface{}, error) {
+				return d.ProvisionStatus(), nil
+			},
+			Post: d.updateProvisionStatus,
+		},
+		{
+			Href:          accessControlListHref,
+			ResourceTypes: []string{"oic.r.acl2"},
+			Interfaces:    securityInterfaces,
+			Secured:       true,
+			Get: func(context.Context, server.Request) (interface{}, error) {
+				return d.AccessControlList(), nil
+			},
+			Post:   d.updateAccessControlList,
+			Delete: d.deleteAccessControlList,
+		},
+		{
+			Href:          credentialHref,
+			ResourceTypes: []string{"oic.r.cred"},
+			Interfaces:    securityInterfaces,
+			Secured:       true,
+			Get:           d.getCredentials,
+			Post:          d.updateCredentials,
+			Delete:        d.deleteCredentials,
+		},
+		{
+			Href:          csrHref,
+			ResourceTypes: []string{"oic.r.csr"},
+			Interfaces:    []string{"oic.if.r", "oic.if.baseline"},
+			Secured:       true,
+			Get:           d.getCertificateSigningRequest,
+		},
+	}
+}
+
+// getDoxm supports the Owned query used by the discovery of owned or disowned devices.
+func (d *Device) getDoxm(ctx context.Context, req server.Request) (interface{}, error) {
+	doxm := d.Doxm()
+	for _, v := range getQueryValues(req, "Owned") {
+		if !strings.EqualFold(v, strconv.FormatBool(doxm.Owned)) {
+			return nil, server.NewError(codes.NotFound, fmt.Errorf("device is not owned=%v", v))
+		}
+	}
+	return doxm, nil
+}
+
+// doxmUpdateRequest is schema.DoxmUpdate which distinguishes the selected just works from the missing oxmsel.
+type doxmUpdateRequest struct {
+	ResourceOwner             string                      `json:"rowneruuid"`
+	OwnerID                   string                      `json:"devowneruuid"`
+	DeviceID                  string                      `json:"deviceuuid"`
+	Owned                     bool                        `json:"owned"`
+	SelectOwnerTransferMethod *schema.OwnerTransferMethod `json:"oxmsel"`
+}
+
+func (d *Device) updateDoxm(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+	var upd doxmUpdateRequest
+	if err := decode(&upd); err != nil {
+		return nil, err
+	}
+	deviceID, err := d.applyDoxmUpdate(req, upd)
+	if err != nil {
+		return nil, err
+	}
+	if upd.SelectOwnerTransferMethod != nil && *upd.SelectOwnerTransferMethod == schema.SharedPin {
+		d.lock.Lock()
+		pin := d.pin
+		d.lock.Unlock()
+		d.cfg.displayPIN(pin)
+	}
+	if deviceID != "" {
+		d.server.SetDeviceID(deviceID)
+	}
+	return nil, nil
+}
+
+// generatePIN returns 8 random digits like iotivity-lite.
+func generatePIN() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+	if err != nil {
+		return "", fmt.Errorf("cannot generate PIN: %w", err)
+	}
+	return fmt.Sprintf("%08d", n.Int64()), nil
+}
+
+// applyDoxmUpdate returns the new ID of the device when it was changed.
+func (d *Device) applyDoxmUpdate(req server.Request, upd doxmUpdateRequest) (string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !req.Secured && (upd.ResourceOwner != "" || upd.OwnerID != "" || upd.DeviceID != "" || upd.Owned) {
+		return "", server.NewError(codes.Unauthorized, fmt.Errorf("only oxmsel can be updated via unsecured connection"))
+	}
+	if otm := upd.SelectOwnerTransferMethod; otm != nil && *otm != d.doxm.SelectedOwnerTransferMethod {
+		if d.doxm.Owned {
+			return "", server.NewError(codes.Forbidden, fmt.Errorf("device is owned"))
+		}
+		if !hasOwnerTransferMethod(d.doxm.SupportedOwnerTransferMethods, *otm) {
+			return "", server.NewError(codes.BadRequest, fmt.Errorf("unsupported ownership transfer method %v", *otm))
+		}
+		d.doxm.SelectedOwnerTransferMethod = *otm
+		if *otm == schema.SharedPin {
+			pin, err := generatePIN()
+			if err != nil {
+				return "", err
+			}
+			d.pin = pin
+		}
+	}
+	if d.doxm.Owned && (upd.OwnerID != "" || upd.DeviceID != "") {
+		return "", server.NewError(codes.Forbidden, fmt.Errorf("device is owned"))
+	}
+	if upd.OwnerID != "" {
+		d.doxm.OwnerID = upd.OwnerID
+	}
+	if upd.ResourceOwner != "" {
+		d.doxm.ResourceOwner = upd.ResourceOwner
+	}
+	if upd.Owned {
+		if d.doxm.OwnerID == unownedID {
+			return "", server.NewError(codes.BadRequest, fmt.Errorf("owner is not set"))
+		}
+		d.doxm.Owned = true
+	}
+	if upd.DeviceID == "" || upd.DeviceID == d.doxm.DeviceID {
+		return "", nil
+	}
+	d.doxm.DeviceID = upd.DeviceID
+	d.updateIdentityLocked()
+	return upd.DeviceID, nil
+}
+
+func hasOwnerTransferMethod(methods []schema.OwnerTransferMethod, method schema.OwnerTransferMethod) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Device) updateProvisionStatus(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+	var upd schema.ProvisionStatusUpdateRequest
+	if err := decode(&upd); err != nil {
+		return nil, err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if upd.CurrentOperationalMode != 0 {
+		if !d.pstat.SupportedOperationalModes.Has(upd.CurrentOperationalMode) {
+			return nil, server.NewError(codes.BadRequest, fmt.Errorf("unsupported operational mode %v", upd.CurrentOperationalMode))
+		}
+		d.pstat.CurrentOperationalMode = upd.CurrentOperationalMode
+	}
+	if upd.ResourceOwner != "" {
+		d.pstat.ResourceOwner = upd.ResourceOwner
+	}
+	if upd.DeviceOnboardingState == nil {
+		return nil, nil
+	}
+	switch s := upd.DeviceOnboardingState.CurrentOrPendingOperationalState; s {
+	case schema.OperationalState_RESET, schema.OperationalState_RFOTM:
+		d.resetLocked()
+	case schema.OperationalState_RFPRO, schema.OperationalState_RFNOP:
+		if !d.doxm.Owned {
+			return nil, server.NewError(codes.Forbidden, fmt.Errorf("cannot set operational state %v: device is not owned", s))
+		}
+		d.pstat.DeviceOnboardingState.CurrentOrPendingOperationalState = s
+		d.pstat.DeviceIsOperational = s == schema.OperationalState_RFNOP
+	default:
+		return nil, server.NewError(codes.BadRequest, fmt.Errorf("unsupported operational state %v", s))
+	}
+	return nil, nil
+}
+
+func (d *Device) updateAccessControlList(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+	var upd acl.UpdateRequest
+	if err := decode(&upd); err != nil {
+		return nil, err
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if upd.ResourceOwner != "" {
+		d.acl.ResourceOwner = upd.ResourceOwner
+	}
+	for _, ac := range upd.AccessControlList {
+		ac.ID = d.nextAccessControlID
+		d.nextAccessControlID++
+		d.acl.AccessControlList = append(d.acl.AccessControlList, ac)
+	}
+	return nil, nil
+}
+
+func (d *Device) deleteAccessControlList(ctx context.Context, req server.Request) error {
+	ids := getQueryValues(req, "aceid")
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(ids) == 0 {
+		d.acl.AccessControlList = nil
+		return nil
+	}
+	list := d.acl.AccessControlList[:0]
+	for _, ac := range d.acl.AccessControlList {
+		if !hasValue(ids, strconv.Itoa(ac.ID)) {
+			list = append(list, ac)
+		}
+	}
+	d.acl.AccessControlList = list
+	return nil
+}
+
+func hasValue(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Device) getCredentials(ctx context.Context, req server.Request) (interface{}, error) {
+	creds := d.Credentials()
+	subjects := getQueryValues(req, "subjectuuid")
+	if len(subjects) == 0 {
+		return creds, nil
+	}
+	filtered := make([]schema.Credential, 0, len(creds.Credentials))
+	for _, c := range creds.Credentials {
+		if hasValue(subjects, c.Subject) {
+			filtered = append(filtered, c)
+		}
+	}
+	creds.Credentials = filtered
+	return creds, nil
+}
+
+func (d *Device) updateCredentials(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+	var upd schema.CredentialUpdateRequest
+	if err := decode(&upd); err != nil {
+		return nil, err
+	}
+	for _, c := range upd.Credentials {
+		if c.PublicData == nil || c.PublicData.Encoding != schema.CredentialPublicDataEncoding_PEM {
+			continue
+		}
+		if _, err := kitSecurity.ParseX509FromPEM(c.PublicData.Data()); err != nil {
+			return nil, server.NewError(codes.BadRequest, fmt.Errorf("invalid credential of %v: %w", c.Subject, err))
+		}
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if upd.ResourceOwner != "" {
+		d.creds.ResourceOwner = upd.ResourceOwner
+	}
+	for _, c := range upd.Credentials {
+		c.ID = d.nextCredentialID
+		d.nextCredentialID++
+		d.creds.Credentials = append(d.creds.Credentials, c)
+	}
+	d.updateIdentityLocked()
+	return nil, nil
+}
+
+func (d *Device) deleteCredentials(ctx context.Context, req server.Request) error {
+	ids := getQueryValues(req, "credid")
+	subjects := getQueryValues(req, "subjectuuid")
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	creds := d.creds.Credentials[:0]
+	for _, c := range d.creds.Credentials {
+		remove := len(ids) == 0 && len(subjects) == 0
+		if hasValue(ids, strconv.Itoa(c.ID)) || hasValue(subjects, c.Subject) {
+			remove = true
+		}
+		if !remove {
+			creds = append(creds, c)
+		}
+	}
+	d.creds.Credentials = creds
+	d.updateIdentityLocked()
+	return nil
+}
+
+// updateIdentityLocked sets the identity certificate and trusted authorities from credentials.
+func (d *Device) updateIdentityLocked() {
+	d.identityCertificate = nil
+	d.trustCAs = nil
+	for _, c := range d.creds.Credentials {
+		if c.Type != schema.CredentialType_ASYMMETRIC_SIGNING_WITH_CERTIFICATE || c.PublicData == nil {
+			continue
+		}
+		certs, err := kitSecurity.ParseX509FromPEM(c.PublicData.Data())
+		if err != nil {
+			continue
+		}
+		switch {
+		case c.Usage == schema.CredentialUsage_CERT && c.Subject == d.doxm.DeviceID:
+			cert := tls.Certificate{PrivateKey: d.key}
+			for _, c := range certs {
+				cert.Certificate = append(cert.Certificate, c.Raw)
+			}
+			d.identityCertificate = &cert
+		case c.Usage == schema.CredentialUsage_TRUST_CA:
+			d.trustCAs = append(d.trustCAs, certs...)
+		}
+	}
+}
+
+func (d *Device) getCertificateSigningRequest(ctx context.Context, req server.Request) (interface{}, error) {
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:            pkix.Name{CommonName: "uuid:" + d.Doxm().DeviceID},
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}, d.key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create csr: %w", err)
+	}
+	return schema.CertificateSigningRequestResponse{
+		Interfaces:                []string{"oic.if.r", "oic.if.baseline"},
+		ResourceTypes:             []string{"oic.r.csr"},
+		Encoding:                  schema.CertificateEncoding_PEM,
+		CertificateSigningRequest: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+	}, nil
+}
+
+func (d *Device) cloudConfigurationResource() server.Resource {
+	return server.Resource{
+		Href:          cloud.ConfigurationResourceHref,
+		ResourceTypes: cloud.ConfigurationResourceTypes,
+		Interfaces:    securityInterfaces,
+		Secured:       !d.cfg.insecure,
+		Get: func(context.Context, server.Request) (interface{}, error) {
+			return d.CloudConfiguration(), nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			var upd cloud.ConfigurationUpdateRequest
+			if err := decode(&upd); err != nil {
+				return nil, err
+			}
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			d.cloud.AuthorizationProvider = upd.AuthorizationProvider
+			d.cloud.URL = upd.URL
+			d.cloud.CloudID = upd.CloudID
+			d.cloud.ProvisioningStatus = cloud.ProvisioningStatus_READY_TO_REGISTER
+			return nil, nil
+		},
+	}
+}
+
+// getDTLSConfig returns the configuration of the handshake according to the security state: the owned device
+// uses the identity certificate, otherwise the selected ownership transfer method is used.
+func (d *Device) getDTLSConfig() (*dtls.Config, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	switch {
+	case d.doxm.Owned:
+		if d.identityCertificate == nil {
+			return nil, fmt.Errorf("identity certificate is not set")
+		}
+		rootCAs := x509.NewCertPool()
+		for _, ca := range d.trustCAs {
+			rootCAs.AddCert(ca)
+		}
+		return &dtls.Config{
+			Certificates:          []tls.Certificate{*d.identityCertificate},
+			CipherSuites:          certificateCipherSuites,
+			ClientAuth:            dtls.RequireAnyClientCert,
+			VerifyPeerCertificate: coap.NewVerifyPeerCertificate(rootCAs, coap.VerifyIndetityCertificate),
+		}, nil
+	case d.doxm.SelectedOwnerTransferMethod == schema.JustWorks:
+		return &dtls.Config{
+			CustomCipherSuites: func() []dtls.CipherSuite {
+				return []dtls.CipherSuite{cipher.NewTLSAecdhAes128Sha256(dtls.CipherSuiteID(0xff00))}
+			},
+			CipherSuites: []dtls.CipherSuiteID{},
+		}, nil
+	case d.doxm.SelectedOwnerTransferMethod == schema.SharedPin && d.pin != "":
+		psk, err := randompin.DerivePSK(d.pin, d.doxm.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		return &dtls.Config{
+			PSK: func([]byte) ([]byte, error) {
+				return psk, nil
+			},
+			CipherSuites: randompin.CipherSuites,
+		}, nil
+	case d.doxm.SelectedOwnerTransferMethod == schema.ManufacturerCertificate && d.cfg.manufacturerCert != nil:
+		rootCAs := x509.NewCertPool()
+		for _, ca := range d.cfg.manufacturerCAs {
+			rootCAs.AddCert(ca)
+		}
+		return &dtls.Config{
+			Certificates: []tls.Certificate{*d.cfg.manufacturerCert},
+			CipherSuites: certificateCipherSuites,
+			ClientAuth:   dtls.RequireAnyClientCert,
+			VerifyPeerCertificate: coap.NewVerifyPeerCertificate(rootCAs, func(*x509.Certificate) error {
+				return nil
+			}),
+		}, nil
+	}
+	return nil, fmt.Errorf("ownership transfer method %v is not supported", d.doxm.SelectedOwnerTransferMethod)
+}
@@ -0,0 +1,334 @@
+package test_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/sdk/app"
+	"github.com/plgd-dev/sdk/local"
+	kitNetCoap "github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/schema/cloud"
+	"github.com/plgd-dev/sdk/server"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTimeout    = time.Second * 10
+	testOwnerID    = "00000000-0000-0000-0000-000000000001"
+	testLightHref  = "/light/1"
+	testDeviceName = "light"
+)
+
+type certificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newCertificate(t *testing.T, cn string, parent *certificate) certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyAgreement,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	signer, signerKey := &template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return certificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+type light struct {
+	State bool   `json:"state"`
+	Power uint64 `json:"power"`
+}
+
+type lightResource struct {
+	lock  sync.Mutex
+	state light
+}
+
+func (l *lightResource) resource() server.Resource {
+	return server.Resource{
+		Href:          testLightHref,
+		ResourceTypes: []string{"core.light"},
+		Interfaces:    []string{"oic.if.a", "oic.if.baseline"},
+		Observable:    true,
+		Get: func(context.Context, server.Request) (interface{}, error) {
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			return l.state, nil
+		},
+		Post: func(ctx context.Context, req server.Request, decode func(v interface{}) error) (interface{}, error) {
+			var v light
+			if err := decode(&v); err != nil {
+				return nil, err
+			}
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			l.state = v
+			return v, nil
+		},
+	}
+}
+
+type testEnv struct {
+	ca     certificate
+	mfgCA  certificate
+	mfg    certificate
+	client *local.Client
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	env := testEnv{
+		ca:    newCertificate(t, "RootCA", nil),
+		mfgCA: newCertificate(t, "MfgCA", nil),
+	}
+	env.mfg = newCertificate(t, "uuid:"+testOwnerID, &env.mfgCA)
+	appCallback, err := app.NewApp(&app.AppConfig{
+		Manufacturer: &app.ManufacturerCerts{
+			CA:      string(env.mfgCA.certPEM),
+			Cert:    string(env.mfg.certPEM),
+			CertKey: string(env.mfg.keyPEM),
+		},
+	})
+	require.NoError(t, err)
+	env.client, err = local.NewClientFromConfig(&local.Config{
+		KeepAliveConnectionTimeoutSeconds: 1,
+		ObserverPollingIntervalSeconds:    1,
+		DeviceOwnershipSDK: &local.DeviceOwnershipSDKConfig{
+			ID:      testOwnerID,
+			Cert:    string(env.ca.certPEM),
+			CertKey: string(env.ca.keyPEM),
+		},
+	}, appCallback, test.NewIdentityCertificateSigner, func(error) {})
+	require.NoError(t, err)
+	err = env.client.Initialization(context.Background())
+	require.NoError(t, err)
+	env.client.SetDeviceStore(local.NewMemoryDeviceStore())
+	return &env
+}
+
+func (env *testEnv) close() {
+	env.client.Close(context.Background())
+}
+
+func (env *testEnv) newDevice(t *testing.T, opts ...test.DeviceOptionFunc) *test.Device {
+	dev, err := test.NewDevice(opts...)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	// the device doesn't join the multicast group, so the client reaches it via the stored endpoints
+	store := local.NewMemoryDeviceStore()
+	err = store.Store(ctx, local.DeviceRecord{
+		ID:        dev.ID(),
+		Endpoints: dev.Endpoints(),
+	})
+	require.NoError(t, err)
+	env.client.SetDeviceStore(store)
+	return dev
+}
+
+type observationHandler struct {
+	res chan light
+}
+
+func (h *observationHandler) Handle(ctx context.Context, body kitNetCoap.DecodeFunc) {
+	var v light
+	if err := body(&v); err != nil {
+		return
+	}
+	h.res <- v
+}
+
+func (h *observationHandler) OnClose()        {}
+func (h *observationHandler) Error(err error) {}
+
+func TestDeviceOwnJustWorks(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	l := &lightResource{}
+	dev := env.newDevice(t, test.WithResources(l.resource()))
+	defer dev.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	deviceID, err := env.client.OwnDevice(ctx, dev.ID(), local.WithOTM(local.OTMType_JustWorks))
+	require.NoError(t, err)
+	require.Equal(t, dev.ID(), deviceID)
+	doxm := dev.Doxm()
+	require.True(t, doxm.Owned)
+	require.Equal(t, testOwnerID, doxm.OwnerID)
+	require.Equal(t, schema.JustWorks, doxm.SelectedOwnerTransferMethod)
+	pstat := dev.ProvisionStatus()
+	require.Equal(t, schema.OperationalState_RFNOP, pstat.DeviceOnboardingState.CurrentOrPendingOperationalState)
+	require.True(t, pstat.DeviceIsOperational)
+	require.NotEmpty(t, dev.AccessControlList().AccessControlList)
+	require.Len(t, dev.Credentials().Credentials, 2)
+
+	// the light is accessible only by the owner
+	var v light
+	err = env.client.GetResource(ctx, deviceID, testLightHref, &v)
+	require.NoError(t, err)
+	require.Equal(t, light{}, v)
+
+	h := &observationHandler{res: make(chan light, 4)}
+	observationID, err := env.client.ObserveResource(ctx, deviceID, testLightHref, h)
+	require.NoError(t, err)
+	require.Equal(t, light{}, <-h.res)
+	err = env.client.UpdateResource(ctx, deviceID, testLightHref, light{State: true, Power: 10}, nil)
+	require.NoError(t, err)
+	select {
+	case v := <-h.res:
+		require.Equal(t, light{State: true, Power: 10}, v)
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+	err = env.client.StopObservingResource(ctx, observationID)
+	require.NoError(t, err)
+
+	err = env.client.OnboardDevice(ctx, deviceID, "apn", "coaps+tcp://cloud:5684", "authCode", "cloudID")
+	require.NoError(t, err)
+	cloudCfg := dev.CloudConfiguration()
+	require.Equal(t, "coaps+tcp://cloud:5684", cloudCfg.URL)
+	require.Equal(t, cloud.ProvisioningStatus_READY_TO_REGISTER, cloudCfg.ProvisioningStatus)
+
+	err = env.client.DisownDevice(ctx, deviceID)
+	require.NoError(t, err)
+	doxm = dev.Doxm()
+	require.False(t, doxm.Owned)
+	require.Equal(t, "00000000-0000-0000-0000-000000000000", doxm.OwnerID)
+	require.Equal(t, schema.OperationalState_RFOTM, dev.ProvisionStatus().DeviceOnboardingState.CurrentOrPendingOperationalState)
+	require.Empty(t, dev.Credentials().Credentials)
+	require.Empty(t, dev.AccessControlList().AccessControlList)
+}
+
+func TestDeviceOwnManufacturerCertificate(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	dev := env.newDevice(t, test.WithManufacturerCertificate(tls.Certificate{
+		Certificate: [][]byte{env.mfg.cert.Raw},
+		PrivateKey:  env.mfg.key,
+	}, []*x509.Certificate{env.mfgCA.cert}))
+	defer dev.Close()
+	require.Equal(t, []schema.OwnerTransferMethod{schema.JustWorks, schema.ManufacturerCertificate}, dev.Doxm().SupportedOwnerTransferMethods)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	deviceID, err := env.client.OwnDevice(ctx, dev.ID())
+	require.NoError(t, err)
+	doxm := dev.Doxm()
+	require.True(t, doxm.Owned)
+	require.Equal(t, schema.ManufacturerCertificate, doxm.SelectedOwnerTransferMethod)
+
+	var d schema.Device
+	err = env.client.GetResource(ctx, deviceID, "/oic/d", &d)
+	require.NoError(t, err)
+	require.Equal(t, "go-devsim", d.Name)
+}
+
+func TestDeviceOwnUnsupportedOTM(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	dev := env.newDevice(t, test.WithOwnerTransferMethods(schema.JustWorks))
+	defer dev.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	_, err := env.client.OwnDevice(ctx, dev.ID())
+	require.Error(t, err)
+	require.False(t, dev.Doxm().Owned)
+}
+
+func TestDeviceFailure(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	dev := env.newDevice(t, test.WithFailure(func(method codes.Code, href string) error {
+		if method == codes.POST && href == "/oic/sec/cred" {
+			return server.NewError(codes.ServiceUnavailable, fmt.Errorf("injected failure"))
+		}
+		return nil
+	}))
+	defer dev.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	_, err := env.client.OwnDevice(ctx, dev.ID(), local.WithOTM(local.OTMType_JustWorks))
+	require.Error(t, err)
+	require.False(t, dev.Doxm().Owned)
+	require.Empty(t, dev.Credentials().Credentials)
+
+	dev.SetFailure(nil)
+	_, err = env.client.OwnDevice(ctx, dev.ID(), local.WithOTM(local.OTMType_JustWorks))
+	require.NoError(t, err)
+	require.True(t, dev.Doxm().Owned)
+}
+
+func TestDeviceInsecure(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	l := &lightResource{}
+	dev := env.newDevice(t, test.WithInsecure(), test.WithDeviceName(testDeviceName), test.WithResources(l.resource()))
+	defer dev.Close()
+	require.Len(t, dev.Endpoints(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// the insecure device is not owned
+	deviceID, err := env.client.OwnDevice(ctx, dev.ID())
+	require.NoError(t, err)
+	require.False(t, dev.Doxm().Owned)
+
+	var d schema.Device
+	err = env.client.GetResource(ctx, deviceID, "/oic/d", &d)
+	require.NoError(t, err)
+	require.Equal(t, testDeviceName, d.Name)
+	err = env.client.UpdateResource(ctx, deviceID, testLightHref, light{State: true}, nil)
+	require.NoError(t, err)
+	var v light
+	err = env.client.GetResource(ctx, deviceID, testLightHref, &v)
+	require.NoError(t, err)
+	require.Equal(t, light{State: true}, v)
+}
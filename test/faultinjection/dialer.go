@@ -0,0 +1,261 @@
+// Package faultinjection provides dialers which pass connections via a proxy injecting faults, so error paths
+// of the client can be tested. Dialer methods match core.DialUDP, core.DialTCP, core.DialDTLS and core.DialTLS.
+package faultinjection
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+
+	"github.com/pion/dtls/v2"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+)
+
+type config struct {
+	rule      RuleFunc
+	handshake HandshakeFunc
+	errors    func(error)
+}
+
+type OptionFunc func(config) config
+
+// WithRule sets the rule applied to packets, by default all packets are forwarded.
+func WithRule(rule RuleFunc) OptionFunc {
+	return func(cfg config) config {
+		if rule != nil {
+			cfg.rule = rule
+		}
+		return cfg
+	}
+}
+
+// WithHandshake sets which handshakes of secure connections fail, by default all handshakes are performed.
+func WithHandshake(handshake HandshakeFunc) OptionFunc {
+	return func(cfg config) config {
+		if handshake != nil {
+			cfg.handshake = handshake
+		}
+		return cfg
+	}
+}
+
+// WithErrors sets the handler of errors of proxies, by default errors are dropped.
+func WithErrors(errors func(error)) OptionFunc {
+	return func(cfg config) config {
+		if errors != nil {
+			cfg.errors = errors
+		}
+		return cfg
+	}
+}
+
+type connProxy interface {
+	Addr() string
+	Close() error
+	setOnClose(onClose func())
+	wait()
+}
+
+// Dialer dials connections via proxies which apply the rule to every packet.
+type Dialer struct {
+	errors func(error)
+
+	lock        sync.Mutex
+	rule        RuleFunc
+	handshake   HandshakeFunc
+	conns       int
+	secureConns int
+	seqs        [2]int
+	proxies     map[int]connProxy
+}
+
+func NewDialer(opts ...OptionFunc) *Dialer {
+	cfg := config{
+		rule: func(Packet) Fault {
+			return Fault{}
+		},
+		handshake: func(int, string) error {
+			return nil
+		},
+		errors: func(error) {},
+	}
+	for _, o := range opts {
+		cfg = o(cfg)
+	}
+	return &Dialer{
+		errors:    cfg.errors,
+		rule:      cfg.rule,
+		handshake: cfg.handshake,
+		proxies:   make(map[int]connProxy),
+	}
+}
+
+// SetRule replaces the rule, it is applied to packets of existing connections too.
+func (d *Dialer) SetRule(rule RuleFunc) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.rule = rule
+}
+
+// SetHandshake replaces the handshake schedule.
+func (d *Dialer) SetHandshake(handshake HandshakeFunc) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.handshake = handshake
+}
+
+// Connections returns the number of dialed connections including connections with failed handshakes.
+func (d *Dialer) Connections() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.conns
+}
+
+func (d *Dialer) getRule() RuleFunc {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.rule == nil {
+		return func(Packet) Fault {
+			return Fault{}
+		}
+	}
+	return d.rule
+}
+
+func (d *Dialer) nextSeq(dir Direction) int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	v := d.seqs[dir]
+	d.seqs[dir]++
+	return v
+}
+
+func (d *Dialer) nextConn() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	v := d.conns
+	d.conns++
+	return v
+}
+
+// checkHandshake returns the number of the connection or the injected handshake error.
+func (d *Dialer) checkHandshake(addr string) (int, error) {
+	d.lock.Lock()
+	conn := d.conns
+	d.conns++
+	n := d.secureConns
+	d.secureConns++
+	handshake := d.handshake
+	d.lock.Unlock()
+	if handshake == nil {
+		return conn, nil
+	}
+	if err := handshake(n, addr); err != nil {
+		return conn, fmt.Errorf("handshake error: %w", err)
+	}
+	return conn, nil
+}
+
+func (d *Dialer) addProxy(conn int, p connProxy) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.proxies[conn] = p
+}
+
+func (d *Dialer) removeProxy(conn int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.proxies, conn)
+}
+
+// attach closes the proxy with the client.
+func (d *Dialer) attach(p connProxy, c *coap.ClientCloseHandler, err error) (*coap.ClientCloseHandler, error) {
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	p.setOnClose(func() {
+		c.Close()
+	})
+	c.RegisterCloseHandler(func(error) {
+		p.Close()
+	})
+	return c, nil
+}
+
+func (d *Dialer) newUDPProxy(conn int, secure bool, addr string) (connProxy, error) {
+	p, err := newUDPProxy(d, conn, secure, addr)
+	if err != nil {
+		return nil, err
+	}
+	d.addProxy(conn, p)
+	return p, nil
+}
+
+func (d *Dialer) newTCPProxy(conn int, secure bool, addr string) (connProxy, error) {
+	p, err := newTCPProxy(d, conn, secure, addr)
+	if err != nil {
+		return nil, err
+	}
+	d.addProxy(conn, p)
+	return p, nil
+}
+
+func (d *Dialer) DialUDP(ctx context.Context, addr string, opts ...coap.DialOptionFunc) (*coap.ClientCloseHandler, error) {
+	p, err := d.newUDPProxy(d.nextConn(), false, addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := coap.DialUDP(ctx, p.Addr(), opts...)
+	return d.attach(p, c, err)
+}
+
+func (d *Dialer) DialTCP(ctx context.Context, addr string, opts ...coap.DialOptionFunc) (*coap.ClientCloseHandler, error) {
+	p, err := d.newTCPProxy(d.nextConn(), false, addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := coap.DialTCP(ctx, p.Addr(), opts...)
+	return d.attach(p, c, err)
+}
+
+func (d *Dialer) DialDTLS(ctx context.Context, addr string, dtlsCfg *dtls.Config, opts ...coap.DialOptionFunc) (*coap.ClientCloseHandler, error) {
+	conn, err := d.checkHandshake(addr)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.newUDPProxy(conn, true, addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := coap.DialUDPSecure(ctx, p.Addr(), dtlsCfg, opts...)
+	return d.attach(p, c, err)
+}
+
+func (d *Dialer) DialTLS(ctx context.Context, addr string, tlsCfg *tls.Config, opts ...coap.DialOptionFunc) (*coap.ClientCloseHandler, error) {
+	conn, err := d.checkHandshake(addr)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.newTCPProxy(conn, true, addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := coap.DialTCPSecure(ctx, p.Addr(), tlsCfg, opts...)
+	return d.attach(p, c, err)
+}
+
+// Close closes all proxies, clients of the proxies don't receive any packet after it.
+func (d *Dialer) Close() {
+	d.lock.Lock()
+	proxies := make([]connProxy, 0, len(d.proxies))
+	for _, p := range d.proxies {
+		proxies = append(proxies, p)
+	}
+	d.lock.Unlock()
+	for _, p := range proxies {
+		p.Close()
+		p.wait()
+	}
+}
@@ -0,0 +1,235 @@
+package faultinjection_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/net/blockwise"
+	"github.com/plgd-dev/sdk/app"
+	"github.com/plgd-dev/sdk/local"
+	"github.com/plgd-dev/sdk/local/core"
+	"github.com/plgd-dev/sdk/pkg/net/coap"
+	"github.com/plgd-dev/sdk/schema"
+	"github.com/plgd-dev/sdk/server"
+	"github.com/plgd-dev/sdk/test"
+	"github.com/plgd-dev/sdk/test/faultinjection"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = time.Second * 10
+
+type blob struct {
+	Data string `json:"data"`
+}
+
+func newTestDevice(t *testing.T) *test.Device {
+	dev, err := test.NewDevice(test.WithInsecure(), test.WithDeviceName("light"), test.WithResources(server.Resource{
+		Href:          "/blob",
+		ResourceTypes: []string{"x.blob"},
+		Interfaces:    []string{"oic.if.r", "oic.if.baseline"},
+		Get: func(context.Context, server.Request) (interface{}, error) {
+			return blob{Data: strings.Repeat("x", 4096)}, nil
+		},
+	}))
+	require.NoError(t, err)
+	return dev
+}
+
+func dial(ctx context.Context, t *testing.T, d *faultinjection.Dialer, dev *test.Device) *coap.ClientCloseHandler {
+	addr, err := dev.Endpoints().GetAddr(schema.UDPScheme)
+	require.NoError(t, err)
+	c, err := d.DialUDP(ctx, addr.String(), coap.WithBlockwise(true, blockwise.SZX1024, testTimeout))
+	require.NoError(t, err)
+	return c
+}
+
+func TestDialerForward(t *testing.T) {
+	dev := newTestDevice(t)
+	defer dev.Close()
+	d := faultinjection.NewDialer(faultinjection.WithRule(faultinjection.Always(faultinjection.ToClient, faultinjection.Fault{Action: faultinjection.Duplicate})))
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := dial(ctx, t, d, dev)
+	defer c.Close()
+
+	var device schema.Device
+	err := c.GetResource(ctx, "/oic/d", &device)
+	require.NoError(t, err)
+	require.Equal(t, "light", device.Name)
+	var b blob
+	err = c.GetResource(ctx, "/blob", &b)
+	require.NoError(t, err)
+	require.Len(t, b.Data, 4096)
+	require.Equal(t, 1, d.Connections())
+}
+
+func TestDialerDelay(t *testing.T) {
+	dev := newTestDevice(t)
+	defer dev.Close()
+	const delay = time.Millisecond * 200
+	d := faultinjection.NewDialer(faultinjection.WithRule(faultinjection.Always(faultinjection.ToClient, faultinjection.Fault{Action: faultinjection.Delay, Delay: delay})))
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := dial(ctx, t, d, dev)
+	defer c.Close()
+
+	start := time.Now()
+	var device schema.Device
+	err := c.GetResource(ctx, "/oic/d", &device)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(delay))
+}
+
+func TestDialerDrop(t *testing.T) {
+	dev := newTestDevice(t)
+	defer dev.Close()
+	d := faultinjection.NewDialer(faultinjection.WithRule(faultinjection.Always(faultinjection.ToClient, faultinjection.Fault{Action: faultinjection.Drop})))
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := dial(ctx, t, d, dev)
+	defer c.Close()
+
+	reqCtx, reqCancel := context.WithTimeout(ctx, time.Millisecond*300)
+	defer reqCancel()
+	var device schema.Device
+	err := c.GetResource(reqCtx, "/oic/d", &device)
+	require.Error(t, err)
+
+	// the rule applies to the existing connection
+	d.SetRule(nil)
+	err = c.GetResource(ctx, "/oic/d", &device)
+	require.NoError(t, err)
+}
+
+func TestDialerCorrupt(t *testing.T) {
+	dev := newTestDevice(t)
+	defer dev.Close()
+	// the last byte of the request is the last character of the path
+	d := faultinjection.NewDialer(faultinjection.WithRule(faultinjection.Nth(faultinjection.ToDevice, faultinjection.Fault{Action: faultinjection.Corrupt}, 0)))
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := dial(ctx, t, d, dev)
+	defer c.Close()
+
+	var device schema.Device
+	err := c.GetResource(ctx, "/oic/d", &device)
+	require.Error(t, err)
+	err = c.GetResource(ctx, "/oic/d", &device)
+	require.NoError(t, err)
+}
+
+func TestDialerCloseDuringBlockwise(t *testing.T) {
+	dev := newTestDevice(t)
+	defer dev.Close()
+	d := faultinjection.NewDialer(faultinjection.WithRule(faultinjection.Blockwise(faultinjection.ToDevice, faultinjection.Fault{Action: faultinjection.Close})))
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := dial(ctx, t, d, dev)
+	closed := make(chan struct{})
+	c.RegisterCloseHandler(func(error) {
+		close(closed)
+	})
+
+	var b blob
+	err := c.GetResource(ctx, "/blob", &b)
+	require.Error(t, err)
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+}
+
+func newCA(t *testing.T) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "RootCA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newSecureClient(t *testing.T, d *faultinjection.Dialer) *local.Client {
+	certPEM, keyPEM := newCA(t)
+	appCallback, err := app.NewApp(nil)
+	require.NoError(t, err)
+	cfg := local.Config{
+		DeviceOwnershipSDK: &local.DeviceOwnershipSDKConfig{
+			ID:      "00000000-0000-0000-0000-000000000001",
+			Cert:    string(certPEM),
+			CertKey: string(keyPEM),
+		},
+	}
+	deviceOwner, err := local.NewDeviceOwnerFromConfig(&cfg, d.DialTLS, d.DialDTLS, appCallback, test.NewIdentityCertificateSigner, func(error) {})
+	require.NoError(t, err)
+	c, err := local.NewClient(appCallback, deviceOwner, time.Hour, time.Second, func(error) {},
+		core.WithDialDTLS(d.DialDTLS),
+		core.WithDialTLS(d.DialTLS),
+		core.WithDialTCP(d.DialTCP),
+		core.WithDialUDP(d.DialUDP),
+	)
+	require.NoError(t, err)
+	err = c.Initialization(context.Background())
+	require.NoError(t, err)
+	return c
+}
+
+func TestDialerOwnRollback(t *testing.T) {
+	dev, err := test.NewDevice()
+	require.NoError(t, err)
+	defer dev.Close()
+	// the ownership transfer dials the device by just works at first, then by the identity certificate
+	d := faultinjection.NewDialer(faultinjection.WithHandshake(faultinjection.FailHandshakes(fmt.Errorf("injected failure"), 1)))
+	defer d.Close()
+	c := newSecureClient(t, d)
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	store := local.NewMemoryDeviceStore()
+	err = store.Store(ctx, local.DeviceRecord{ID: dev.ID(), Endpoints: dev.Endpoints()})
+	require.NoError(t, err)
+	c.SetDeviceStore(store)
+
+	_, err = c.OwnDevice(ctx, dev.ID(), local.WithOTM(local.OTMType_JustWorks))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "injected failure")
+	// the device was reset by the rollback
+	require.False(t, dev.Doxm().Owned)
+	require.Equal(t, schema.OperationalState_RFOTM, dev.ProvisionStatus().DeviceOnboardingState.CurrentOrPendingOperationalState)
+
+	d.SetHandshake(nil)
+	_, err = c.OwnDevice(ctx, dev.ID(), local.WithOTM(local.OTMType_JustWorks))
+	require.NoError(t, err)
+	require.True(t, dev.Doxm().Owned)
+}
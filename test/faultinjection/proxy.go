@@ -0,0 +1,348 @@
+package faultinjection
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	udpMessage "github.com/plgd-dev/go-coap/v2/udp/message"
+)
+
+// maxDatagramSize is the size of the buffer for one packet.
+const maxDatagramSize = 64 * 1024
+
+func loopbackAddr(addr string) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %v: %w", addr, err)
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.To4() == nil {
+		return "[::1]:0", nil
+	}
+	return "127.0.0.1:0", nil
+}
+
+func decodeMessage(data []byte) *Message {
+	m := udpMessage.Message{
+		Options: make(message.Options, 0, 32),
+	}
+	if _, err := m.Unmarshal(data); err != nil {
+		return nil
+	}
+	return &Message{
+		Code:    m.Code,
+		Token:   m.Token,
+		Options: m.Options,
+	}
+}
+
+func corrupt(data []byte) []byte {
+	res := append([]byte(nil), data...)
+	if len(res) > 0 {
+		res[len(res)-1] = ^res[len(res)-1]
+	}
+	return res
+}
+
+// proxy forwards packets of one connection between the client and the device.
+type proxy struct {
+	dialer *Dialer
+	conn   int
+	secure bool
+	closer func()
+
+	lock    sync.Mutex
+	indexes [2]int
+	onClose func()
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+func (p *proxy) newPacket(dir Direction, data []byte) Packet {
+	p.lock.Lock()
+	index := p.indexes[dir]
+	p.indexes[dir]++
+	p.lock.Unlock()
+	pkt := Packet{
+		Direction: dir,
+		Conn:      p.conn,
+		Index:     index,
+		Seq:       p.dialer.nextSeq(dir),
+		Secure:    p.secure,
+		Data:      data,
+	}
+	return pkt
+}
+
+// setOnClose sets the function which closes the client when the connection is closed by the Close action.
+func (p *proxy) setOnClose(onClose func()) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.onClose = onClose
+}
+
+// Close closes sockets of the proxy.
+func (p *proxy) Close() error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.lock.Unlock()
+	p.closer()
+	p.dialer.removeProxy(p.conn)
+	return nil
+}
+
+func (p *proxy) wait() {
+	p.wg.Wait()
+}
+
+// closeConnection closes the proxy and the client as the reaction to the Close action.
+func (p *proxy) closeConnection() {
+	p.lock.Lock()
+	onClose := p.onClose
+	p.lock.Unlock()
+	p.Close()
+	if onClose != nil {
+		go onClose()
+	}
+}
+
+type udpProxy struct {
+	proxy
+	listener *net.UDPConn
+	upstream *net.UDPConn
+
+	clientLock sync.Mutex
+	client     *net.UDPAddr
+}
+
+func newUDPProxy(d *Dialer, conn int, secure bool, addr string) (*udpProxy, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve address %v: %w", addr, err)
+	}
+	laddr, err := loopbackAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	la, err := net.ResolveUDPAddr("udp", laddr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve address %v: %w", laddr, err)
+	}
+	listener, err := net.ListenUDP("udp", la)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen: %w", err)
+	}
+	upstream, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("cannot dial %v: %w", addr, err)
+	}
+	p := &udpProxy{
+		listener: listener,
+		upstream: upstream,
+	}
+	p.proxy = proxy{
+		dialer: d,
+		conn:   conn,
+		secure: secure,
+		closer: func() {
+			listener.Close()
+			upstream.Close()
+		},
+	}
+	p.wg.Add(2)
+	go p.pump(ToDevice)
+	go p.pump(ToClient)
+	return p, nil
+}
+
+func (p *udpProxy) Addr() string {
+	return p.listener.LocalAddr().String()
+}
+
+func (p *udpProxy) getClient() *net.UDPAddr {
+	p.clientLock.Lock()
+	defer p.clientLock.Unlock()
+	return p.client
+}
+
+func (p *udpProxy) read(dir Direction, buf []byte) (int, error) {
+	if dir == ToClient {
+		return p.upstream.Read(buf)
+	}
+	n, addr, err := p.listener.ReadFromUDP(buf)
+	if err == nil {
+		p.clientLock.Lock()
+		p.client = addr
+		p.clientLock.Unlock()
+	}
+	return n, err
+}
+
+func (p *udpProxy) write(dir Direction, data []byte) {
+	var err error
+	if dir == ToClient {
+		client := p.getClient()
+		if client == nil {
+			return
+		}
+		_, err = p.listener.WriteToUDP(data, client)
+	} else {
+		_, err = p.upstream.Write(data)
+	}
+	if err != nil {
+		p.dialer.errors(fmt.Errorf("cannot forward packet %v of connection %v: %w", dir, p.conn, err))
+	}
+}
+
+func (p *udpProxy) pump(dir Direction) {
+	defer p.wg.Done()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := p.read(dir, buf)
+		if err != nil {
+			return
+		}
+		data := append([]byte(nil), buf[:n]...)
+		pkt := p.newPacket(dir, data)
+		if !p.secure {
+			pkt.Message = decodeMessage(data)
+		}
+		switch f := p.dialer.getRule()(pkt); f.Action {
+		case Drop:
+		case Delay:
+			time.AfterFunc(f.Delay, func() {
+				p.write(dir, data)
+			})
+		case Duplicate:
+			p.write(dir, data)
+			p.write(dir, data)
+		case Corrupt:
+			p.write(dir, corrupt(data))
+		case Close:
+			p.closeConnection()
+			return
+		default:
+			p.write(dir, data)
+		}
+	}
+}
+
+// tcpProxy forwards the stream of one connection. Chunks of the stream don't preserve boundaries
+// of messages, so Drop and Duplicate actions close the connection to keep the stream consistent.
+type tcpProxy struct {
+	proxy
+	listener net.Listener
+	addr     string
+
+	connsLock sync.Mutex
+	conns     []net.Conn
+}
+
+func newTCPProxy(d *Dialer, conn int, secure bool, addr string) (*tcpProxy, error) {
+	laddr, err := loopbackAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", laddr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen: %w", err)
+	}
+	p := &tcpProxy{
+		listener: listener,
+		addr:     addr,
+	}
+	p.proxy = proxy{
+		dialer: d,
+		conn:   conn,
+		secure: secure,
+		closer: func() {
+			listener.Close()
+			p.connsLock.Lock()
+			defer p.connsLock.Unlock()
+			for _, c := range p.conns {
+				c.Close()
+			}
+		},
+	}
+	p.wg.Add(1)
+	go p.accept()
+	return p, nil
+}
+
+func (p *tcpProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+func (p *tcpProxy) addConn(c net.Conn) bool {
+	p.lock.Lock()
+	closed := p.closed
+	p.lock.Unlock()
+	p.connsLock.Lock()
+	defer p.connsLock.Unlock()
+	if closed {
+		c.Close()
+		return false
+	}
+	p.conns = append(p.conns, c)
+	return true
+}
+
+func (p *tcpProxy) accept() {
+	defer p.wg.Done()
+	client, err := p.listener.Accept()
+	if err != nil {
+		return
+	}
+	// the proxy serves only one connection
+	p.listener.Close()
+	if !p.addConn(client) {
+		return
+	}
+	upstream, err := net.Dial("tcp", p.addr)
+	if err != nil {
+		p.dialer.errors(fmt.Errorf("cannot dial %v for connection %v: %w", p.addr, p.conn, err))
+		p.closeConnection()
+		return
+	}
+	if !p.addConn(upstream) {
+		return
+	}
+	p.wg.Add(2)
+	go p.pump(ToDevice, client, upstream)
+	go p.pump(ToClient, upstream, client)
+}
+
+func (p *tcpProxy) pump(dir Direction, from, to net.Conn) {
+	defer p.wg.Done()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := from.Read(buf)
+		if err != nil {
+			p.closeConnection()
+			return
+		}
+		data := append([]byte(nil), buf[:n]...)
+		f := p.dialer.getRule()(p.newPacket(dir, data))
+		switch f.Action {
+		case Delay:
+			time.Sleep(f.Delay)
+		case Corrupt:
+			data = corrupt(data)
+		case Drop, Duplicate, Close:
+			p.closeConnection()
+			return
+		}
+		if _, err := to.Write(data); err != nil {
+			p.closeConnection()
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package faultinjection
+
+import (
+	"time"
+
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+)
+
+// Direction of the packet passing the proxy.
+type Direction int
+
+const (
+	// ToDevice packets are sent by the client.
+	ToDevice Direction = iota
+	// ToClient packets are sent by the device.
+	ToClient
+)
+
+func (d Direction) String() string {
+	if d == ToClient {
+		return "ToClient"
+	}
+	return "ToDevice"
+}
+
+// Action is what the proxy does with the packet.
+type Action int
+
+const (
+	// Forward passes the packet unchanged.
+	Forward Action = iota
+	// Drop discards the packet.
+	Drop
+	// Delay passes the packet after Fault.Delay.
+	Delay
+	// Duplicate passes the packet twice.
+	Duplicate
+	// Corrupt passes the packet with inverted bits of the last byte.
+	Corrupt
+	// Close closes the connection, the client is closed as if the device closed the connection.
+	Close
+)
+
+// Fault is applied to the packet.
+type Fault struct {
+	Action Action
+	// Delay of the Delay action.
+	Delay time.Duration
+}
+
+// Message is the CoAP message of the packet.
+type Message struct {
+	Code    codes.Code
+	Token   message.Token
+	Options message.Options
+}
+
+// IsBlockwise returns true when the message is not the first block of the blockwise transfer.
+func (m *Message) IsBlockwise() bool {
+	for _, id := range []message.OptionID{message.Block1, message.Block2} {
+		if v, err := m.Options.GetUint32(id); err == nil && v>>4 > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Packet is the datagram of UDP and DTLS connections or the chunk of the TCP and TLS stream.
+type Packet struct {
+	Direction Direction
+	// Conn is the number of the connection dialed by the Dialer, starting from 0.
+	Conn int
+	// Index is the number of the packet in its direction within the connection, starting from 0.
+	Index int
+	// Seq is the number of the packet in its direction within all connections of the Dialer, starting from 0.
+	Seq int
+	// Secure is true for DTLS and TLS connections.
+	Secure bool
+	Data   []byte
+	// Message is set for UDP connections when the packet is the CoAP message.
+	Message *Message
+}
+
+// RuleFunc decides what happens with the packet.
+type RuleFunc = func(p Packet) Fault
+
+// HandshakeFunc fails the handshake of the n-th DTLS or TLS connection of the Dialer (starting from 0) when it returns the error.
+type HandshakeFunc = func(n int, addr string) error
+
+// Rules applies the first rule which doesn't forward the packet.
+func Rules(rules ...RuleFunc) RuleFunc {
+	return func(p Packet) Fault {
+		for _, r := range rules {
+			if f := r(p); f.Action != Forward {
+				return f
+			}
+		}
+		return Fault{}
+	}
+}
+
+// Always applies the fault to all packets in the direction.
+func Always(dir Direction, fault Fault) RuleFunc {
+	return func(p Packet) Fault {
+		if p.Direction == dir {
+			return fault
+		}
+		return Fault{}
+	}
+}
+
+// Nth applies the fault to packets in the direction with sequence numbers seqs.
+func Nth(dir Direction, fault Fault, seqs ...int) RuleFunc {
+	return func(p Packet) Fault {
+		if p.Direction != dir {
+			return Fault{}
+		}
+		for _, s := range seqs {
+			if s == p.Seq {
+				return fault
+			}
+		}
+		return Fault{}
+	}
+}
+
+// OnConnection applies the rule only to packets of the n-th connection.
+func OnConnection(n int, rule RuleFunc) RuleFunc {
+	return func(p Packet) Fault {
+		if p.Conn == n {
+			return rule(p)
+		}
+		return Fault{}
+	}
+}
+
+// Blockwise applies the fault to CoAP messages in the direction which continue the blockwise transfer.
+func Blockwise(dir Direction, fault Fault) RuleFunc {
+	return func(p Packet) Fault {
+		if p.Direction == dir && p.Message != nil && p.Message.IsBlockwise() {
+			return fault
+		}
+		return Fault{}
+	}
+}
+
+// FailHandshakes fails handshakes of secure connections with numbers conns.
+func FailHandshakes(err error, conns ...int) HandshakeFunc {
+	return func(n int, addr string) error {
+		for _, c := range conns {
+			if c == n {
+				return err
+			}
+		}
+		return nil
+	}
+}